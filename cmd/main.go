@@ -2,11 +2,19 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"log/slog"
 	"os"
+	"time"
 
 	configloader "github.com/Oyatillohgayratov/config-loader"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/audit"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/bruteforce"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/clientip"
 	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/config"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/email"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/jwt"
 	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/server"
 	"github.com/Oyatillohgayratov/fitness-tracking-app/router"
 	"github.com/Oyatillohgayratov/fitness-tracking-app/storage"
@@ -46,7 +54,45 @@ func main() {
 	}
 	queries = storage.New(db)
 
-	mux := router.NewMux(logger, queries)
+	authConfig := cfg.Auth.WithDefaults()
+	go purgeUnverifiedUsers(ctx, queries, authConfig.UnverifiedTTL)
+	auditConfig := cfg.Audit.WithDefaults()
+	if auditConfig.Retention < audit.MinRetention {
+		logger.Error("Audit retention is too short", "retention", auditConfig.Retention, "minimum", audit.MinRetention)
+		os.Exit(1)
+	}
+	go purgeAuditEvents(ctx, queries, auditConfig.Retention)
+
+	sender, err := newSender(cfg.Email.WithDefaults())
+	if err != nil {
+		logger.Error("Failed to configure email", "error", err)
+		os.Exit(1)
+	}
+	mail := email.NewQueue(sender, logger, 100)
+	go mail.Run(ctx)
+
+	tokens, err := jwt.New(cfg.JWT.WithDefaults())
+	if err != nil {
+		logger.Error("Failed to load JWT keys", "error", err)
+		os.Exit(1)
+	}
+
+	bruteForceConfig := cfg.BruteForce.WithDefaults()
+	attempts, err := newAttemptStore(bruteForceConfig, db.DB)
+	if err != nil {
+		logger.Error("Failed to configure brute-force protection", "error", err)
+		os.Exit(1)
+	}
+	go purgeLoginAttempts(ctx, attempts, bruteForceConfig.Window)
+	limiter := bruteforce.NewLimiter(attempts, bruteForceConfig)
+
+	trustedProxies, err := clientip.ParseTrusted(cfg.Server.TrustedProxies)
+	if err != nil {
+		logger.Error("Failed to configure trusted proxies", "error", err)
+		os.Exit(1)
+	}
+
+	mux := router.NewMux(logger, db.DB, queries, authConfig, mail, tokens, limiter, trustedProxies)
 
 	srv := server.New(cfg.GetHostPrort(), mux, *logger)
 	if err := srv.Run(); err != nil {
@@ -55,6 +101,106 @@ func main() {
 	}
 }
 
+func newSender(cfg config.Email) (email.Sender, error) {
+	switch cfg.Transport {
+	case "smtp":
+		return email.SMTPSender{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.From,
+			TLS:      cfg.SMTP.TLS,
+		}, nil
+	case "file":
+		return email.FileSender{Dir: cfg.Dir, From: cfg.From}, nil
+	case "memory":
+		return &email.MemorySender{}, nil
+	default:
+		return nil, fmt.Errorf("unknown email transport %q", cfg.Transport)
+	}
+}
+
+func newAttemptStore(cfg config.BruteForce, db *sql.DB) (bruteforce.Store, error) {
+	switch cfg.Store {
+	case "memory":
+		return bruteforce.NewMemoryStore(), nil
+	case "postgres":
+		return bruteforce.NewPostgresStore(db), nil
+	default:
+		return nil, fmt.Errorf("unknown brute-force store %q", cfg.Store)
+	}
+}
+
+// purgeUnverifiedUsers deletes accounts that were not verified within ttl,
+// checking every hour.
+func purgeUnverifiedUsers(ctx context.Context, queries *storage.Queries, ttl time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		n, err := queries.PurgeUnverifiedUsers(ctx, time.Now().Add(-ttl))
+		if err != nil {
+			logger.Error("Failed to purge unverified users", "error", err)
+		} else if n > 0 {
+			logger.Info("Purged unverified users", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeAuditEvents deletes audit events older than retention, checking once
+// a day. The database refuses to delete younger events, so it is told the
+// retention first; it refuses a retention below audit.MinRetention too.
+func purgeAuditEvents(ctx context.Context, queries *storage.Queries, retention time.Duration) {
+	if err := queries.SetAuditRetention(ctx, int64(retention.Seconds())); err != nil {
+		logger.Error("Failed to set audit retention", "error", err)
+		return
+	}
+
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+	for {
+		n, err := queries.PurgeAuditEvents(ctx)
+		if err != nil {
+			logger.Error("Failed to purge audit events", "error", err)
+		} else if n > 0 {
+			logger.Info("Purged audit events", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeLoginAttempts forgets failed login and password reset counters that
+// have not grown within window, checking every hour.
+func purgeLoginAttempts(ctx context.Context, store bruteforce.Store, window time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		n, err := store.Purge(ctx, time.Now().Add(-window))
+		if err != nil {
+			logger.Error("Failed to purge login attempts", "error", err)
+		} else if n > 0 {
+			logger.Info("Purged login attempts", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 // func ListUsers(w http.ResponseWriter, r *http.Request) {
 // 	ctx := context.Background()
 // 	users, err := queries.ListUser(ctx)
@@ -1,8 +1,143 @@
+// Package errors defines the domain errors handlers return to clients. Each
+// error carries one of the kinds below, which decides the HTTP status it is
+// reported with.
 package errors
 
-import "errors"
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
 
-var ( 
-	ErrDecodeUserRegister = errors.New("failed to decode user register")
+	"github.com/lib/pq"
+)
 
-)
\ No newline at end of file
+// Kinds of domain errors. Match them with errors.Is.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrTooLarge     = errors.New("request too large")
+	ErrInternal     = errors.New("internal error")
+)
+
+// FieldError describes why a single request field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is a domain error with a message safe to show to clients.
+type Error struct {
+	Kind    error
+	Message string
+	// Fields lists the offending fields of a validation error.
+	Fields []FieldError
+	// RetryAfter is how long a rate limited client should wait.
+	RetryAfter time.Duration
+	// Err is the underlying cause, kept for logging only.
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+// Wrap returns a copy of e with err recorded as its cause.
+func (e *Error) Wrap(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+func NotFound(msg string) *Error {
+	return &Error{Kind: ErrNotFound, Message: msg}
+}
+
+func Conflict(msg string) *Error {
+	return &Error{Kind: ErrConflict, Message: msg}
+}
+
+// Invalid reports a malformed request or invalid fields.
+func Invalid(msg string, fields ...FieldError) *Error {
+	return &Error{Kind: ErrValidation, Message: msg, Fields: fields}
+}
+
+// Field is shorthand for an Invalid error about a single field.
+func Field(field, msg string) *Error {
+	return Invalid(fmt.Sprintf("invalid %s: %s", field, msg), FieldError{Field: field, Message: msg})
+}
+
+func Unauthorized(msg string) *Error {
+	return &Error{Kind: ErrUnauthorized, Message: msg}
+}
+
+func Forbidden(msg string) *Error {
+	return &Error{Kind: ErrForbidden, Message: msg}
+}
+
+func RateLimited(msg string, retryAfter time.Duration) *Error {
+	return &Error{Kind: ErrRateLimited, Message: msg, RetryAfter: retryAfter}
+}
+
+func TooLarge(msg string) *Error {
+	return &Error{Kind: ErrTooLarge, Message: msg}
+}
+
+// Internal reports an unexpected failure. msg is shown to the client, the
+// cause should be attached with Wrap.
+func Internal(msg string) *Error {
+	return &Error{Kind: ErrInternal, Message: msg}
+}
+
+// FromDB maps database errors to domain errors: a missing row becomes a not
+// found error about resource and a unique violation becomes a conflict.
+// Other errors are returned as internal errors.
+func FromDB(err error, resource string) *Error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return NotFound(resource + " not found").Wrap(err)
+	case IsUniqueViolation(err):
+		return Conflict(resource + " already exists").Wrap(err)
+	default:
+		return Internal("failed to access " + resource).Wrap(err)
+	}
+}
+
+// IsUniqueViolation reports whether err was caused by a unique constraint.
+func IsUniqueViolation(err error) bool {
+	return UniqueConstraint(err) != ""
+}
+
+// UniqueConstraint returns the name of the unique constraint or index err
+// violated, or "" when err is not a unique violation.
+func UniqueConstraint(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return pqErr.Constraint
+	}
+	return ""
+}
+
+// As returns err as a domain error, treating errors of any other type as
+// internal errors.
+func As(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return Internal("internal server error").Wrap(err)
+}
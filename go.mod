@@ -4,7 +4,7 @@ go 1.22.2
 
 require (
 	github.com/Oyatillohgayratov/config-loader v0.0.0-20240904161608-22afd4e6ae17
-	github.com/dgrijalva/jwt-go v3.2.0+incompatible
+	github.com/golang-jwt/jwt/v5 v5.2.1
 	github.com/lib/pq v1.10.9
 	github.com/sqlc-dev/pqtype v0.3.0
 	golang.org/x/crypto v0.27.0
@@ -0,0 +1,128 @@
+package analytics
+
+import "time"
+
+const (
+	Day   = "day"
+	Week  = "week"
+	Month = "month"
+)
+
+// ValidBucket reports whether bucket is one of Day, Week or Month.
+func ValidBucket(bucket string) bool {
+	return bucket == Day || bucket == Week || bucket == Month
+}
+
+// Truncate returns the start of the bucket date falls in, matching Postgres
+// date_trunc: weeks start on Monday.
+func Truncate(date time.Time, bucket string) time.Time {
+	y, m, d := date.Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	switch bucket {
+	case Week:
+		offset := (int(start.Weekday()) + 6) % 7
+		return start.AddDate(0, 0, -offset)
+	case Month:
+		return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return start
+}
+
+// Buckets returns the start of every bucket between from and to, inclusive,
+// so that series have an entry for periods without any training.
+func Buckets(from, to time.Time, bucket string) []time.Time {
+	var starts []time.Time
+	end := Truncate(to, bucket)
+	for t := Truncate(from, bucket); !t.After(end); t = next(t, bucket) {
+		starts = append(starts, t)
+	}
+	return starts
+}
+
+func next(t time.Time, bucket string) time.Time {
+	switch bucket {
+	case Week:
+		return t.AddDate(0, 0, 7)
+	case Month:
+		return t.AddDate(0, 1, 0)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+// LinearTrend fits a line through ys by ordinary least squares, with x being
+// the index of the bucket, and returns its slope (change per bucket) and
+// intercept.
+func LinearTrend(ys []float64) (slope, intercept float64) {
+	n := float64(len(ys))
+	if n == 0 {
+		return 0, 0
+	}
+	if n == 1 {
+		return 0, ys[0]
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	for i, y := range ys {
+		x := float64(i)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	slope = (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+	return slope, (sumY - slope*sumX) / n
+}
+
+// Streaks returns the length of the run of consecutive non-empty buckets that
+// ends with the last one, and of the longest run. The last bucket may still be
+// in progress, so it being empty does not break the current streak.
+func Streaks(counts []int32) (current, longest int) {
+	run := 0
+	for _, n := range counts {
+		if n == 0 {
+			run = 0
+			continue
+		}
+		run++
+		longest = max(longest, run)
+	}
+
+	end := len(counts)
+	if end > 0 && counts[end-1] == 0 {
+		end--
+	}
+	for i := end - 1; i >= 0 && counts[i] > 0; i-- {
+		current++
+	}
+	return current, longest
+}
+
+// SetRange is a recommended number of hard sets per muscle group per week.
+type SetRange struct {
+	Min float64
+	Max float64
+}
+
+// defaultSetRange follows the common 10-20 weekly sets guideline for
+// hypertrophy; smaller or heavily indirectly trained groups need less.
+var defaultSetRange = SetRange{Min: 10, Max: 20}
+
+var setRanges = map[string]SetRange{
+	"forearms":   {Min: 4, Max: 12},
+	"calves":     {Min: 6, Max: 16},
+	"abs":        {Min: 6, Max: 16},
+	"obliques":   {Min: 4, Max: 12},
+	"lower_back": {Min: 4, Max: 10},
+	"adductors":  {Min: 4, Max: 12},
+	"abductors":  {Min: 4, Max: 12},
+	"traps":      {Min: 6, Max: 16},
+	"full_body":  {Min: 0, Max: 0},
+}
+
+// RecommendedSets returns the recommended weekly set range of a muscle group.
+func RecommendedSets(muscle string) SetRange {
+	if r, ok := setRanges[muscle]; ok {
+		return r
+	}
+	return defaultSetRange
+}
@@ -0,0 +1,129 @@
+package analytics
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestValidBucket(t *testing.T) {
+	for bucket, want := range map[string]bool{Day: true, Week: true, Month: true, "year": false, "": false} {
+		if got := ValidBucket(bucket); got != want {
+			t.Errorf("ValidBucket(%q) = %v, want %v", bucket, got, want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		date, bucket, want string
+	}{
+		{"2024-05-01", Day, "2024-05-01"},
+		{"2024-05-01", Week, "2024-04-29"},
+		{"2024-04-29", Week, "2024-04-29"},
+		{"2024-05-05", Week, "2024-04-29"},
+		{"2024-01-03", Week, "2024-01-01"},
+		{"2025-01-01", Week, "2024-12-30"},
+		{"2024-05-31", Month, "2024-05-01"},
+		{"2024-02-29", Month, "2024-02-01"},
+	}
+	for _, tt := range tests {
+		if got := Truncate(date(tt.date), tt.bucket); !got.Equal(date(tt.want)) {
+			t.Errorf("Truncate(%s, %s) = %s, want %s", tt.date, tt.bucket, got.Format(time.DateOnly), tt.want)
+		}
+	}
+
+	// The time of day and location do not matter, only the calendar date.
+	late := time.Date(2024, 5, 5, 23, 30, 0, 0, time.FixedZone("EST", -5*3600))
+	if got := Truncate(late, Day); !got.Equal(date("2024-05-05")) {
+		t.Errorf("Truncate(%s, day) = %s", late, got)
+	}
+}
+
+func TestBuckets(t *testing.T) {
+	tests := []struct {
+		name, from, to, bucket string
+		want                   []string
+	}{
+		{"days", "2024-02-28", "2024-03-01", Day, []string{"2024-02-28", "2024-02-29", "2024-03-01"}},
+		{"weeks", "2024-05-01", "2024-05-13", Week, []string{"2024-04-29", "2024-05-06", "2024-05-13"}},
+		{"months from the end of one", "2024-01-31", "2024-03-02", Month, []string{"2024-01-01", "2024-02-01", "2024-03-01"}},
+		{"single", "2024-05-01", "2024-05-01", Month, []string{"2024-05-01"}},
+		{"to before from", "2024-05-02", "2024-05-01", Day, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, b := range Buckets(date(tt.from), date(tt.to), tt.bucket) {
+				got = append(got, b.Format(time.DateOnly))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Buckets = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinearTrend(t *testing.T) {
+	tests := []struct {
+		name             string
+		ys               []float64
+		slope, intercept float64
+	}{
+		{"empty", nil, 0, 0},
+		{"single", []float64{7}, 0, 7},
+		{"line", []float64{1, 3, 5, 7}, 2, 1},
+		{"flat", []float64{4, 4, 4}, 0, 4},
+		{"falling", []float64{10, 8, 6}, -2, 10},
+		{"noisy", []float64{1, 2, 2, 3}, 0.6, 1.1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slope, intercept := LinearTrend(tt.ys)
+			if math.Abs(slope-tt.slope) > 1e-9 || math.Abs(intercept-tt.intercept) > 1e-9 {
+				t.Errorf("LinearTrend(%v) = %v, %v, want %v, %v", tt.ys, slope, intercept, tt.slope, tt.intercept)
+			}
+		})
+	}
+}
+
+func TestStreaks(t *testing.T) {
+	tests := []struct {
+		name             string
+		counts           []int32
+		current, longest int
+	}{
+		{"empty", nil, 0, 0},
+		{"none", []int32{0, 0}, 0, 0},
+		{"all", []int32{1, 2, 1}, 3, 3},
+		{"broken", []int32{1, 1, 1, 0, 2, 1}, 2, 3},
+		{"last in progress", []int32{0, 1, 1, 0}, 2, 2},
+		{"ended", []int32{1, 0, 0}, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current, longest := Streaks(tt.counts)
+			if current != tt.current || longest != tt.longest {
+				t.Errorf("Streaks(%v) = %d, %d, want %d, %d", tt.counts, current, longest, tt.current, tt.longest)
+			}
+		})
+	}
+}
+
+func TestRecommendedSets(t *testing.T) {
+	if got := RecommendedSets("calves"); got != (SetRange{Min: 6, Max: 16}) {
+		t.Errorf("RecommendedSets(calves) = %+v", got)
+	}
+	if got := RecommendedSets("chest"); got != defaultSetRange {
+		t.Errorf("RecommendedSets(chest) = %+v, want the default %+v", got, defaultSetRange)
+	}
+}
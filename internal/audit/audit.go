@@ -0,0 +1,145 @@
+// Package audit records security sensitive and data changing actions in the
+// append-only audit_events table.
+package audit
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/clientip"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/requestid"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/storage"
+	"github.com/sqlc-dev/pqtype"
+)
+
+// MinRetention is the shortest time audit events can be kept for. The
+// database refuses a shorter retention, so a misconfiguration cannot purge
+// recent events.
+const MinRetention = 90 * 24 * time.Hour
+
+// Actions. The part before the dot names the kind of target.
+const (
+	ActionLogin         = "user.login"
+	ActionLoginFailed   = "user.login_failed"
+	ActionLockout       = "user.lockout"
+	ActionUserUpdate    = "user.update"
+	ActionUserDelete    = "user.delete"
+	ActionPasswordReset = "user.password_reset"
+	ActionTOTPEnable    = "user.totp_enable"
+	ActionTOTPDisable   = "user.totp_disable"
+	ActionWorkoutDelete = "workout.delete"
+
+	ActionAdminListUsers     = "admin.list_users"
+	ActionAdminSuspend       = "admin.suspend"
+	ActionAdminUnsuspend     = "admin.unsuspend"
+	ActionAdminResetPassword = "admin.reset_password"
+	ActionAdminChangeRole    = "admin.change_role"
+)
+
+// Target types.
+const (
+	TargetUser    = "user"
+	TargetWorkout = "workout"
+)
+
+// Event describes an action. ActorID and TargetID are 0 when there is no
+// authenticated actor or no target.
+type Event struct {
+	ActorID    int32
+	Action     string
+	TargetType string
+	TargetID   int32
+	// Details is stored as JSON, usually the result of Changes.
+	Details any
+}
+
+// Record stores e together with the client address, user agent and request
+// id of r. Callers changing data record the event in the same transaction,
+// so that the change and its record are committed together.
+func Record(r *http.Request, q *storage.Queries, e Event) error {
+	params := storage.CreateAuditEventParams{
+		ActorID:    sql.NullInt32{Int32: e.ActorID, Valid: e.ActorID != 0},
+		Action:     e.Action,
+		TargetType: sql.NullString{String: e.TargetType, Valid: e.TargetType != ""},
+		TargetID:   sql.NullInt32{Int32: e.TargetID, Valid: e.TargetID != 0},
+		Ip:         nullString(clientip.FromRequest(r)),
+		UserAgent:  nullString(r.UserAgent()),
+		RequestID:  nullString(requestid.FromContext(r.Context())),
+	}
+	if e.Details != nil {
+		b, err := json.Marshal(e.Details)
+		if err != nil {
+			return err
+		}
+		params.Details = pqtype.NullRawMessage{RawMessage: b, Valid: true}
+	}
+	return q.CreateAuditEvent(r.Context(), params)
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// Change is the value of a field before and after an action.
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// Changes compares the JSON encodings of before and after and returns the
+// fields that differ. Nested objects are compared field by field and
+// reported with dotted names such as profile.units. Either side may be nil
+// to describe a creation or a deletion.
+func Changes(before, after any) (map[string]Change, error) {
+	b, err := flatten(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := flatten(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]Change{}
+	for k, v := range b {
+		if !reflect.DeepEqual(v, a[k]) {
+			changes[k] = Change{Before: v, After: a[k]}
+		}
+	}
+	for k, v := range a {
+		if _, ok := b[k]; !ok {
+			changes[k] = Change{Before: nil, After: v}
+		}
+	}
+	return changes, nil
+}
+
+func flatten(v any) (map[string]any, error) {
+	fields := map[string]any{}
+	if v == nil {
+		return fields, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, err
+	}
+	flattenInto(fields, "", obj)
+	return fields, nil
+}
+
+func flattenInto(fields map[string]any, prefix string, obj map[string]any) {
+	for k, v := range obj {
+		if nested, ok := v.(map[string]any); ok {
+			flattenInto(fields, prefix+k+".", nested)
+			continue
+		}
+		fields[prefix+k] = v
+	}
+}
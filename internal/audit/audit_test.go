@@ -0,0 +1,152 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/config"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/storage"
+	"github.com/sqlc-dev/pqtype"
+)
+
+type profile struct {
+	Units string `json:"units,omitempty"`
+	Sex   string `json:"sex,omitempty"`
+}
+
+type user struct {
+	ID       int      `json:"id"`
+	Username string   `json:"username"`
+	Profile  *profile `json:"profile,omitempty"`
+}
+
+func TestChanges(t *testing.T) {
+	ann := user{ID: 1, Username: "ann", Profile: &profile{Units: "metric"}}
+	tests := []struct {
+		name          string
+		before, after any
+		want          map[string]Change
+	}{
+		{"unchanged", ann, ann, map[string]Change{}},
+		{"field", ann, user{ID: 1, Username: "anna", Profile: &profile{Units: "metric"}}, map[string]Change{
+			"username": {Before: "ann", After: "anna"},
+		}},
+		{"nested field", ann, user{ID: 1, Username: "ann", Profile: &profile{Units: "imperial", Sex: "female"}}, map[string]Change{
+			"profile.units": {Before: "metric", After: "imperial"},
+			"profile.sex":   {Before: nil, After: "female"},
+		}},
+		{"nested object removed", ann, user{ID: 1, Username: "ann"}, map[string]Change{
+			"profile.units": {Before: "metric", After: nil},
+		}},
+		{"creation", nil, user{ID: 2, Username: "bob"}, map[string]Change{
+			"id":       {Before: nil, After: 2.0},
+			"username": {Before: nil, After: "bob"},
+		}},
+		{"deletion", user{ID: 2, Username: "bob"}, nil, map[string]Change{
+			"id":       {Before: 2.0, After: nil},
+			"username": {Before: "bob", After: nil},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Changes(tt.before, tt.after)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Changes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangesRejectsNonObjects(t *testing.T) {
+	if _, err := Changes([]int{1}, nil); err == nil {
+		t.Error("Changes accepted a slice")
+	}
+	if _, err := Changes(nil, func() {}); err == nil {
+		t.Error("Changes accepted a function")
+	}
+}
+
+// recorder stands in for the database and keeps the arguments of the
+// statements it is given.
+type recorder struct {
+	storage.DBTX
+	args []any
+}
+
+func (r *recorder) ExecContext(_ context.Context, _ string, args ...any) (sql.Result, error) {
+	r.args = args
+	return nil, nil
+}
+
+func TestRecord(t *testing.T) {
+	r := httptest.NewRequest("POST", "/admin/users/2/suspend", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	r.Header.Set("User-Agent", "test")
+
+	db := &recorder{}
+	err := Record(r, storage.New(db), Event{
+		ActorID:    1,
+		Action:     ActionAdminSuspend,
+		TargetType: TargetUser,
+		TargetID:   2,
+		Details:    map[string]Change{"suspended": {Before: false, After: true}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []any{
+		sql.NullInt32{Int32: 1, Valid: true},
+		ActionAdminSuspend,
+		sql.NullString{String: TargetUser, Valid: true},
+		sql.NullInt32{Int32: 2, Valid: true},
+		sql.NullString{String: "192.0.2.1", Valid: true},
+		sql.NullString{String: "test", Valid: true},
+		sql.NullString{},
+		pqtype.NullRawMessage{RawMessage: json.RawMessage(`{"suspended":{"before":false,"after":true}}`), Valid: true},
+	}
+	if !reflect.DeepEqual(db.args, want) {
+		t.Errorf("Record stored %v, want %v", db.args, want)
+	}
+
+	// Events without an actor, target or details store none.
+	if err := Record(r, storage.New(db), Event{Action: ActionLoginFailed}); err != nil {
+		t.Fatal(err)
+	}
+	for _, i := range []int{0, 2, 3, 7} {
+		if v := reflect.ValueOf(db.args[i]); v.FieldByName("Valid").Bool() {
+			t.Errorf("argument %d = %v, want null", i, db.args[i])
+		}
+	}
+}
+
+// The database and the app must agree on the minimum retention, and the
+// default has to respect it.
+func TestMinRetention(t *testing.T) {
+	migration, err := os.ReadFile("../../migrations/024_limit_audit_retention.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := regexp.MustCompile(`CHECK \(retention >= interval '(\d+) days'\)`).FindSubmatch(migration)
+	if m == nil {
+		t.Fatal("migration does not check the retention")
+	}
+	days, _ := strconv.Atoi(string(m[1]))
+	if got := time.Duration(days) * 24 * time.Hour; got != MinRetention {
+		t.Errorf("migration minimum = %v, want %v", got, MinRetention)
+	}
+
+	if got := (config.Audit{}).WithDefaults().Retention; got < MinRetention {
+		t.Errorf("default retention %v is below the minimum %v", got, MinRetention)
+	}
+}
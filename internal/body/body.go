@@ -0,0 +1,46 @@
+package body
+
+import "time"
+
+// BMI returns the body mass index for a weight in kilograms and a height in
+// centimetres.
+func BMI(weightKg, heightCm float64) float64 {
+	m := heightCm / 100
+	return weightKg / (m * m)
+}
+
+// FFMI returns the fat-free mass index and its value normalized to a height of
+// 1.8 m, which makes it comparable between people of different heights.
+func FFMI(weightKg, bodyFatPercent, heightCm float64) (ffmi, normalized float64) {
+	m := heightCm / 100
+	lean := weightKg * (1 - bodyFatPercent/100)
+	ffmi = lean / (m * m)
+	return ffmi, ffmi + 6.1*(1.8-m)
+}
+
+// Sample is a single dated value of a time series.
+type Sample struct {
+	Date  time.Time
+	Value float64
+}
+
+// MovingAverage smooths samples, which must be sorted by date, with a
+// trailing window of the given number of days: every result is the mean of
+// the samples taken on that day and the window-1 days before it. Weigh-ins
+// are rarely daily, so the window is based on dates rather than on a number
+// of samples.
+func MovingAverage(samples []Sample, window int) []float64 {
+	avg := make([]float64, len(samples))
+	start := 0
+	var sum float64
+	for i, s := range samples {
+		sum += s.Value
+		cutoff := s.Date.AddDate(0, 0, -window)
+		for !samples[start].Date.After(cutoff) {
+			sum -= samples[start].Value
+			start++
+		}
+		avg[i] = sum / float64(i-start+1)
+	}
+	return avg
+}
@@ -0,0 +1,220 @@
+// Package bruteforce slows down password guessing. It counts failed attempts
+// per key, such as an account or a client address, makes every attempt past
+// a free allowance wait exponentially longer and locks keys out for a while
+// when they keep failing.
+package bruteforce
+
+import (
+	"context"
+	"time"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/config"
+)
+
+// Policy decides how failures of a key are punished.
+type Policy struct {
+	// FreeAttempts failures are allowed before attempts are delayed.
+	FreeAttempts int
+	// BaseDelay is the delay after the last free failure. It doubles with
+	// every further failure up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Every LockoutThreshold failures lock the key for LockoutDuration. 0
+	// disables lockouts.
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+	// Window is how long failures are remembered after the last one.
+	Window time.Duration
+}
+
+// delay returns how long to wait after the given number of failures.
+func (p Policy) delay(failures int) time.Duration {
+	n := failures - p.FreeAttempts + 1
+	if failures == 0 || n <= 0 {
+		return 0
+	}
+	if n > 30 {
+		return p.MaxDelay
+	}
+	return min(p.BaseDelay<<(n-1), p.MaxDelay)
+}
+
+// Attempt is the outcome of reserving an attempt.
+type Attempt struct {
+	// Wait is how long to wait before trying again. It is only set when the
+	// attempt was refused, and then nothing was counted.
+	Wait time.Duration
+	// Failures is the number of failures of the key, this attempt included.
+	Failures int
+	// LockedUntil is set when this attempt locked the key out.
+	LockedUntil time.Time
+}
+
+// Guard applies a Policy to the keys with a given prefix in a Store.
+type Guard struct {
+	store  Store
+	prefix string
+	policy Policy
+}
+
+// NewGuard returns a guard keeping its counters in store under prefix.
+func NewGuard(store Store, prefix string, policy Policy) *Guard {
+	return &Guard{store: store, prefix: prefix, policy: policy}
+}
+
+// Reserve counts an attempt of key as failed before it is made, so that
+// parallel attempts cannot all get through before the first of them fails.
+// Release or Reset take the failure back when the attempt succeeds. When key
+// has to wait, Reserve counts nothing and returns the wait instead.
+func (g *Guard) Reserve(ctx context.Context, key string) (Attempt, error) {
+	// Postgres keeps microseconds; truncating lets Release recognize the
+	// lockout of an attempt after a round trip.
+	now := time.Now().Truncate(time.Microsecond)
+	var a Attempt
+	err := g.store.Update(ctx, g.prefix+key, func(s State) State {
+		a = Attempt{}
+		if s.LastFailure.Before(now.Add(-g.policy.Window)) {
+			s = State{}
+		}
+		// Without failures there is nothing to wait for, whatever time a
+		// store gives a key it has just created.
+		var until time.Time
+		if s.Failures > 0 {
+			until = s.LastFailure.Add(g.policy.delay(s.Failures))
+		}
+		if s.LockedUntil.After(until) {
+			until = s.LockedUntil
+		}
+		if until.After(now) {
+			a.Wait = until.Sub(now)
+			return s
+		}
+
+		s.Failures++
+		s.LastFailure = now
+		a.Failures = s.Failures
+		if g.policy.LockoutThreshold > 0 && s.Failures%g.policy.LockoutThreshold == 0 {
+			s.LockedUntil = now.Add(g.policy.LockoutDuration)
+			a.LockedUntil = s.LockedUntil
+		}
+		return s
+	})
+	return a, err
+}
+
+// Release takes back the failure counted by a, keeping earlier ones, and
+// lifts the lockout a caused.
+func (g *Guard) Release(ctx context.Context, key string, a Attempt) error {
+	return g.store.Update(ctx, g.prefix+key, func(s State) State {
+		if s.Failures > 0 {
+			s.Failures--
+		}
+		if !a.LockedUntil.IsZero() && s.LockedUntil.Equal(a.LockedUntil) {
+			s.LockedUntil = time.Time{}
+		}
+		return s
+	})
+}
+
+// Reset forgets the failures of key.
+func (g *Guard) Reset(ctx context.Context, key string) error {
+	return g.store.Reset(ctx, g.prefix+key)
+}
+
+// Limiter throttles logins and password reset requests, both per account
+// and per client address. Accounts are keyed by their normalized email, so
+// unknown emails are throttled like existing ones and responses do not tell
+// them apart.
+type Limiter struct {
+	accounts       *Guard
+	addresses      *Guard
+	resetAccounts  *Guard
+	resetAddresses *Guard
+}
+
+// NewLimiter returns a limiter keeping its counters in store.
+func NewLimiter(store Store, cfg config.BruteForce) *Limiter {
+	cfg = cfg.WithDefaults()
+	login := Policy{
+		FreeAttempts: cfg.FreeAttempts,
+		BaseDelay:    time.Second,
+		MaxDelay:     cfg.MaxDelay,
+		Window:       cfg.Window,
+	}
+	account := login
+	account.LockoutThreshold = cfg.LockoutThreshold
+	account.LockoutDuration = cfg.LockoutDuration
+	address := login
+	address.FreeAttempts = cfg.AddressFreeAttempts
+
+	reset := Policy{
+		FreeAttempts: cfg.ResetRequests,
+		BaseDelay:    time.Minute,
+		MaxDelay:     time.Hour,
+		Window:       cfg.Window,
+	}
+	resetAddress := reset
+	resetAddress.FreeAttempts = cfg.AddressResetRequests
+
+	return &Limiter{
+		accounts:       NewGuard(store, "login:account:", account),
+		addresses:      NewGuard(store, "login:address:", address),
+		resetAccounts:  NewGuard(store, "reset:account:", reset),
+		resetAddresses: NewGuard(store, "reset:address:", resetAddress),
+	}
+}
+
+// BeginLogin reserves a login attempt to account from address before the
+// credentials are checked, counting it as failed. The returned attempt
+// describes the account; its Wait is set when the account or the address has
+// to wait. A failed login needs no further call.
+func (l *Limiter) BeginLogin(ctx context.Context, account, address string) (Attempt, error) {
+	return reserve(ctx, l.accounts, account, l.addresses, address)
+}
+
+// LoginSucceeded forgets the failed logins to account once a login passed
+// all of its factors. Of the failures from the client address only the one
+// reserved for this login is taken back, so that logging in to an account of
+// their own does not let an attacker go on guessing others.
+func (l *Limiter) LoginSucceeded(ctx context.Context, account, address string) error {
+	if err := l.addresses.Release(ctx, address, Attempt{}); err != nil {
+		return err
+	}
+	return l.accounts.Reset(ctx, account)
+}
+
+// ReleaseLogin takes back the attempt a when the login did not fail even
+// though it did not complete either, such as when the password was right and
+// a second factor is still needed.
+func (l *Limiter) ReleaseLogin(ctx context.Context, account, address string, a Attempt) error {
+	if err := l.addresses.Release(ctx, address, Attempt{}); err != nil {
+		return err
+	}
+	return l.accounts.Release(ctx, account, a)
+}
+
+// AllowReset counts a password reset request for account from address, or
+// returns how long it has to wait when there were too many.
+func (l *Limiter) AllowReset(ctx context.Context, account, address string) (time.Duration, error) {
+	a, err := reserve(ctx, l.resetAccounts, account, l.resetAddresses, address)
+	return a.Wait, err
+}
+
+// reserve reserves an attempt of both keys, or of neither when one of them
+// has to wait.
+func reserve(ctx context.Context, account *Guard, accountKey string, address *Guard, addressKey string) (Attempt, error) {
+	a, err := address.Reserve(ctx, addressKey)
+	if err != nil || a.Wait > 0 {
+		return Attempt{Wait: a.Wait}, err
+	}
+	acct, err := account.Reserve(ctx, accountKey)
+	if err != nil {
+		return Attempt{}, err
+	}
+	if acct.Wait > 0 {
+		if err := address.Release(ctx, addressKey, a); err != nil {
+			return Attempt{}, err
+		}
+	}
+	return acct, nil
+}
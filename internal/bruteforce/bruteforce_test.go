@@ -0,0 +1,236 @@
+package bruteforce
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/config"
+)
+
+var testPolicy = Policy{
+	FreeAttempts:     2,
+	BaseDelay:        time.Hour,
+	MaxDelay:         4 * time.Hour,
+	LockoutThreshold: 5,
+	LockoutDuration:  24 * time.Hour,
+	Window:           48 * time.Hour,
+}
+
+// stampingStore hands out new keys the way PostgresStore did when it created
+// their row: without failures, but with the current time as the last one.
+type stampingStore struct {
+	*MemoryStore
+}
+
+func (s stampingStore) Update(ctx context.Context, key string, fn func(State) State) error {
+	return s.MemoryStore.Update(ctx, key, func(st State) State {
+		if st == (State{}) {
+			st.LastFailure = time.Now().Add(time.Second)
+		}
+		return fn(st)
+	})
+}
+
+func TestPolicyDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{1, 0},
+		{2, time.Hour},
+		{3, 2 * time.Hour},
+		{4, 4 * time.Hour},
+		{5, 4 * time.Hour},
+		{100, 4 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := testPolicy.delay(tt.failures); got != tt.want {
+			t.Errorf("delay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+
+	free := Policy{BaseDelay: time.Second, MaxDelay: time.Minute}
+	if got := free.delay(0); got != 0 {
+		t.Errorf("delay(0) without free attempts = %v, want 0", got)
+	}
+}
+
+func TestGuardFirstAttempt(t *testing.T) {
+	stores := map[string]Store{"memory": NewMemoryStore(), "stamping": stampingStore{NewMemoryStore()}}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			g := NewGuard(store, "test:", testPolicy)
+			a, err := g.Reserve(context.Background(), "new")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if a.Wait != 0 || a.Failures != 1 {
+				t.Errorf("first attempt = %+v, want one failure and no wait", a)
+			}
+
+			// A reset deletes the key, so the next attempt is a first one again.
+			if err := g.Reset(context.Background(), "new"); err != nil {
+				t.Fatal(err)
+			}
+			if a, err := g.Reserve(context.Background(), "new"); err != nil || a.Wait != 0 {
+				t.Errorf("attempt after reset = %+v, %v, want no wait", a, err)
+			}
+		})
+	}
+}
+
+func TestGuardDelays(t *testing.T) {
+	ctx := context.Background()
+	g := NewGuard(NewMemoryStore(), "test:", testPolicy)
+	for i := 1; i <= testPolicy.FreeAttempts; i++ {
+		a, err := g.Reserve(ctx, "k")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.Wait != 0 || a.Failures != i {
+			t.Fatalf("attempt %d = %+v, want failure %d without wait", i, a, i)
+		}
+	}
+
+	a, err := g.Reserve(ctx, "k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Wait <= 59*time.Minute || a.Wait > time.Hour || a.Failures != 0 {
+		t.Errorf("attempt past the free ones = %+v, want a wait of an hour and nothing counted", a)
+	}
+}
+
+func TestGuardLockout(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	g := NewGuard(store, "test:", testPolicy)
+
+	// Four failures long ago, so that the delay has passed.
+	store.Update(ctx, "test:k", func(State) State {
+		return State{Failures: testPolicy.LockoutThreshold - 1, LastFailure: time.Now().Add(-5 * time.Hour)}
+	})
+	a, err := g.Reserve(ctx, "k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Failures != testPolicy.LockoutThreshold || a.LockedUntil.IsZero() {
+		t.Fatalf("attempt at the threshold = %+v, want a lockout", a)
+	}
+	blocked, err := g.Reserve(ctx, "k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blocked.Wait < testPolicy.LockoutDuration-time.Minute {
+		t.Errorf("attempt while locked = %+v, want to wait for the lockout", blocked)
+	}
+
+	// Taking the attempt back lifts the lockout it caused.
+	if err := g.Release(ctx, "k", a); err != nil {
+		t.Fatal(err)
+	}
+	store.Update(ctx, "test:k", func(s State) State {
+		if s.Failures != testPolicy.LockoutThreshold-1 || !s.LockedUntil.IsZero() {
+			t.Errorf("state after release = %+v", s)
+		}
+		return s
+	})
+}
+
+func TestGuardWindow(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	g := NewGuard(store, "test:", testPolicy)
+	store.Update(ctx, "test:k", func(State) State {
+		return State{Failures: 50, LastFailure: time.Now().Add(-testPolicy.Window - time.Minute)}
+	})
+	a, err := g.Reserve(ctx, "k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Wait != 0 || a.Failures != 1 {
+		t.Errorf("attempt after the window = %+v, want the count to start over", a)
+	}
+}
+
+func TestGuardParallel(t *testing.T) {
+	g := NewGuard(NewMemoryStore(), "test:", testPolicy)
+	var passed atomic.Int32
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a, err := g.Reserve(context.Background(), "k")
+			if err == nil && a.Wait == 0 {
+				passed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := passed.Load(); got != int32(testPolicy.FreeAttempts) {
+		t.Errorf("%d parallel attempts passed, want %d", got, testPolicy.FreeAttempts)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.BruteForce{FreeAttempts: 2, AddressFreeAttempts: 3}
+	l := NewLimiter(stampingStore{NewMemoryStore()}, cfg)
+
+	for i := 0; i < 2; i++ {
+		if a, err := l.BeginLogin(ctx, "ann@example.com", "192.0.2.1"); err != nil || a.Wait != 0 {
+			t.Fatalf("login %d = %+v, %v, want no wait", i, a, err)
+		}
+	}
+	if a, _ := l.BeginLogin(ctx, "ann@example.com", "192.0.2.1"); a.Wait == 0 {
+		t.Error("third failed login to the account did not wait")
+	}
+
+	// The refused attempt was taken back from the address, which has one
+	// attempt left for another account.
+	if a, err := l.BeginLogin(ctx, "bob@example.com", "192.0.2.1"); err != nil || a.Wait != 0 {
+		t.Errorf("login to another account = %+v, %v, want no wait", a, err)
+	}
+	if a, _ := l.BeginLogin(ctx, "cat@example.com", "192.0.2.1"); a.Wait == 0 {
+		t.Error("fourth failed login from the address did not wait")
+	}
+
+	// A successful login forgets the failures of the account.
+	if err := l.LoginSucceeded(ctx, "ann@example.com", "192.0.2.2"); err != nil {
+		t.Fatal(err)
+	}
+	if a, err := l.BeginLogin(ctx, "ann@example.com", "192.0.2.2"); err != nil || a.Wait != 0 {
+		t.Errorf("login after success = %+v, %v, want no wait", a, err)
+	}
+}
+
+func TestLimiterReleaseLogin(t *testing.T) {
+	ctx := context.Background()
+	l := NewLimiter(NewMemoryStore(), config.BruteForce{FreeAttempts: 1})
+	for i := 0; i < 3; i++ {
+		a, err := l.BeginLogin(ctx, "ann@example.com", "192.0.2.1")
+		if err != nil || a.Wait != 0 {
+			t.Fatalf("login %d = %+v, %v, want no wait", i, a, err)
+		}
+		// The password was right and a second factor is needed.
+		if err := l.ReleaseLogin(ctx, "ann@example.com", "192.0.2.1", a); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestAllowReset(t *testing.T) {
+	ctx := context.Background()
+	l := NewLimiter(stampingStore{NewMemoryStore()}, config.BruteForce{ResetRequests: 1})
+	if wait, err := l.AllowReset(ctx, "ann@example.com", "192.0.2.1"); err != nil || wait != 0 {
+		t.Fatalf("first reset = %v, %v, want no wait", wait, err)
+	}
+	if wait, _ := l.AllowReset(ctx, "ann@example.com", "192.0.2.1"); wait == 0 {
+		t.Error("second reset request did not wait")
+	}
+}
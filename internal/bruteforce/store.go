@@ -0,0 +1,126 @@
+package bruteforce
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/storage"
+)
+
+// State is what a Store knows about a key.
+type State struct {
+	Failures    int
+	LastFailure time.Time
+	// LockedUntil is zero unless the key was locked out.
+	LockedUntil time.Time
+}
+
+// Store keeps the failure counters.
+type Store interface {
+	// Update replaces the state of key with what fn returns for its current
+	// state, which has no failures for an unknown key. Updates of the same
+	// key are serialized, so fn sees the result of the previous one.
+	Update(ctx context.Context, key string, fn func(State) State) error
+	// Reset forgets the failures of key.
+	Reset(ctx context.Context, key string) error
+	// Purge forgets the keys whose last failure and lockout both ended
+	// before the given time and returns how many there were.
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// MemoryStore keeps the counters in the process. Every instance of the app
+// counts on its own.
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[string]State
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: map[string]State{}}
+}
+
+func (s *MemoryStore) Update(ctx context.Context, key string, fn func(State) State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[key] = fn(s.states[key])
+	return nil
+}
+
+func (s *MemoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, key)
+	return nil
+}
+
+func (s *MemoryStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for key, state := range s.states {
+		if state.LastFailure.Before(before) && state.LockedUntil.Before(before) {
+			delete(s.states, key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// PostgresStore keeps the counters in the login_throttles table, so that
+// instances sharing the database share them too. Updates lock the row of
+// their key until they commit.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore returns a store using db.
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Update(ctx context.Context, key string, fn func(State) State) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	q := storage.New(tx)
+
+	// Creating the row first gives concurrent updates of a new key a row to
+	// wait for. It has no failures, so it has no time of the last one either.
+	err = q.CreateLoginThrottle(ctx, storage.CreateLoginThrottleParams{Key: key})
+	if err != nil {
+		return err
+	}
+	t, err := q.GetLoginThrottleForUpdate(ctx, key)
+	if err != nil {
+		return err
+	}
+	current := State{Failures: int(t.Failures), LastFailure: t.LastFailure}
+	if t.LockedUntil.Valid {
+		current.LockedUntil = t.LockedUntil.Time
+	}
+
+	next := fn(current)
+	err = q.UpdateLoginThrottle(ctx, storage.UpdateLoginThrottleParams{
+		Key:         key,
+		Failures:    int32(next.Failures),
+		LastFailure: next.LastFailure,
+		LockedUntil: sql.NullTime{Time: next.LockedUntil, Valid: !next.LockedUntil.IsZero()},
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresStore) Reset(ctx context.Context, key string) error {
+	return storage.New(s.db).DeleteLoginThrottle(ctx, key)
+}
+
+func (s *PostgresStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return storage.New(s.db).PurgeLoginThrottles(ctx, before)
+}
@@ -0,0 +1,137 @@
+package bruteforce
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore(), "")
+}
+
+// TestPostgresStore runs against the database in TEST_DATABASE_URL, which
+// must be one that can be written to freely.
+func TestPostgresStore(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	migration, err := os.ReadFile("../../migrations/019_add_login_throttles.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(string(migration)); err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, NewPostgresStore(db), fmt.Sprintf("test:%d:", time.Now().UnixNano()))
+}
+
+// testStore checks the contract of Store, using keys with the given prefix.
+func testStore(t *testing.T, s Store, prefix string) {
+	ctx := context.Background()
+	key := prefix + "k"
+
+	t.Run("unknown key", func(t *testing.T) {
+		err := s.Update(ctx, prefix+"unknown", func(st State) State {
+			if st.Failures != 0 || !st.LastFailure.IsZero() || !st.LockedUntil.IsZero() {
+				t.Errorf("state of an unknown key = %+v, want none", st)
+			}
+			return st
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		now := time.Now().Truncate(time.Microsecond)
+		want := State{Failures: 3, LastFailure: now, LockedUntil: now.Add(time.Hour)}
+		if err := s.Update(ctx, key, func(State) State { return want }); err != nil {
+			t.Fatal(err)
+		}
+		err := s.Update(ctx, key, func(st State) State {
+			if st.Failures != want.Failures || !st.LastFailure.Equal(want.LastFailure) || !st.LockedUntil.Equal(want.LockedUntil) {
+				t.Errorf("state = %+v, want %+v", st, want)
+			}
+			return st
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("serialized", func(t *testing.T) {
+		parallel := prefix + "parallel"
+		var wg sync.WaitGroup
+		for range 20 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := s.Update(ctx, parallel, func(st State) State {
+					st.Failures++
+					st.LastFailure = time.Now()
+					return st
+				})
+				if err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+		s.Update(ctx, parallel, func(st State) State {
+			if st.Failures != 20 {
+				t.Errorf("failures = %d, want 20", st.Failures)
+			}
+			return st
+		})
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		if err := s.Reset(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+		s.Update(ctx, key, func(st State) State {
+			if st.Failures != 0 {
+				t.Errorf("failures after reset = %d, want 0", st.Failures)
+			}
+			return st
+		})
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		now := time.Now()
+		states := map[string]State{
+			"old":    {Failures: 1, LastFailure: now.Add(-2 * time.Hour)},
+			"recent": {Failures: 1, LastFailure: now},
+			"locked": {Failures: 1, LastFailure: now.Add(-2 * time.Hour), LockedUntil: now.Add(time.Hour)},
+		}
+		for k, st := range states {
+			if err := s.Update(ctx, prefix+k, func(State) State { return st }); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := s.Purge(ctx, now.Add(-time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		for k, st := range states {
+			s.Update(ctx, prefix+k, func(got State) State {
+				if kept := got.Failures > 0; kept == (k == "old") {
+					t.Errorf("%s: kept = %v", k, kept)
+				}
+				return st
+			})
+		}
+	})
+}
@@ -0,0 +1,99 @@
+// Package clientip works out the address a request came from. Behind a
+// reverse proxy every connection comes from the proxy, so the address it
+// appends to X-Forwarded-For is used instead, but only for connections from
+// trusted proxies since clients can set the header freely.
+package clientip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Header lists the addresses a request was forwarded for, the client first.
+const Header = "X-Forwarded-For"
+
+type contextKey struct{}
+
+// ParseTrusted parses a list of proxy addresses and CIDR ranges.
+func ParseTrusted(proxies []string) ([]netip.Prefix, error) {
+	trusted := make([]netip.Prefix, 0, len(proxies))
+	for _, p := range proxies {
+		if prefix, err := netip.ParsePrefix(p); err == nil {
+			trusted = append(trusted, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", p)
+		}
+		trusted = append(trusted, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return trusted, nil
+}
+
+// Middleware stores the client address of every request for FromRequest.
+// Starting from the connection, it walks X-Forwarded-For from the right for
+// as long as the addresses belong to trusted proxies; the first one that
+// does not is the client.
+func Middleware(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			addr := resolve(r, trusted)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, addr)))
+		})
+	}
+}
+
+// FromRequest returns the client address Middleware found, or the address
+// of the connection for requests it did not see.
+func FromRequest(r *http.Request) string {
+	if addr, ok := r.Context().Value(contextKey{}).(string); ok {
+		return addr
+	}
+	return remoteAddr(r)
+}
+
+func resolve(r *http.Request, trusted []netip.Prefix) string {
+	client := remoteAddr(r)
+	var hops []string
+	for _, h := range r.Header.Values(Header) {
+		hops = append(hops, strings.Split(h, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		if !isTrusted(client, trusted) {
+			break
+		}
+		hop := strings.TrimSpace(hops[i])
+		if _, err := netip.ParseAddr(hop); err != nil {
+			break
+		}
+		client = hop
+	}
+	return client
+}
+
+func isTrusted(addr string, trusted []netip.Prefix) bool {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, p := range trusted {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func remoteAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
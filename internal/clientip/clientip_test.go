@@ -0,0 +1,90 @@
+package clientip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestParseTrusted(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies []string
+		want    []netip.Prefix
+		wantErr bool
+	}{
+		{"none", nil, []netip.Prefix{}, false},
+		{"range", []string{"10.0.0.0/8"}, []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, false},
+		{"range is masked", []string{"10.1.2.3/8"}, []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, false},
+		{"address", []string{"192.0.2.1"}, []netip.Prefix{netip.MustParsePrefix("192.0.2.1/32")}, false},
+		{"IPv6 address", []string{"2001:db8::1"}, []netip.Prefix{netip.MustParsePrefix("2001:db8::1/128")}, false},
+		{"IPv4-mapped address", []string{"::ffff:192.0.2.1"}, []netip.Prefix{netip.MustParsePrefix("192.0.2.1/32")}, false},
+		{"hostname", []string{"proxy.internal"}, nil, true},
+		{"bad prefix length", []string{"10.0.0.0/33"}, nil, true},
+		{"one bad entry", []string{"10.0.0.0/8", ""}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTrusted(tt.proxies)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTrusted(%q) error = %v, want error %v", tt.proxies, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTrusted(%q) = %v, want %v", tt.proxies, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	trusted, err := ParseTrusted([]string{"10.0.0.0/8", "2001:db8::/32"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		want      string
+	}{
+		{"direct", "192.0.2.1:1234", nil, "192.0.2.1"},
+		{"untrusted connection", "192.0.2.1:1234", []string{"198.51.100.7"}, "192.0.2.1"},
+		{"trusted proxy", "10.0.0.1:1234", []string{"198.51.100.7"}, "198.51.100.7"},
+		{"trusted proxy without header", "10.0.0.1:1234", nil, "10.0.0.1"},
+		{"spoofed entry before the client", "10.0.0.1:1234", []string{"203.0.113.9, 198.51.100.7"}, "198.51.100.7"},
+		{"chain of proxies", "10.0.0.1:1234", []string{"198.51.100.7, 10.0.0.2"}, "198.51.100.7"},
+		{"chain across headers", "10.0.0.1:1234", []string{"198.51.100.7", "10.0.0.2"}, "198.51.100.7"},
+		{"only proxies", "10.0.0.1:1234", []string{"10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		{"garbage hop", "10.0.0.1:1234", []string{"198.51.100.7, unknown"}, "10.0.0.1"},
+		{"IPv6 proxy", "[2001:db8::1]:1234", []string{"198.51.100.7"}, "198.51.100.7"},
+		{"IPv4-mapped proxy", "[::ffff:10.0.0.1]:1234", []string{"198.51.100.7"}, "198.51.100.7"},
+		{"remote without port", "192.0.2.1", nil, "192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remote
+			for _, h := range tt.forwarded {
+				r.Header.Add(Header, h)
+			}
+			var got string
+			Middleware(trusted)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				got = FromRequest(r)
+			})).ServeHTTP(httptest.NewRecorder(), r)
+			if got != tt.want {
+				t.Errorf("client = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromRequestWithoutMiddleware(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	r.Header.Set(Header, "198.51.100.7")
+	if got := FromRequest(r); got != "192.0.2.1" {
+		t.Errorf("FromRequest = %s, want the connection address", got)
+	}
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 )
 
 type Config struct {
@@ -23,7 +24,214 @@ type Config struct {
 			Host string
 			Port string
 		}
+		// TrustedProxies lists the addresses and CIDR ranges of the reverse
+		// proxies in front of the server. Requests from them are attributed
+		// to the client they name in X-Forwarded-For.
+		TrustedProxies []string `yaml:"trusted_proxies"`
 	}
+	Auth  Auth
+	Email Email
+	JWT   JWT
+	Audit Audit
+
+	BruteForce BruteForce `yaml:"brute_force"`
+}
+
+// BruteForce configures how failed logins and password reset requests are
+// throttled.
+type BruteForce struct {
+	// Store keeps the counters: memory (the default) or postgres. Instances
+	// sharing a database need postgres to see each other's counts.
+	Store string `yaml:"store"`
+	// FreeAttempts failed logins per account are allowed before every
+	// further attempt has to wait, starting at a second and doubling up to
+	// MaxDelay.
+	FreeAttempts int           `yaml:"free_attempts"`
+	MaxDelay     time.Duration `yaml:"max_delay"`
+	// Every LockoutThreshold failed logins lock the account for
+	// LockoutDuration and notify its owner by email.
+	LockoutThreshold int           `yaml:"lockout_threshold"`
+	LockoutDuration  time.Duration `yaml:"lockout_duration"`
+	// AddressFreeAttempts is FreeAttempts for the failed logins from one
+	// client address, whichever accounts they were for.
+	AddressFreeAttempts int `yaml:"address_free_attempts"`
+	// ResetRequests password reset emails can be requested per email
+	// address, and AddressResetRequests per client address, before further
+	// requests are delayed, starting at a minute and doubling up to an hour.
+	ResetRequests        int `yaml:"reset_requests"`
+	AddressResetRequests int `yaml:"address_reset_requests"`
+	// Window is how long failures are remembered after the last one.
+	Window time.Duration `yaml:"window"`
+}
+
+// WithDefaults fills in unset brute-force protection settings.
+func (b BruteForce) WithDefaults() BruteForce {
+	if b.Store == "" {
+		b.Store = "memory"
+	}
+	if b.FreeAttempts == 0 {
+		b.FreeAttempts = 5
+	}
+	if b.MaxDelay == 0 {
+		b.MaxDelay = 15 * time.Minute
+	}
+	if b.LockoutThreshold == 0 {
+		b.LockoutThreshold = 10
+	}
+	if b.LockoutDuration == 0 {
+		b.LockoutDuration = 15 * time.Minute
+	}
+	if b.AddressFreeAttempts == 0 {
+		b.AddressFreeAttempts = 20
+	}
+	if b.ResetRequests == 0 {
+		b.ResetRequests = 3
+	}
+	if b.AddressResetRequests == 0 {
+		b.AddressResetRequests = 10
+	}
+	if b.Window == 0 {
+		b.Window = 24 * time.Hour
+	}
+	return b
+}
+
+// Audit configures the audit log.
+type Audit struct {
+	// Retention is how long audit events are kept, at least 90 days.
+	Retention time.Duration `yaml:"retention"`
+}
+
+// WithDefaults fills in unset audit settings.
+func (a Audit) WithDefaults() Audit {
+	if a.Retention == 0 {
+		a.Retention = 365 * 24 * time.Hour
+	}
+	return a
+}
+
+// JWT configures how access tokens are signed and verified.
+type JWT struct {
+	Issuer   string `yaml:"issuer"`
+	Audience string `yaml:"audience"`
+	// AccessTokenTTL is how long an access token stays valid.
+	AccessTokenTTL time.Duration `yaml:"access_token_ttl"`
+	// Leeway tolerates clock skew when checking expiry and not-before times.
+	Leeway time.Duration `yaml:"leeway"`
+	// SigningKey is the id of the key new tokens are signed with. The other
+	// keys only verify tokens, so keys can be rotated without invalidating
+	// the tokens already issued. Defaults to the only key when there is one.
+	SigningKey string   `yaml:"signing_key"`
+	Keys       []JWTKey `yaml:"keys"`
+}
+
+// JWTKey is a signing or verification key.
+type JWTKey struct {
+	// ID is sent in the kid header of the tokens the key signs.
+	ID string `yaml:"id"`
+	// Algorithm is HS256, RS256 or EdDSA.
+	Algorithm string `yaml:"algorithm"`
+	// Secret is the HS256 secret. SecretEnv names an environment variable
+	// to read it from instead.
+	Secret    string `yaml:"secret"`
+	SecretEnv string `yaml:"secret_env"`
+	// PrivateKeyFile and PublicKeyFile are PEM files with RS256 and EdDSA
+	// keys. A key with only a public key can verify tokens but not sign
+	// them.
+	PrivateKeyFile string `yaml:"private_key_file"`
+	PublicKeyFile  string `yaml:"public_key_file"`
+}
+
+// WithDefaults fills in unset JWT settings.
+func (j JWT) WithDefaults() JWT {
+	if j.Issuer == "" {
+		j.Issuer = "fitness-tracking-app"
+	}
+	if j.Audience == "" {
+		j.Audience = "fitness-tracking-app"
+	}
+	if j.AccessTokenTTL == 0 {
+		j.AccessTokenTTL = 15 * time.Minute
+	}
+	if j.Leeway == 0 {
+		j.Leeway = 30 * time.Second
+	}
+	if j.SigningKey == "" && len(j.Keys) == 1 {
+		j.SigningKey = j.Keys[0].ID
+	}
+	return j
+}
+
+// Email configures how emails are delivered.
+type Email struct {
+	// Transport is smtp, file or memory. file writes messages into a
+	// maildir under Dir and is the default; memory only keeps them in the
+	// process, which is useful for tests.
+	Transport string `yaml:"transport"`
+	From      string `yaml:"from"`
+	Dir       string `yaml:"dir"`
+	SMTP      struct {
+		Host     string `yaml:"host"`
+		Port     int    `yaml:"port"`
+		Username string `yaml:"username"`
+		Password string `yaml:"password"`
+		// TLS is starttls (the default), tls or none.
+		TLS string `yaml:"tls"`
+	} `yaml:"smtp"`
+}
+
+// Auth configures account verification and password resets.
+type Auth struct {
+	// VerifyURL is the page verification links point to; the token is
+	// appended as the token query parameter.
+	VerifyURL string `yaml:"verify_url"`
+	// VerificationTTL is how long a verification link stays valid.
+	VerificationTTL time.Duration `yaml:"verification_ttl"`
+	// VerificationResendInterval is the minimum time between two
+	// verification emails to the same user.
+	VerificationResendInterval time.Duration `yaml:"verification_resend_interval"`
+	// UnverifiedTTL is how long unverified accounts are kept before they are
+	// deleted.
+	UnverifiedTTL time.Duration `yaml:"unverified_ttl"`
+	// ResetURL is the page password reset links point to; the token is
+	// appended as the token query parameter.
+	ResetURL string `yaml:"reset_url"`
+	// PasswordResetTTL is how long a password reset link stays valid.
+	PasswordResetTTL time.Duration `yaml:"password_reset_ttl"`
+	// TOTPIssuer names the app in authenticator apps.
+	TOTPIssuer string `yaml:"totp_issuer"`
+	// MFATokenTTL is how long a user with two-factor authentication has to
+	// enter their code after the password was accepted.
+	MFATokenTTL time.Duration `yaml:"mfa_token_ttl"`
+}
+
+// WithDefaults fills in the settings left out of the configuration file.
+func (a Auth) WithDefaults() Auth {
+	if a.VerifyURL == "" {
+		a.VerifyURL = "http://localhost:8080/verify"
+	}
+	if a.VerificationTTL == 0 {
+		a.VerificationTTL = 48 * time.Hour
+	}
+	if a.VerificationResendInterval == 0 {
+		a.VerificationResendInterval = time.Minute
+	}
+	if a.UnverifiedTTL == 0 {
+		a.UnverifiedTTL = 7 * 24 * time.Hour
+	}
+	if a.ResetURL == "" {
+		a.ResetURL = "http://localhost:8080/reset-password"
+	}
+	if a.PasswordResetTTL == 0 {
+		a.PasswordResetTTL = 30 * time.Minute
+	}
+	if a.TOTPIssuer == "" {
+		a.TOTPIssuer = "Fitness Tracker"
+	}
+	if a.MFATokenTTL == 0 {
+		a.MFATokenTTL = 5 * time.Minute
+	}
+	return a
 }
 
 func (c Config) LoadConfig() string {
@@ -40,3 +248,20 @@ func (c Config) LoadConfig() string {
 func (c Config) GetHostPrort() string {
 	return fmt.Sprintf("%s:%s", c.Server.Http.Host, c.Server.Http.Port)
 }
+
+// WithDefaults fills in unset email settings.
+func (e Email) WithDefaults() Email {
+	if e.Transport == "" {
+		e.Transport = "file"
+	}
+	if e.From == "" {
+		e.From = "Fitness Tracker <no-reply@localhost>"
+	}
+	if e.Dir == "" {
+		e.Dir = "mail"
+	}
+	if e.SMTP.Port == 0 {
+		e.SMTP.Port = 587
+	}
+	return e
+}
@@ -1,22 +1,221 @@
+// Package email renders the emails the app sends and delivers them through
+// a pluggable Sender.
 package email
 
-import "net/smtp"
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"path"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
 
-func SendResetEmail(email, token string) error {
-	from := "dilshoddilmurodov112@gmail.com"
-	password := "xmxu rdhp pmdf pezk"
-	to := email
-	subject := "Password Reset Request"
-	body := "your token: " + token
+// Message is an email with a plain text and an HTML version of its body.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender delivers messages.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Bytes encodes msg as a MIME multipart/alternative message from the given
+// address.
+func (m Message) Bytes(from string) ([]byte, error) {
+	fromAddr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address: %w", err)
+	}
+	toAddr, err := mail.ParseAddress(m.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient: %w", err)
+	}
+
+	var parts bytes.Buffer
+	body := multipart.NewWriter(&parts)
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "From: %s\r\n", fromAddr)
+	fmt.Fprintf(&out, "To: %s\r\n", toAddr)
+	fmt.Fprintf(&out, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&out, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&out, "Message-ID: %s\r\n", messageID(fromAddr.Address))
+	fmt.Fprintf(&out, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&out, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", body.Boundary())
+
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		if part.content == "" {
+			continue
+		}
+		w, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+
+	out.Write(parts.Bytes())
+	return out.Bytes(), nil
+}
+
+func messageID(from string) string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	domain := "localhost"
+	if i := strings.LastIndex(from, "@"); i >= 0 {
+		domain = from[i+1:]
+	}
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
+
+// Templates of the emails the app sends. Each has a text and an HTML
+// version per locale; the text version also defines the subject.
+const (
+	TemplateVerification = "verification"
+	TemplateReset        = "reset"
+	TemplateDigest       = "digest"
+	TemplateLockout      = "lockout"
+)
+
+// DefaultLocale is used when the recipient's language is not supported.
+const DefaultLocale = "en"
+
+//go:embed templates
+var templateFS embed.FS
+
+type localized struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+var funcs = map[string]any{
+	"hours":   func(d time.Duration) int { return int(d.Round(time.Hour).Hours()) },
+	"minutes": func(d time.Duration) int { return int(d.Round(time.Minute).Minutes()) },
+}
+
+// templates maps locale and template name to the parsed templates.
+var templates = mustParseTemplates()
+
+func mustParseTemplates() map[string]map[string]localized {
+	all := map[string]map[string]localized{}
+	locales, err := fs.ReadDir(templateFS, "templates")
+	if err != nil {
+		panic(err)
+	}
+	for _, locale := range locales {
+		dir := path.Join("templates", locale.Name())
+		all[locale.Name()] = map[string]localized{}
+		for _, name := range []string{TemplateVerification, TemplateReset, TemplateDigest, TemplateLockout} {
+			text, html := name+".txt.tmpl", name+".html.tmpl"
+			all[locale.Name()][name] = localized{
+				text: texttemplate.Must(texttemplate.New(text).Funcs(funcs).ParseFS(templateFS, path.Join(dir, text))),
+				html: htmltemplate.Must(htmltemplate.New(html).Funcs(funcs).ParseFS(templateFS, path.Join(dir, html))),
+			}
+		}
+	}
+	return all
+}
+
+// MatchLocale picks the supported locale that best matches an
+// Accept-Language header, ignoring quality values.
+func MatchLocale(acceptLanguage string) string {
+	for _, tag := range strings.Split(acceptLanguage, ",") {
+		tag, _, _ = strings.Cut(strings.TrimSpace(tag), ";")
+		lang, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if _, ok := templates[lang]; ok {
+			return lang
+		}
+	}
+	return DefaultLocale
+}
 
-	msg := "From: " + from + "\n" +
-		"To: " + to + "\n" +
-		"Subject: " + subject + "\n\n" +
-		body
+// Render builds the message of the named template in locale for to.
+func Render(to, name, locale string, data any) (Message, error) {
+	t, ok := templates[locale][name]
+	if !ok {
+		t, ok = templates[DefaultLocale][name]
+	}
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email template %q", name)
+	}
 
-	err := smtp.SendMail("smtp.gmail.com:587",
-		smtp.PlainAuth("", from, password, "smtp.gmail.com"),
-		from, []string{to}, []byte(msg))
+	var subject, text, html bytes.Buffer
+	if err := t.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := t.text.Execute(&text, data); err != nil {
+		return Message{}, err
+	}
+	if err := t.html.Execute(&html, data); err != nil {
+		return Message{}, err
+	}
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
+
+// VerificationData fills the verification template.
+type VerificationData struct {
+	Username  string
+	Link      string
+	ExpiresIn time.Duration
+}
+
+// ResetData fills the password reset template.
+type ResetData struct {
+	Username  string
+	Link      string
+	ExpiresIn time.Duration
+}
+
+// LockoutData fills the account lockout template.
+type LockoutData struct {
+	Username string
+	Attempts int
+	Until    time.Time
+}
 
-	return err
+// DigestData fills the weekly training digest template.
+type DigestData struct {
+	Username string
+	From, To string
+	Workouts int
+	Sets     int
+	Volume   float64
+	Unit     string
+	Records  []string
 }
@@ -0,0 +1,93 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+var ErrQueueFull = errors.New("email queue is full")
+
+// Queue sends messages in the background so requests do not wait for the
+// mail server, retrying failed deliveries with exponential backoff.
+type Queue struct {
+	sender Sender
+	logger *slog.Logger
+	jobs   chan job
+
+	// MaxAttempts is how often a message is tried before it is dropped.
+	MaxAttempts int
+	// Backoff is the delay before the first retry; it doubles with every
+	// further attempt.
+	Backoff time.Duration
+	// Timeout bounds a single delivery attempt.
+	Timeout time.Duration
+}
+
+type job struct {
+	msg     Message
+	attempt int
+}
+
+// NewQueue returns a queue holding up to size pending messages. Run must be
+// called for messages to be sent.
+func NewQueue(sender Sender, logger *slog.Logger, size int) *Queue {
+	return &Queue{
+		sender:      sender,
+		logger:      logger,
+		jobs:        make(chan job, size),
+		MaxAttempts: 5,
+		Backoff:     5 * time.Second,
+		Timeout:     30 * time.Second,
+	}
+}
+
+// Enqueue schedules msg for delivery without blocking.
+func (q *Queue) Enqueue(msg Message) error {
+	select {
+	case q.jobs <- job{msg: msg}:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Run delivers queued messages until ctx is done.
+func (q *Queue) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			if n := len(q.jobs); n > 0 {
+				q.logger.Warn("email queue stopped with pending messages", "count", n)
+			}
+			return
+		case j := <-q.jobs:
+			q.deliver(ctx, j)
+		}
+	}
+}
+
+func (q *Queue) deliver(ctx context.Context, j job) {
+	sendCtx, cancel := context.WithTimeout(ctx, q.Timeout)
+	err := q.sender.Send(sendCtx, j.msg)
+	cancel()
+	if err == nil {
+		return
+	}
+
+	j.attempt++
+	if j.attempt >= q.MaxAttempts {
+		q.logger.Error("failed to send email, giving up", "subject", j.msg.Subject, "attempts", j.attempt, "error", err)
+		return
+	}
+	delay := q.Backoff << (j.attempt - 1)
+	q.logger.Warn("failed to send email, retrying", "subject", j.msg.Subject, "attempt", j.attempt, "retry_in", delay, "error", err)
+	time.AfterFunc(delay, func() {
+		select {
+		case q.jobs <- j:
+		default:
+			q.logger.Error("failed to requeue email", "subject", j.msg.Subject, "error", ErrQueueFull)
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package email
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileSender writes messages into a maildir instead of sending them, so
+// development setups can read them with any mail client.
+type FileSender struct {
+	Dir  string
+	From string
+}
+
+func (s FileSender) Send(ctx context.Context, msg Message) error {
+	data, err := msg.Bytes(s.From)
+	if err != nil {
+		return err
+	}
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(s.Dir, sub), 0o755); err != nil {
+			return err
+		}
+	}
+
+	// Maildir delivery: write under tmp and move into new once complete.
+	b := make([]byte, 8)
+	rand.Read(b)
+	name := fmt.Sprintf("%d.%s.fitness", time.Now().UnixNano(), hex.EncodeToString(b))
+	tmp := filepath.Join(s.Dir, "tmp", name)
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(s.Dir, "new", name))
+}
+
+// MemorySender keeps sent messages in memory for tests.
+type MemorySender struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func (s *MemorySender) Send(ctx context.Context, msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+// Messages returns the messages sent so far.
+func (s *MemorySender) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
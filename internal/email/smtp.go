@@ -0,0 +1,107 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+)
+
+// TLS modes of an SMTP connection.
+const (
+	// TLSStartTLS upgrades a plain connection with STARTTLS, usually on
+	// port 587.
+	TLSStartTLS = "starttls"
+	// TLSImplicit connects over TLS from the start, usually on port 465.
+	TLSImplicit = "tls"
+	// TLSNone never encrypts, which is only meant for local test servers.
+	TLSNone = "none"
+)
+
+// SMTPSender delivers messages through an SMTP server.
+type SMTPSender struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	TLS      string
+}
+
+func (s SMTPSender) Send(ctx context.Context, msg Message) error {
+	data, err := msg.Bytes(s.From)
+	if err != nil {
+		return err
+	}
+	from, err := mail.ParseAddress(s.From)
+	if err != nil {
+		return err
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return err
+	}
+
+	c, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if s.TLS == TLSStartTLS || s.TLS == "" {
+		if err := c.StartTLS(&tls.Config{ServerName: s.Host}); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+	if s.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to.Address); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (s SMTPSender) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	var conn net.Conn
+	var err error
+	if s.TLS == TLSImplicit {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: s.Host}}
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/errors"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/audit"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/email"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/pagination"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/problem"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/models"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/storage"
+)
+
+// AdminListUsers lists accounts by username, optionally searching usernames
+// and emails with q and narrowing them by role and suspended state.
+func (u UserHandler) AdminListUsers(w http.ResponseWriter, r *http.Request) {
+	actor, ok := u.authorize(w, r, PermUsersRead)
+	if !ok {
+		return
+	}
+
+	page, err := pagination.Parse(r, []string{"username"}, "username")
+	if err != nil {
+		problem.Write(w, r, errors.Invalid(err.Error()))
+		return
+	}
+
+	params := storage.ListUserParams{
+		SortDesc:  page.Desc,
+		PageLimit: int32(page.Limit + 1),
+	}
+	if q := r.FormValue("q"); q != "" {
+		params.Query = sql.NullString{String: q, Valid: true}
+	}
+	if role := r.FormValue("role"); role != "" {
+		params.Role = sql.NullString{String: role, Valid: true}
+	}
+	if v := r.FormValue("suspended"); v != "" {
+		suspended, err := strconv.ParseBool(v)
+		if err != nil {
+			problem.Write(w, r, errors.Invalid("invalid suspended parameter"))
+			return
+		}
+		params.Suspended = sql.NullBool{Bool: suspended, Valid: true}
+	}
+	if page.After != nil {
+		params.AfterUsername = sql.NullString{String: page.After.Key, Valid: true}
+		params.AfterID = page.After.ID
+	}
+
+	users, err := u.Storage.ListUser(r.Context(), params)
+	if err != nil {
+		u.Logger.Error("failed to list users", "error", err)
+		problem.Write(w, r, errors.Internal("failed to list users"))
+		return
+	}
+
+	// Listing reveals the email addresses of other users, so it is recorded
+	// like the admin actions that change accounts, with the search that was
+	// made and the accounts it returned.
+	listed := make([]int32, 0, page.Limit)
+	for _, user := range users[:min(len(users), page.Limit)] {
+		listed = append(listed, user.ID)
+	}
+	err = audit.Record(r, &u.Storage, audit.Event{
+		ActorID: actor.ID,
+		Action:  audit.ActionAdminListUsers,
+		Details: map[string]any{"query": r.URL.Query(), "user_ids": listed},
+	})
+	if err != nil {
+		u.Logger.Error("failed to record audit event", "action", audit.ActionAdminListUsers, "error", err)
+		problem.Write(w, r, errors.Internal("failed to list users"))
+		return
+	}
+
+	res := models.Page[models.AdminUserResponse]{
+		Items: make([]models.AdminUserResponse, 0, page.Limit),
+	}
+	for _, user := range users[:min(len(users), page.Limit)] {
+		res.Items = append(res.Items, models.AdminUserResponse{
+			ID:            user.ID,
+			Username:      user.Username,
+			Email:         user.Email,
+			Role:          user.Role,
+			EmailVerified: user.EmailVerifiedAt.Valid,
+			SuspendedAt:   timePtr(user.SuspendedAt),
+			CreatedAt:     user.CreateAt,
+		})
+	}
+	if len(users) > page.Limit {
+		last := users[page.Limit-1]
+		next := page.Next(last.Username, last.ID)
+		res.NextCursor = &next
+		pagination.SetLink(w, r, next)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+// AdminSuspendUser blocks an account from logging in and ends its sessions.
+func (u UserHandler) AdminSuspendUser(w http.ResponseWriter, r *http.Request) {
+	var req models.SuspendUserRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	actor, target, ok := u.adminTarget(w, r, PermUsersSuspend)
+	if !ok {
+		return
+	}
+
+	u.adminAction(w, r, "failed to suspend user", func(q *storage.Queries) error {
+		n, err := q.SuspendUser(r.Context(), target.ID)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return errors.Conflict("user is already suspended")
+		}
+		if err := q.RevokeUserRefreshTokens(r.Context(), target.ID); err != nil {
+			return err
+		}
+		return audit.Record(r, q, adminEvent(actor, target, audit.ActionAdminSuspend, map[string]string{"reason": req.Reason}))
+	}, http.StatusNoContent)
+}
+
+func (u UserHandler) AdminUnsuspendUser(w http.ResponseWriter, r *http.Request) {
+	actor, target, ok := u.adminTarget(w, r, PermUsersSuspend)
+	if !ok {
+		return
+	}
+
+	u.adminAction(w, r, "failed to unsuspend user", func(q *storage.Queries) error {
+		n, err := q.UnsuspendUser(r.Context(), target.ID)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return errors.Conflict("user is not suspended")
+		}
+		return audit.Record(r, q, adminEvent(actor, target, audit.ActionAdminUnsuspend, nil))
+	}, http.StatusNoContent)
+}
+
+// AdminResetPassword invalidates the user's password and sessions and sends
+// them a password reset link.
+func (u UserHandler) AdminResetPassword(w http.ResponseWriter, r *http.Request) {
+	actor, target, ok := u.adminTarget(w, r, PermUsersResetPassword)
+	if !ok {
+		return
+	}
+
+	ok = u.adminAction(w, r, "failed to reset password", func(q *storage.Queries) error {
+		if err := q.ClearUserPassword(r.Context(), target.ID); err != nil {
+			return err
+		}
+		if err := q.RevokeUserRefreshTokens(r.Context(), target.ID); err != nil {
+			return err
+		}
+		return audit.Record(r, q, adminEvent(actor, target, audit.ActionAdminResetPassword, nil))
+	}, http.StatusAccepted)
+	if ok {
+		go u.sendPasswordReset(context.WithoutCancel(r.Context()), target.Email, email.DefaultLocale)
+	}
+}
+
+func (u UserHandler) AdminUpdateRole(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateRoleRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	actor, target, ok := u.adminTarget(w, r, PermUsersManageRoles)
+	if !ok {
+		return
+	}
+
+	u.adminAction(w, r, "failed to update role", func(q *storage.Queries) error {
+		if _, err := q.GetRole(r.Context(), req.Role); err == sql.ErrNoRows {
+			return errors.Field("role", "is not a known role")
+		} else if err != nil {
+			return err
+		}
+		err := q.UpdateUserRole(r.Context(), storage.UpdateUserRoleParams{ID: target.ID, Role: req.Role})
+		if err != nil {
+			return err
+		}
+		return audit.Record(r, q, adminEvent(actor, target, audit.ActionAdminChangeRole, map[string]audit.Change{
+			"role": {Before: target.Role, After: req.Role},
+		}))
+	}, http.StatusNoContent)
+}
+
+// adminTarget authorizes an admin action on the user named by the id path
+// parameter. Admins cannot act on their own account, so they cannot lock
+// themselves out.
+func (u UserHandler) adminTarget(w http.ResponseWriter, r *http.Request, permission string) (actor, target storage.User, ok bool) {
+	actor, ok = u.authorize(w, r, permission)
+	if !ok {
+		return actor, target, false
+	}
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return actor, target, false
+	}
+	if id == actor.ID {
+		problem.Write(w, r, errors.Forbidden("admins cannot change their own account"))
+		return actor, target, false
+	}
+
+	target, err := u.Storage.GetUser(r.Context(), id)
+	if err != nil {
+		e := errors.FromDB(err, "user")
+		if e.Kind == errors.ErrInternal {
+			u.Logger.Error("failed to get user", "error", err)
+		}
+		problem.Write(w, r, e)
+		return actor, target, false
+	}
+	return actor, target, true
+}
+
+func adminEvent(actor, target storage.User, action string, details any) audit.Event {
+	return audit.Event{
+		ActorID:    actor.ID,
+		Action:     action,
+		TargetType: audit.TargetUser,
+		TargetID:   target.ID,
+		Details:    details,
+	}
+}
+
+// adminAction runs fn in a transaction and responds with status when it
+// succeeds.
+func (u UserHandler) adminAction(w http.ResponseWriter, r *http.Request, msg string, fn func(q *storage.Queries) error, status int) bool {
+	if err := u.withTx(r.Context(), fn); err != nil {
+		e := errors.As(err)
+		if e.Kind == errors.ErrInternal {
+			u.Logger.Error(msg, "error", err)
+			e = errors.Internal(msg)
+		}
+		problem.Write(w, r, e)
+		return false
+	}
+	w.WriteHeader(status)
+	return true
+}
@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/errors"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/analytics"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/middleware"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/problem"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/models"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/storage"
+)
+
+const (
+	defaultAnalyticsDays = 12 * 7
+	maxAnalyticsDays     = 5 * 366
+)
+
+// analyticsQuery holds the parameters shared by the analytics endpoints.
+type analyticsQuery struct {
+	userID int32
+	from   time.Time
+	to     time.Time
+	bucket string
+}
+
+// parseAnalyticsQuery reads from, to, bucket and tz. Workouts are bucketed by
+// the calendar date they started on in their own timezone; without from and
+// to the last twelve weeks up to today in the caller's timezone are used.
+func (u UserHandler) parseAnalyticsQuery(w http.ResponseWriter, r *http.Request) (analyticsQuery, bool) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return analyticsQuery{}, false
+	}
+
+	loc, ok := u.location(w, r, userID, nil)
+	if !ok {
+		return analyticsQuery{}, false
+	}
+
+	q := analyticsQuery{userID: userID, bucket: analytics.Week}
+	if b := r.FormValue("bucket"); b != "" {
+		if !analytics.ValidBucket(b) {
+			problem.Write(w, r, errors.Invalid("bucket must be one of day, week, month"))
+			return analyticsQuery{}, false
+		}
+		q.bucket = b
+	}
+
+	q.to = today(loc)
+	if v := r.FormValue("to"); v != "" {
+		to, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			problem.Write(w, r, errors.Invalid("invalid to parameter, expected YYYY-MM-DD"))
+			return analyticsQuery{}, false
+		}
+		q.to = to
+	}
+	q.from = q.to.AddDate(0, 0, -defaultAnalyticsDays+1)
+	if v := r.FormValue("from"); v != "" {
+		from, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			problem.Write(w, r, errors.Invalid("invalid from parameter, expected YYYY-MM-DD"))
+			return analyticsQuery{}, false
+		}
+		q.from = from
+	}
+
+	if q.to.Before(q.from) {
+		problem.Write(w, r, errors.Invalid("to must not be before from"))
+		return analyticsQuery{}, false
+	}
+	if days(q.from, q.to) >= maxAnalyticsDays {
+		problem.Write(w, r, errors.Invalid("date range is too long"))
+		return analyticsQuery{}, false
+	}
+	return q, true
+}
+
+// weeks returns the length of the range in weeks, counting partial weeks.
+func (q analyticsQuery) weeks() float64 {
+	return float64(days(q.from, q.to)+1) / 7
+}
+
+// GetVolumeAnalytics returns tonnage (weight x reps) per bucket, grouped by
+// exercise or by primary muscle group.
+func (u UserHandler) GetVolumeAnalytics(w http.ResponseWriter, r *http.Request) {
+	q, ok := u.parseAnalyticsQuery(w, r)
+	if !ok {
+		return
+	}
+	groupBy := r.FormValue("group_by")
+	if groupBy == "" {
+		groupBy = "exercise"
+	}
+	c, ok := u.unitConverter(w, r, q.userID, nil)
+	if !ok {
+		return
+	}
+
+	type point struct {
+		key, name   string
+		bucketStart time.Time
+		tonnage     float64
+		sets        int32
+	}
+	var points []point
+
+	switch groupBy {
+	case "exercise":
+		rows, err := u.Storage.GetVolumeByExercise(r.Context(), storage.GetVolumeByExerciseParams{
+			UserID:   q.userID,
+			Bucket:   q.bucket,
+			FromDate: q.from,
+			ToDate:   q.to,
+		})
+		if err != nil {
+			u.Logger.Error("failed to get volume by exercise", "error", err)
+			problem.Write(w, r, errors.Internal("failed to get volume"))
+			return
+		}
+		for _, row := range rows {
+			points = append(points, point{
+				key:         strconv.Itoa(int(row.DefinitionID.Int32)),
+				name:        row.Name,
+				bucketStart: row.BucketStart,
+				tonnage:     row.Tonnage,
+				sets:        row.Sets,
+			})
+		}
+	case "muscle":
+		rows, err := u.Storage.GetVolumeByMuscle(r.Context(), storage.GetVolumeByMuscleParams{
+			UserID:   q.userID,
+			Bucket:   q.bucket,
+			FromDate: q.from,
+			ToDate:   q.to,
+		})
+		if err != nil {
+			u.Logger.Error("failed to get volume by muscle", "error", err)
+			problem.Write(w, r, errors.Internal("failed to get volume"))
+			return
+		}
+		for _, row := range rows {
+			points = append(points, point{
+				key:         row.Muscle,
+				name:        row.Muscle,
+				bucketStart: row.BucketStart,
+				tonnage:     row.Tonnage,
+				sets:        row.Sets,
+			})
+		}
+	default:
+		problem.Write(w, r, errors.Invalid("group_by must be one of exercise, muscle"))
+		return
+	}
+
+	buckets := analytics.Buckets(q.from, q.to, q.bucket)
+	index := make(map[string]int, len(buckets))
+	for i, b := range buckets {
+		index[b.Format(time.DateOnly)] = i
+	}
+
+	series := make(map[string]*models.VolumeSeries)
+	var keys []string
+	for _, p := range points {
+		s, ok := series[p.key]
+		if !ok {
+			s = &models.VolumeSeries{Key: p.key, Name: p.name, Points: make([]models.VolumePoint, len(buckets))}
+			for i, b := range buckets {
+				s.Points[i].BucketStart = b.Format(time.DateOnly)
+			}
+			series[p.key] = s
+			keys = append(keys, p.key)
+		}
+		if i, ok := index[p.bucketStart.Format(time.DateOnly)]; ok {
+			s.Points[i].Tonnage = c.Weight(p.tonnage)
+			s.Points[i].Sets = p.sets
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return series[keys[i]].Name < series[keys[j]].Name })
+
+	res := models.VolumeResponse{
+		From:    q.from.Format(time.DateOnly),
+		To:      q.to.Format(time.DateOnly),
+		Bucket:  q.bucket,
+		GroupBy: groupBy,
+		Unit:    c.Name(),
+		Series:  make([]models.VolumeSeries, 0, len(keys)),
+	}
+	for _, k := range keys {
+		s := series[k]
+		ys := make([]float64, len(s.Points))
+		for i, p := range s.Points {
+			ys[i] = p.Tonnage
+		}
+		s.Trend.Slope, s.Trend.Intercept = analytics.LinearTrend(ys)
+		res.Series = append(res.Series, *s)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+// GetFrequencyAnalytics returns the number of sessions and their average
+// duration per bucket.
+func (u UserHandler) GetFrequencyAnalytics(w http.ResponseWriter, r *http.Request) {
+	q, ok := u.parseAnalyticsQuery(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := u.Storage.GetSessionStats(r.Context(), storage.GetSessionStatsParams{
+		UserID:   q.userID,
+		Bucket:   q.bucket,
+		FromDate: q.from,
+		ToDate:   q.to,
+	})
+	if err != nil {
+		u.Logger.Error("failed to get session stats", "error", err)
+		problem.Write(w, r, errors.Internal("failed to get frequency"))
+		return
+	}
+
+	buckets := analytics.Buckets(q.from, q.to, q.bucket)
+	res := models.FrequencyResponse{
+		From:   q.from.Format(time.DateOnly),
+		To:     q.to.Format(time.DateOnly),
+		Bucket: q.bucket,
+		Points: make([]models.FrequencyPoint, len(buckets)),
+	}
+	index := make(map[string]int, len(buckets))
+	for i, b := range buckets {
+		res.Points[i].BucketStart = b.Format(time.DateOnly)
+		index[res.Points[i].BucketStart] = i
+	}
+
+	var totalDuration float64
+	for _, row := range rows {
+		i, ok := index[row.BucketStart.Format(time.DateOnly)]
+		if !ok {
+			continue
+		}
+		res.Points[i].Sessions = row.Sessions
+		res.Points[i].AvgDurationSeconds = row.AvgDurationSeconds
+		res.TotalSessions += row.Sessions
+		totalDuration += row.AvgDurationSeconds * float64(row.Sessions)
+	}
+	if res.TotalSessions > 0 {
+		res.AvgDurationSeconds = totalDuration / float64(res.TotalSessions)
+	}
+	res.SessionsPerWeek = float64(res.TotalSessions) / q.weeks()
+
+	ys := make([]float64, len(res.Points))
+	counts := make([]int32, len(res.Points))
+	for i, p := range res.Points {
+		ys[i] = float64(p.Sessions)
+		counts[i] = p.Sessions
+	}
+	res.Trend.Slope, res.Trend.Intercept = analytics.LinearTrend(ys)
+	res.CurrentStreak, res.LongestStreak = analytics.Streaks(counts)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+// GetMuscleBalanceAnalytics compares the weekly number of sets per muscle
+// group with the recommended range. Sets count fully for primary muscles and
+// half for secondary ones.
+func (u UserHandler) GetMuscleBalanceAnalytics(w http.ResponseWriter, r *http.Request) {
+	q, ok := u.parseAnalyticsQuery(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := u.Storage.GetSetsPerMuscle(r.Context(), storage.GetSetsPerMuscleParams{
+		UserID:   q.userID,
+		FromDate: q.from,
+		ToDate:   q.to,
+	})
+	if err != nil {
+		u.Logger.Error("failed to get sets per muscle", "error", err)
+		problem.Write(w, r, errors.Internal("failed to get muscle balance"))
+		return
+	}
+
+	sets := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		sets[row.Muscle] = row.Sets
+	}
+
+	res := models.MuscleBalanceResponse{
+		From:    q.from.Format(time.DateOnly),
+		To:      q.to.Format(time.DateOnly),
+		Muscles: make([]models.MuscleBalance, 0, len(models.Muscles)),
+	}
+	for _, m := range models.Muscles {
+		recommended := analytics.RecommendedSets(m)
+		if recommended.Max == 0 && sets[m] == 0 {
+			continue
+		}
+		weekly := sets[m] / q.weeks()
+		status := "within"
+		switch {
+		case weekly < recommended.Min:
+			status = "below"
+		case weekly > recommended.Max:
+			status = "above"
+		}
+		res.Muscles = append(res.Muscles, models.MuscleBalance{
+			Muscle:         m,
+			Sets:           sets[m],
+			WeeklySets:     weekly,
+			RecommendedMin: recommended.Min,
+			RecommendedMax: recommended.Max,
+			Status:         status,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/errors"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/audit"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/pagination"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/problem"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/models"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/storage"
+)
+
+// AdminListAuditEvents queries the audit log, newest first by default. It
+// can be narrowed by actor, action, target and a from/to time range given
+// in RFC 3339.
+func (u UserHandler) AdminListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	if _, ok := u.authorize(w, r, PermAuditRead); !ok {
+		return
+	}
+
+	page, err := pagination.Parse(r, []string{"time"}, "-time")
+	if err != nil {
+		problem.Write(w, r, errors.Invalid(err.Error()))
+		return
+	}
+
+	params := storage.ListAuditEventsParams{
+		SortDesc:  page.Desc,
+		PageLimit: int32(page.Limit + 1),
+	}
+	for _, f := range []struct {
+		name string
+		dst  *sql.NullString
+	}{{"action", &params.Action}, {"target_type", &params.TargetType}} {
+		if v := r.FormValue(f.name); v != "" {
+			*f.dst = sql.NullString{String: v, Valid: true}
+		}
+	}
+	for _, f := range []struct {
+		name string
+		dst  *sql.NullInt32
+	}{{"actor", &params.ActorID}, {"target", &params.TargetID}} {
+		if v := r.FormValue(f.name); v != "" {
+			id, err := strconv.ParseInt(v, 10, 32)
+			if err != nil {
+				problem.Write(w, r, errors.Invalid("invalid "+f.name+" parameter"))
+				return
+			}
+			*f.dst = sql.NullInt32{Int32: int32(id), Valid: true}
+		}
+	}
+	for _, f := range []struct {
+		name string
+		dst  *sql.NullTime
+	}{{"from", &params.FromTime}, {"to", &params.ToTime}} {
+		if v := r.FormValue(f.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				problem.Write(w, r, errors.Invalid("invalid "+f.name+" parameter"))
+				return
+			}
+			*f.dst = sql.NullTime{Time: t, Valid: true}
+		}
+	}
+	if page.After != nil {
+		key, err := time.Parse(time.RFC3339Nano, page.After.Key)
+		if err != nil {
+			problem.Write(w, r, errors.Invalid(pagination.ErrInvalidCursor.Error()))
+			return
+		}
+		params.AfterKey = sql.NullTime{Time: key, Valid: true}
+		params.AfterID = page.After.ID
+	}
+
+	events, err := u.Storage.ListAuditEvents(r.Context(), params)
+	if err != nil {
+		u.Logger.Error("failed to list audit events", "error", err)
+		problem.Write(w, r, errors.Internal("failed to list audit events"))
+		return
+	}
+
+	res := models.Page[models.AuditEventResponse]{
+		Items: make([]models.AuditEventResponse, 0, page.Limit),
+	}
+	for _, e := range events[:min(len(events), page.Limit)] {
+		res.Items = append(res.Items, models.AuditEventResponse{
+			ID:         e.ID,
+			ActorID:    int32Ptr(e.ActorID),
+			Action:     e.Action,
+			TargetType: stringPtr(e.TargetType),
+			TargetID:   int32Ptr(e.TargetID),
+			IP:         stringPtr(e.Ip),
+			UserAgent:  stringPtr(e.UserAgent),
+			RequestID:  stringPtr(e.RequestID),
+			Details:    e.Details.RawMessage,
+			CreatedAt:  e.CreateAt,
+		})
+	}
+	if len(events) > page.Limit {
+		last := events[page.Limit-1]
+		next := page.Next(last.CreateAt.Format(time.RFC3339Nano), last.ID)
+		res.NextCursor = &next
+		pagination.SetLink(w, r, next)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+// recordEvent records an event outside of a transaction, for actions that
+// should not fail because their record could not be written.
+func (u UserHandler) recordEvent(r *http.Request, e audit.Event) {
+	if err := audit.Record(r, &u.Storage, e); err != nil {
+		u.Logger.Error("failed to record audit event", "action", e.Action, "error", err)
+	}
+}
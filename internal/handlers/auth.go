@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/errors"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/audit"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/bruteforce"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/clientip"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/email"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/hash"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/jwt"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/middleware"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/problem"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/token"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/models"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/storage"
+)
+
+// refreshTokenTTL is how long a refresh token can be exchanged for a new
+// token pair before the user has to log in again.
+const refreshTokenTTL = 30 * 24 * time.Hour
+
+func (u UserHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req models.LoginRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	account := normalizeEmail(req.Email)
+	attempt, ok := u.beginLogin(w, r, account)
+	if !ok {
+		return
+	}
+
+	user, err := u.Storage.GetUserByEmail(r.Context(), req.Email)
+	if err != nil && err != sql.ErrNoRows {
+		u.releaseLogin(r, account, attempt)
+		u.Logger.Error("failed to get user", "error", err)
+		problem.Write(w, r, errors.Internal("failed to login"))
+		return
+	}
+	if err == sql.ErrNoRows {
+		u.loginFailed(r, attempt, storage.User{}, "unknown_email")
+		problem.Write(w, r, errors.Unauthorized("invalid email or password"))
+		return
+	}
+	if !hash.VerifyPassword(req.Password, user.PasswordHash) {
+		u.loginFailed(r, attempt, user, "invalid_password")
+		problem.Write(w, r, errors.Unauthorized("invalid email or password"))
+		return
+	}
+	// A right password is no guess; take the attempt back unless the login
+	// completes below.
+	if user.SuspendedAt.Valid || user.TotpEnabledAt.Valid {
+		u.releaseLogin(r, account, attempt)
+	}
+	if user.SuspendedAt.Valid {
+		u.recordLoginFailure(r, user.ID, "suspended")
+		problem.Write(w, r, errors.Forbidden("account is suspended"))
+		return
+	}
+
+	if user.TotpEnabledAt.Valid {
+		mfaToken, err := u.Tokens.Generate(jwt.TypeMFA, user.ID, u.Auth.MFATokenTTL)
+		if err != nil {
+			u.Logger.Error("failed to generate mfa token", "error", err)
+			problem.Write(w, r, errors.Internal("failed to login"))
+			return
+		}
+		res := models.MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+			ExpiresIn:   int(u.Auth.MFATokenTTL.Seconds()),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&res)
+		return
+	}
+
+	u.loginSucceeded(r, account)
+	u.login(w, r, user.ID)
+}
+
+// login starts a new session for an authenticated user and responds with its
+// token pair.
+func (u UserHandler) login(w http.ResponseWriter, r *http.Request, userID int32) {
+	familyID, err := token.Generate()
+	if err != nil {
+		u.Logger.Error("failed to generate token family", "error", err)
+		problem.Write(w, r, errors.Internal("failed to login"))
+		return
+	}
+
+	res, err := u.issueTokens(r.Context(), userID, familyID)
+	if err != nil {
+		u.Logger.Error("failed to issue tokens", "error", err)
+		problem.Write(w, r, errors.Internal("failed to login"))
+		return
+	}
+
+	u.recordEvent(r, audit.Event{
+		ActorID:    userID,
+		Action:     audit.ActionLogin,
+		TargetType: audit.TargetUser,
+		TargetID:   userID,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+// recordLoginFailure records a rejected login attempt for the given user,
+// or for an unknown account when userID is 0.
+func (u UserHandler) recordLoginFailure(r *http.Request, userID int32, reason string) {
+	e := audit.Event{
+		Action:  audit.ActionLoginFailed,
+		Details: map[string]string{"reason": reason},
+	}
+	if userID != 0 {
+		e.TargetType, e.TargetID = audit.TargetUser, userID
+	}
+	u.recordEvent(r, e)
+}
+
+// beginLogin reserves a login attempt to account before any credential is
+// checked, counting it as failed until the login succeeds, and responds with
+// 429 while the account or the client address has to wait after failed
+// attempts.
+func (u UserHandler) beginLogin(w http.ResponseWriter, r *http.Request, account string) (bruteforce.Attempt, bool) {
+	attempt, err := u.Limiter.BeginLogin(r.Context(), account, clientip.FromRequest(r))
+	if err != nil {
+		u.Logger.Error("failed to check login attempts", "error", err)
+		problem.Write(w, r, errors.Internal("failed to login"))
+		return attempt, false
+	}
+	if attempt.Wait > 0 {
+		problem.Write(w, r, errors.RateLimited("too many failed login attempts, try again later", attempt.Wait))
+		return attempt, false
+	}
+	return attempt, true
+}
+
+// loginFailed records a failed login whose attempt beginLogin already
+// counted. user is the zero User for unknown accounts. When the attempt
+// locked an existing account out, its owner is told by email.
+func (u UserHandler) loginFailed(r *http.Request, attempt bruteforce.Attempt, user storage.User, reason string) {
+	u.recordLoginFailure(r, user.ID, reason)
+	if attempt.LockedUntil.IsZero() || user.ID == 0 {
+		return
+	}
+
+	u.recordEvent(r, audit.Event{
+		Action:     audit.ActionLockout,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		Details:    map[string]any{"failures": attempt.Failures, "locked_until": attempt.LockedUntil},
+	})
+	err := u.queueEmail(user.Email, email.TemplateLockout, email.MatchLocale(r.Header.Get("Accept-Language")), email.LockoutData{
+		Username: user.Username,
+		Attempts: attempt.Failures,
+		Until:    attempt.LockedUntil,
+	})
+	if err != nil {
+		u.Logger.Error("failed to queue lockout email", "error", err)
+	}
+}
+
+// loginSucceeded forgets the failed logins to account once a login passed
+// all of its factors.
+func (u UserHandler) loginSucceeded(r *http.Request, account string) {
+	if err := u.Limiter.LoginSucceeded(r.Context(), account, clientip.FromRequest(r)); err != nil {
+		u.Logger.Error("failed to reset failed logins", "error", err)
+	}
+}
+
+// releaseLogin takes back an attempt that did not fail without completing
+// the login either.
+func (u UserHandler) releaseLogin(r *http.Request, account string, attempt bruteforce.Attempt) {
+	if err := u.Limiter.ReleaseLogin(r.Context(), account, clientip.FromRequest(r), attempt); err != nil {
+		u.Logger.Error("failed to release login attempt", "error", err)
+	}
+}
+
+// Refresh exchanges a refresh token for a new token pair. Every refresh token
+// can be used once; presenting an already rotated token revokes the whole
+// chain it belongs to, since it means the token has leaked.
+func (u UserHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshTokenRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	stored, err := u.Storage.GetRefreshTokenByHash(r.Context(), token.Hash(req.RefreshToken))
+	if err == sql.ErrNoRows {
+		problem.Write(w, r, errors.Unauthorized("invalid refresh token"))
+		return
+	}
+	if err != nil {
+		u.Logger.Error("failed to get refresh token", "error", err)
+		problem.Write(w, r, errors.Internal("failed to refresh token"))
+		return
+	}
+
+	if stored.RevokedAt.Valid {
+		u.revokeRefreshTokenFamily(r.Context(), stored.FamilyID)
+		problem.Write(w, r, errors.Unauthorized("invalid refresh token"))
+		return
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		problem.Write(w, r, errors.Unauthorized("refresh token expired"))
+		return
+	}
+
+	n, err := u.Storage.RevokeRefreshToken(r.Context(), stored.ID)
+	if err != nil {
+		u.Logger.Error("failed to revoke refresh token", "error", err)
+		problem.Write(w, r, errors.Internal("failed to refresh token"))
+		return
+	}
+	if n == 0 {
+		// Someone else rotated this token between our read and write.
+		u.revokeRefreshTokenFamily(r.Context(), stored.FamilyID)
+		problem.Write(w, r, errors.Unauthorized("invalid refresh token"))
+		return
+	}
+
+	res, err := u.issueTokens(r.Context(), stored.UserID, stored.FamilyID)
+	if err != nil {
+		u.Logger.Error("failed to issue tokens", "error", err)
+		problem.Write(w, r, errors.Internal("failed to refresh token"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+func (u UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshTokenRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	stored, err := u.Storage.GetRefreshTokenByHash(r.Context(), token.Hash(req.RefreshToken))
+	if err != nil && err != sql.ErrNoRows {
+		u.Logger.Error("failed to get refresh token", "error", err)
+		problem.Write(w, r, errors.Internal("failed to logout"))
+		return
+	}
+	if err == nil {
+		err = u.Storage.RevokeRefreshTokenFamily(r.Context(), stored.FamilyID)
+		if err != nil {
+			u.Logger.Error("failed to revoke refresh tokens", "error", err)
+			problem.Write(w, r, errors.Internal("failed to logout"))
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write([]byte(`{"message": "success"}`))
+	if err != nil {
+		u.Logger.Error("failed to write response", "error", err)
+		problem.Write(w, r, errors.Internal("Internal Server Error"))
+		return
+	}
+}
+
+func (u UserHandler) issueTokens(ctx context.Context, userID int32, familyID string) (models.TokenResponse, error) {
+	accessToken, err := u.Tokens.GenerateAccessToken(userID)
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	refreshToken, err := token.Generate()
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	_, err = u.Storage.CreateRefreshToken(ctx, storage.CreateRefreshTokenParams{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: token.Hash(refreshToken),
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	})
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	return models.TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(u.Tokens.AccessTokenTTL().Seconds()),
+	}, nil
+}
+
+// JWKS publishes the public keys access tokens can be verified with.
+func (u UserHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(u.Tokens.JWKS())
+}
+
+func (u UserHandler) revokeRefreshTokenFamily(ctx context.Context, familyID string) {
+	if err := u.Storage.RevokeRefreshTokenFamily(ctx, familyID); err != nil {
+		u.Logger.Error("failed to revoke refresh tokens", "error", err)
+	}
+}
+
+// callerID returns the id of the authenticated user. Requests that still name
+// a user through the given query parameter are only allowed for the caller's
+// own id and get 403 otherwise.
+func callerID(w http.ResponseWriter, r *http.Request, param string) (int32, bool) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return 0, false
+	}
+
+	idStr := r.FormValue(param)
+	if idStr == "" {
+		return userID, true
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		problem.Write(w, r, errors.Invalid("invalid "+param+" parameter"))
+		return 0, false
+	}
+	if int32(id) != userID {
+		problem.Write(w, r, errors.Forbidden("forbidden"))
+		return 0, false
+	}
+	return userID, true
+}
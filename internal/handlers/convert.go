@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/errors"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/problem"
+)
+
+// pathID parses the named path wildcard as an id, writing a 400 response
+// when it is not a valid integer.
+func pathID(w http.ResponseWriter, r *http.Request, name string) (int32, bool) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 32)
+	if err != nil {
+		problem.Write(w, r, errors.Invalid("invalid "+name+" parameter"))
+		return 0, false
+	}
+	return int32(id), true
+}
+
+func nullString(v *string) sql.NullString {
+	if v == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *v, Valid: true}
+}
+
+func nullInt32(v *int32) sql.NullInt32 {
+	if v == nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: *v, Valid: true}
+}
+
+func nullFloat64(v *float64) sql.NullFloat64 {
+	if v == nil {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: *v, Valid: true}
+}
+
+func stringPtr(v sql.NullString) *string {
+	if !v.Valid {
+		return nil
+	}
+	return &v.String
+}
+
+func int32Ptr(v sql.NullInt32) *int32 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int32
+}
+
+func float64Ptr(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
+
+func timePtr(v sql.NullTime) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Time
+}
+
+// convert applies a unit conversion to an optional value.
+func convert(v *float64, fn func(float64) float64) *float64 {
+	if v == nil {
+		return nil
+	}
+	c := fn(*v)
+	return &c
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"net/http"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/errors"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/problem"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/validate"
+)
+
+// maxBodyBytes limits the size of JSON request bodies.
+const maxBodyBytes = 1 << 20
+
+// decode reads the JSON request body into v and checks it against the
+// validation rules declared on its type. Unknown fields, trailing data and
+// oversized bodies are rejected, and all rule violations are reported
+// together.
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(v)
+	if err == nil && dec.Decode(&struct{}{}) != io.EOF {
+		err = stderrors.New("unexpected data after the JSON value")
+	}
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if stderrors.As(err, &tooLarge) {
+			problem.Write(w, r, errors.TooLarge("request body is too large"))
+			return false
+		}
+		problem.Write(w, r, errors.Invalid("invalid request body: "+err.Error()).Wrap(err))
+		return false
+	}
+
+	if fields := validate.Struct(v); len(fields) > 0 {
+		problem.Write(w, r, errors.Invalid("request validation failed", fields...))
+		return false
+	}
+	return true
+}
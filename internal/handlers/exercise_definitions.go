@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/errors"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/middleware"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/problem"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/models"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/storage"
+)
+
+// ListExerciseDefinitions searches the catalog together with the caller's
+// custom exercises by name or alias, optionally narrowed by muscle group and
+// equipment.
+func (u UserHandler) ListExerciseDefinitions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+
+	definitions, err := u.Storage.SearchExerciseDefinitions(r.Context(), storage.SearchExerciseDefinitionsParams{
+		UserID:    userID,
+		Query:     strings.TrimSpace(r.FormValue("q")),
+		Muscle:    r.FormValue("muscle"),
+		Equipment: r.FormValue("equipment"),
+	})
+	if err != nil {
+		u.Logger.Error("failed to search exercise definitions", "error", err)
+		problem.Write(w, r, errors.Internal("failed to get exercise definitions"))
+		return
+	}
+
+	res := make([]models.ExerciseDefinitionResponse, 0, len(definitions))
+	for _, d := range definitions {
+		res = append(res, exerciseDefinitionResponse(d))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+func (u UserHandler) GetExerciseDefinition(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+
+	definition, err := u.Storage.GetExerciseDefinition(r.Context(), storage.GetExerciseDefinitionParams{
+		ID:     id,
+		UserID: sql.NullInt32{Int32: userID, Valid: true},
+	})
+	if err == sql.ErrNoRows {
+		problem.Write(w, r, errors.NotFound("exercise definition not found"))
+		return
+	}
+	if err != nil {
+		u.Logger.Error("failed to get exercise definition", "error", err)
+		problem.Write(w, r, errors.Internal("failed to get exercise definition"))
+		return
+	}
+
+	res := exerciseDefinitionResponse(definition)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+// CreateExerciseDefinition adds a custom exercise that is only visible to the
+// caller.
+func (u UserHandler) CreateExerciseDefinition(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+
+	var req models.ExerciseDefinitionCreateRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+
+	// The vocabularies live in models, so they are checked here rather than
+	// declared on the request.
+	var fields []errors.FieldError
+	for _, f := range []struct {
+		field   string
+		values  []string
+		allowed []string
+	}{
+		{"primary_muscles", req.PrimaryMuscles, models.Muscles},
+		{"secondary_muscles", req.SecondaryMuscles, models.Muscles},
+		{"equipment", []string{req.Equipment}, models.Equipment},
+		{"movement_pattern", []string{req.MovementPattern}, models.MovementPatterns},
+		{"kind", []string{req.Kind}, models.ExerciseKinds},
+	} {
+		for _, v := range f.values {
+			if !slices.Contains(f.allowed, v) {
+				fields = append(fields, errors.FieldError{Field: f.field, Message: "unknown value " + v})
+				break
+			}
+		}
+	}
+	if len(fields) > 0 {
+		problem.Write(w, r, errors.Invalid("request validation failed", fields...))
+		return
+	}
+
+	definition, err := u.Storage.CreateExerciseDefinition(r.Context(), storage.CreateExerciseDefinitionParams{
+		UserID:           sql.NullInt32{Int32: userID, Valid: true},
+		Name:             req.Name,
+		Aliases:          nonNil(req.Aliases),
+		PrimaryMuscles:   req.PrimaryMuscles,
+		SecondaryMuscles: nonNil(req.SecondaryMuscles),
+		Equipment:        req.Equipment,
+		MovementPattern:  req.MovementPattern,
+		Unilateral:       req.Unilateral,
+		Kind:             req.Kind,
+	})
+	if errors.IsUniqueViolation(err) {
+		problem.Write(w, r, errors.Conflict("exercise with this name already exists"))
+		return
+	}
+	if err != nil {
+		u.Logger.Error("failed to create exercise definition", "error", err)
+		problem.Write(w, r, errors.Internal("failed to create exercise definition"))
+		return
+	}
+
+	res := exerciseDefinitionResponse(definition)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&res)
+}
+
+func exerciseDefinitionResponse(definition storage.ExerciseDefinition) models.ExerciseDefinitionResponse {
+	return models.ExerciseDefinitionResponse{
+		ID:               definition.ID,
+		Name:             definition.Name,
+		Aliases:          nonNil(definition.Aliases),
+		PrimaryMuscles:   nonNil(definition.PrimaryMuscles),
+		SecondaryMuscles: nonNil(definition.SecondaryMuscles),
+		Equipment:        definition.Equipment,
+		MovementPattern:  definition.MovementPattern,
+		Unilateral:       definition.Unilateral,
+		Kind:             definition.Kind,
+		Custom:           definition.UserID.Valid,
+		CreateAt:         definition.CreateAt,
+	}
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/errors"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/middleware"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/problem"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/units"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/models"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/storage"
+)
+
+func (u UserHandler) AddExercise(w http.ResponseWriter, r *http.Request) {
+	workoutID, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+
+	var req models.ExerciseCreateRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	_, err := u.Storage.GetWorkoutByUserID(r.Context(), storage.GetWorkoutByUserIDParams{ID: workoutID, UserID: userID})
+	if err == sql.ErrNoRows {
+		problem.Write(w, r, errors.NotFound("workout not found"))
+		return
+	}
+	if err != nil {
+		u.Logger.Error("failed to get workout", "error", err)
+		problem.Write(w, r, errors.Internal("failed to add exercise"))
+		return
+	}
+
+	definition, err := u.Storage.GetExerciseDefinition(r.Context(), storage.GetExerciseDefinitionParams{
+		ID:     req.ExerciseDefinitionID,
+		UserID: sql.NullInt32{Int32: userID, Valid: true},
+	})
+	if err == sql.ErrNoRows {
+		problem.Write(w, r, errors.Invalid("exercise definition not found"))
+		return
+	}
+	if err != nil {
+		u.Logger.Error("failed to get exercise definition", "error", err)
+		problem.Write(w, r, errors.Internal("failed to add exercise"))
+		return
+	}
+
+	exercise, err := u.Storage.CreateExercise(r.Context(), storage.CreateExerciseParams{
+		WorkoutID:    workoutID,
+		Name:         definition.Name,
+		Notes:        nullString(req.Notes),
+		DefinitionID: sql.NullInt32{Int32: definition.ID, Valid: true},
+	})
+	if err != nil {
+		u.Logger.Error("failed to create exercise", "error", err)
+		problem.Write(w, r, errors.Internal("failed to add exercise"))
+		return
+	}
+
+	res := exerciseResponse(exercise, nil, units.Converter{})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&res)
+}
+
+func (u UserHandler) AddSet(w http.ResponseWriter, r *http.Request) {
+	workoutID, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	exerciseID, ok := pathID(w, r, "exercise_id")
+	if !ok {
+		return
+	}
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+
+	var req models.SetCreateRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	c, ok := u.unitConverter(w, r, userID, req.Unit)
+	if !ok {
+		return
+	}
+
+	exercise, err := u.Storage.GetExerciseByUserID(r.Context(), storage.GetExerciseByUserIDParams{ID: exerciseID, UserID: userID})
+	if err == sql.ErrNoRows || (err == nil && exercise.WorkoutID != workoutID) {
+		problem.Write(w, r, errors.NotFound("exercise not found"))
+		return
+	}
+	if err != nil {
+		u.Logger.Error("failed to get exercise", "error", err)
+		problem.Write(w, r, errors.Internal("failed to log set"))
+		return
+	}
+
+	var set storage.Set
+	var prs []storage.PersonalRecord
+	err = u.withTx(r.Context(), func(q *storage.Queries) error {
+		var err error
+		set, err = q.CreateSet(r.Context(), storage.CreateSetParams{
+			ExerciseID:      exerciseID,
+			Repetitions:     nullInt32(req.Repetitions),
+			Weight:          nullFloat64(convert(req.Weight, c.ToKilograms)),
+			DurationSeconds: nullInt32(req.DurationSeconds),
+			DistanceMeters:  nullFloat64(convert(req.Distance, c.ToMetres)),
+			Rpe:             nullFloat64(req.Rpe),
+			RestSeconds:     nullInt32(req.RestSeconds),
+		})
+		if err != nil {
+			return err
+		}
+		prs, err = detectRecords(r.Context(), q, userID, exercise, set)
+		return err
+	})
+	if err != nil {
+		u.Logger.Error("failed to create set", "error", err)
+		problem.Write(w, r, errors.Internal("failed to log set"))
+		return
+	}
+
+	res := models.SetSaveResponse{
+		SetResponse:     setResponse(set, c),
+		Unit:            c.Name(),
+		PersonalRecords: personalRecordResponses(prs, c),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&res)
+}
+
+// UpdateSet records the actual numbers of a set, typically one that was
+// planned from a template.
+func (u UserHandler) UpdateSet(w http.ResponseWriter, r *http.Request) {
+	workoutID, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	exerciseID, ok := pathID(w, r, "exercise_id")
+	if !ok {
+		return
+	}
+	setID, ok := pathID(w, r, "set_id")
+	if !ok {
+		return
+	}
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+
+	var req models.SetCreateRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	c, ok := u.unitConverter(w, r, userID, req.Unit)
+	if !ok {
+		return
+	}
+
+	exercise, err := u.Storage.GetExerciseByUserID(r.Context(), storage.GetExerciseByUserIDParams{ID: exerciseID, UserID: userID})
+	if err == sql.ErrNoRows || (err == nil && exercise.WorkoutID != workoutID) {
+		problem.Write(w, r, errors.NotFound("exercise not found"))
+		return
+	}
+	if err != nil {
+		u.Logger.Error("failed to get exercise", "error", err)
+		problem.Write(w, r, errors.Internal("failed to update set"))
+		return
+	}
+
+	var set storage.Set
+	var prs []storage.PersonalRecord
+	err = u.withTx(r.Context(), func(q *storage.Queries) error {
+		var err error
+		set, err = q.UpdateSet(r.Context(), storage.UpdateSetParams{
+			ID:              setID,
+			ExerciseID:      exerciseID,
+			Repetitions:     nullInt32(req.Repetitions),
+			Weight:          nullFloat64(convert(req.Weight, c.ToKilograms)),
+			DurationSeconds: nullInt32(req.DurationSeconds),
+			DistanceMeters:  nullFloat64(convert(req.Distance, c.ToMetres)),
+			Rpe:             nullFloat64(req.Rpe),
+			RestSeconds:     nullInt32(req.RestSeconds),
+		})
+		if err != nil {
+			return err
+		}
+		if !exercise.DefinitionID.Valid {
+			return nil
+		}
+		// The set may have held records that no longer stand, so its records
+		// are dropped and the best of the remaining sets found again.
+		err = q.DeleteSetPersonalRecords(r.Context(), storage.DeleteSetPersonalRecordsParams{
+			SetID:        nullInt32(&set.ID),
+			WorkoutID:    nullInt32(&exercise.WorkoutID),
+			DefinitionID: exercise.DefinitionID.Int32,
+		})
+		if err != nil {
+			return err
+		}
+		all, err := reconcileRecords(r.Context(), q, userID, exercise.DefinitionID.Int32)
+		if err != nil {
+			return err
+		}
+		for _, pr := range all {
+			if pr.SetID.Valid && pr.SetID.Int32 == set.ID {
+				prs = append(prs, pr)
+			}
+		}
+		return nil
+	})
+	if err == sql.ErrNoRows {
+		problem.Write(w, r, errors.NotFound("set not found"))
+		return
+	}
+	if err != nil {
+		u.Logger.Error("failed to update set", "error", err)
+		problem.Write(w, r, errors.Internal("failed to update set"))
+		return
+	}
+
+	res := models.SetSaveResponse{
+		SetResponse:     setResponse(set, c),
+		Unit:            c.Name(),
+		PersonalRecords: personalRecordResponses(prs, c),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+// GetWorkout returns a workout together with all of its exercises and sets.
+func (u UserHandler) GetWorkout(w http.ResponseWriter, r *http.Request) {
+	workoutID, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+
+	workout, err := u.Storage.GetWorkoutByUserID(r.Context(), storage.GetWorkoutByUserIDParams{ID: workoutID, UserID: userID})
+	if err == sql.ErrNoRows {
+		problem.Write(w, r, errors.NotFound("workout not found"))
+		return
+	}
+	if err != nil {
+		u.Logger.Error("failed to get workout", "error", err)
+		problem.Write(w, r, errors.Internal("failed to get workout"))
+		return
+	}
+	c, ok := u.unitConverter(w, r, userID, nil)
+	if !ok {
+		return
+	}
+
+	res, err := workoutDetail(r.Context(), &u.Storage, workout, c)
+	if err != nil {
+		u.Logger.Error("failed to get workout exercises", "error", err)
+		problem.Write(w, r, errors.Internal("failed to get workout"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+// workoutDetail loads the exercises and sets of a workout into the nested
+// response shape, presenting values in the units of c.
+func workoutDetail(ctx context.Context, q *storage.Queries, workout storage.Workout, c units.Converter) (models.WorkoutDetailResponse, error) {
+	exercises, err := q.GetExercisesByWorkoutID(ctx, workout.ID)
+	if err != nil {
+		return models.WorkoutDetailResponse{}, err
+	}
+
+	sets, err := q.GetSetsByWorkoutID(ctx, workout.ID)
+	if err != nil {
+		return models.WorkoutDetailResponse{}, err
+	}
+
+	setsByExercise := make(map[int32][]storage.Set)
+	for _, s := range sets {
+		setsByExercise[s.ExerciseID] = append(setsByExercise[s.ExerciseID], s)
+	}
+
+	res := models.WorkoutDetailResponse{
+		WorkoutCreateResponse: workoutResponse(workout),
+		Unit:                  c.Name(),
+		Exercises:             make([]models.ExerciseResponse, 0, len(exercises)),
+	}
+	for _, e := range exercises {
+		res.Exercises = append(res.Exercises, exerciseResponse(e, setsByExercise[e.ID], c))
+	}
+	return res, nil
+}
+
+func workoutResponse(workout storage.Workout) models.WorkoutCreateResponse {
+	return models.WorkoutCreateResponse{
+		ID:          workout.ID,
+		UserID:      workout.UserID,
+		Name:        workout.Name,
+		Description: stringPtr(workout.Description),
+		Date:        workout.Date.Format(time.DateOnly),
+		StartedAt:   workout.StartedAt,
+		EndedAt:     timePtr(workout.EndedAt),
+		Timezone:    workout.Timezone,
+		TemplateID:  int32Ptr(workout.TemplateID),
+		CreateAt:    workout.CreateAt,
+		UpdateAt:    workout.UpdateAt,
+	}
+}
+
+func exerciseResponse(exercise storage.Exercise, sets []storage.Set, c units.Converter) models.ExerciseResponse {
+	res := models.ExerciseResponse{
+		ID:                   exercise.ID,
+		WorkoutID:            exercise.WorkoutID,
+		ExerciseDefinitionID: int32Ptr(exercise.DefinitionID),
+		Name:                 exercise.Name,
+		Notes:                stringPtr(exercise.Notes),
+		Position:             exercise.Position,
+		CreateAt:             exercise.CreateAt,
+		Sets:                 make([]models.SetResponse, 0, len(sets)),
+	}
+	for _, s := range sets {
+		res.Sets = append(res.Sets, setResponse(s, c))
+	}
+	return res
+}
+
+func setResponse(set storage.Set, c units.Converter) models.SetResponse {
+	return models.SetResponse{
+		ID:              set.ID,
+		ExerciseID:      set.ExerciseID,
+		Position:        set.Position,
+		Repetitions:     int32Ptr(set.Repetitions),
+		Weight:          convert(float64Ptr(set.Weight), c.Weight),
+		DurationSeconds: int32Ptr(set.DurationSeconds),
+		Distance:        convert(float64Ptr(set.DistanceMeters), c.Distance),
+		Rpe:             float64Ptr(set.Rpe),
+		RestSeconds:     int32Ptr(set.RestSeconds),
+		TargetRepsMin:   int32Ptr(set.TargetRepsMin),
+		TargetRepsMax:   int32Ptr(set.TargetRepsMax),
+		TargetWeightMin: convert(float64Ptr(set.TargetWeightMin), c.Load),
+		TargetWeightMax: convert(float64Ptr(set.TargetWeightMax), c.Load),
+		CreateAt:        set.CreateAt,
+	}
+}
@@ -0,0 +1,385 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/errors"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/body"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/middleware"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/problem"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/units"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/models"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/storage"
+)
+
+const (
+	defaultAverageWindow = 7
+	maxAverageWindow     = 90
+)
+
+func (u UserHandler) CreateBodyMeasurement(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+
+	var req models.BodyMeasurementRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	measuredOn, ok := validBodyMeasurement(w, r, req)
+	if !ok {
+		return
+	}
+	c, ok := u.unitConverter(w, r, userID, req.Unit)
+	if !ok {
+		return
+	}
+
+	m, err := u.Storage.CreateBodyMeasurement(r.Context(), storage.CreateBodyMeasurementParams{
+		UserID:         userID,
+		MeasuredOn:     measuredOn,
+		WeightKg:       nullFloat64(convert(req.Weight, c.ToKilograms)),
+		BodyFatPercent: nullFloat64(req.BodyFatPercent),
+		NeckCm:         nullFloat64(convert(req.Neck, c.ToCentimetres)),
+		ChestCm:        nullFloat64(convert(req.Chest, c.ToCentimetres)),
+		WaistCm:        nullFloat64(convert(req.Waist, c.ToCentimetres)),
+		HipsCm:         nullFloat64(convert(req.Hips, c.ToCentimetres)),
+		ArmCm:          nullFloat64(convert(req.Arm, c.ToCentimetres)),
+		ThighCm:        nullFloat64(convert(req.Thigh, c.ToCentimetres)),
+		CalfCm:         nullFloat64(convert(req.Calf, c.ToCentimetres)),
+		Notes:          nullString(req.Notes),
+	})
+	if err != nil {
+		u.Logger.Error("failed to create body measurement", "error", err)
+		problem.Write(w, r, errors.Internal("failed to create body measurement"))
+		return
+	}
+
+	height, err := u.heightCm(r.Context(), userID)
+	if err != nil {
+		u.Logger.Error("failed to get height", "error", err)
+		problem.Write(w, r, errors.Internal("failed to create body measurement"))
+		return
+	}
+	res := bodyMeasurementResponse(m, height, c)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&res)
+}
+
+// ListBodyMeasurements returns the measurements between from and to (the last
+// 90 days by default) with the weight smoothed by a trailing moving average of
+// window days.
+func (u UserHandler) ListBodyMeasurements(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+
+	loc, ok := u.location(w, r, userID, nil)
+	if !ok {
+		return
+	}
+	to := today(loc)
+	if v := r.FormValue("to"); v != "" {
+		var err error
+		if to, err = time.Parse(time.DateOnly, v); err != nil {
+			problem.Write(w, r, errors.Invalid("invalid to parameter, expected YYYY-MM-DD"))
+			return
+		}
+	}
+	from := to.AddDate(0, 0, -89)
+	if v := r.FormValue("from"); v != "" {
+		var err error
+		if from, err = time.Parse(time.DateOnly, v); err != nil {
+			problem.Write(w, r, errors.Invalid("invalid from parameter, expected YYYY-MM-DD"))
+			return
+		}
+	}
+	if to.Before(from) {
+		problem.Write(w, r, errors.Invalid("to must not be before from"))
+		return
+	}
+	c, ok := u.unitConverter(w, r, userID, nil)
+	if !ok {
+		return
+	}
+	window := defaultAverageWindow
+	if v := r.FormValue("window"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxAverageWindow {
+			problem.Write(w, r, errors.Invalid("window must be between 1 and 90 days"))
+			return
+		}
+		window = n
+	}
+
+	// Load the days before from as well so the first averages in the range
+	// cover a full window.
+	measurements, err := u.Storage.ListBodyMeasurements(r.Context(), storage.ListBodyMeasurementsParams{
+		UserID:   userID,
+		FromDate: from.AddDate(0, 0, -window+1),
+		ToDate:   to,
+	})
+	if err != nil {
+		u.Logger.Error("failed to list body measurements", "error", err)
+		problem.Write(w, r, errors.Internal("failed to get body measurements"))
+		return
+	}
+
+	height, err := u.heightCm(r.Context(), userID)
+	if err != nil {
+		u.Logger.Error("failed to get height", "error", err)
+		problem.Write(w, r, errors.Internal("failed to get body measurements"))
+		return
+	}
+
+	var samples []body.Sample
+	for _, m := range measurements {
+		if m.WeightKg.Valid {
+			samples = append(samples, body.Sample{Date: m.MeasuredOn, Value: m.WeightKg.Float64})
+		}
+	}
+	averages := body.MovingAverage(samples, window)
+
+	res := models.BodyMeasurementListResponse{
+		From:          from.Format(time.DateOnly),
+		To:            to.Format(time.DateOnly),
+		AverageWindow: window,
+		Unit:          c.Name(),
+		Height:        convert(height, c.Length),
+		Measurements:  []models.BodyMeasurementResponse{},
+	}
+	i := 0
+	for _, m := range measurements {
+		var average *float64
+		if m.WeightKg.Valid {
+			average = convert(&averages[i], c.Weight)
+			i++
+		}
+		if m.MeasuredOn.Before(from) {
+			continue
+		}
+		item := bodyMeasurementResponse(m, height, c)
+		item.WeightAverage = average
+		res.Measurements = append(res.Measurements, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+func (u UserHandler) GetBodyMeasurement(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+	c, ok := u.unitConverter(w, r, userID, nil)
+	if !ok {
+		return
+	}
+
+	m, err := u.Storage.GetBodyMeasurement(r.Context(), storage.GetBodyMeasurementParams{ID: id, UserID: userID})
+	if err == sql.ErrNoRows {
+		problem.Write(w, r, errors.NotFound("body measurement not found"))
+		return
+	}
+	if err != nil {
+		u.Logger.Error("failed to get body measurement", "error", err)
+		problem.Write(w, r, errors.Internal("failed to get body measurement"))
+		return
+	}
+
+	height, err := u.heightCm(r.Context(), userID)
+	if err != nil {
+		u.Logger.Error("failed to get height", "error", err)
+		problem.Write(w, r, errors.Internal("failed to get body measurement"))
+		return
+	}
+	res := bodyMeasurementResponse(m, height, c)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+func (u UserHandler) UpdateBodyMeasurement(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+
+	var req models.BodyMeasurementRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	measuredOn, ok := validBodyMeasurement(w, r, req)
+	if !ok {
+		return
+	}
+	c, ok := u.unitConverter(w, r, userID, req.Unit)
+	if !ok {
+		return
+	}
+
+	m, err := u.Storage.UpdateBodyMeasurement(r.Context(), storage.UpdateBodyMeasurementParams{
+		ID:             id,
+		UserID:         userID,
+		MeasuredOn:     measuredOn,
+		WeightKg:       nullFloat64(convert(req.Weight, c.ToKilograms)),
+		BodyFatPercent: nullFloat64(req.BodyFatPercent),
+		NeckCm:         nullFloat64(convert(req.Neck, c.ToCentimetres)),
+		ChestCm:        nullFloat64(convert(req.Chest, c.ToCentimetres)),
+		WaistCm:        nullFloat64(convert(req.Waist, c.ToCentimetres)),
+		HipsCm:         nullFloat64(convert(req.Hips, c.ToCentimetres)),
+		ArmCm:          nullFloat64(convert(req.Arm, c.ToCentimetres)),
+		ThighCm:        nullFloat64(convert(req.Thigh, c.ToCentimetres)),
+		CalfCm:         nullFloat64(convert(req.Calf, c.ToCentimetres)),
+		Notes:          nullString(req.Notes),
+	})
+	if err == sql.ErrNoRows {
+		problem.Write(w, r, errors.NotFound("body measurement not found"))
+		return
+	}
+	if err != nil {
+		u.Logger.Error("failed to update body measurement", "error", err)
+		problem.Write(w, r, errors.Internal("failed to update body measurement"))
+		return
+	}
+
+	height, err := u.heightCm(r.Context(), userID)
+	if err != nil {
+		u.Logger.Error("failed to get height", "error", err)
+		problem.Write(w, r, errors.Internal("failed to update body measurement"))
+		return
+	}
+	res := bodyMeasurementResponse(m, height, c)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+func (u UserHandler) DeleteBodyMeasurement(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+
+	n, err := u.Storage.DeleteBodyMeasurement(r.Context(), storage.DeleteBodyMeasurementParams{ID: id, UserID: userID})
+	if err != nil {
+		u.Logger.Error("failed to delete body measurement", "error", err)
+		problem.Write(w, r, errors.Internal("failed to delete body measurement"))
+		return
+	}
+	if n == 0 {
+		problem.Write(w, r, errors.NotFound("body measurement not found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func validBodyMeasurement(w http.ResponseWriter, r *http.Request, req models.BodyMeasurementRequest) (time.Time, bool) {
+	measuredOn, err := time.Parse(time.DateOnly, req.MeasuredOn)
+	if err != nil {
+		problem.Write(w, r, errors.Field("measured_on", "expected YYYY-MM-DD"))
+		return time.Time{}, false
+	}
+
+	values := []struct {
+		field string
+		v     *float64
+	}{
+		{"weight", req.Weight}, {"neck", req.Neck}, {"chest", req.Chest}, {"waist", req.Waist},
+		{"hips", req.Hips}, {"arm", req.Arm}, {"thigh", req.Thigh}, {"calf", req.Calf},
+	}
+	var fields []errors.FieldError
+	empty := req.BodyFatPercent == nil
+	for _, f := range values {
+		if f.v == nil {
+			continue
+		}
+		if *f.v <= 0 {
+			fields = append(fields, errors.FieldError{Field: f.field, Message: "must be positive"})
+		}
+		empty = false
+	}
+	if req.BodyFatPercent != nil && (*req.BodyFatPercent < 0 || *req.BodyFatPercent >= 100) {
+		fields = append(fields, errors.FieldError{Field: "body_fat_percent", Message: "must be in [0, 100)"})
+	}
+	if len(fields) > 0 {
+		problem.Write(w, r, errors.Invalid("invalid measurements", fields...))
+		return time.Time{}, false
+	}
+	if empty {
+		problem.Write(w, r, errors.Invalid("missing measurements"))
+		return time.Time{}, false
+	}
+	return measuredOn, true
+}
+
+// heightCm returns the height stored in the caller's profile, or nil when it
+// has not been set.
+func (u UserHandler) heightCm(ctx context.Context, userID int32) (*float64, error) {
+	profile, err := u.profile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return profile.HeightCm, nil
+}
+
+// bodyMeasurementResponse presents m in the units of c and derives BMI and
+// FFMI from it when the height is known.
+func bodyMeasurementResponse(m storage.BodyMeasurement, heightCm *float64, c units.Converter) models.BodyMeasurementResponse {
+	res := models.BodyMeasurementResponse{
+		ID:             m.ID,
+		MeasuredOn:     m.MeasuredOn.Format(time.DateOnly),
+		Weight:         convert(float64Ptr(m.WeightKg), c.Weight),
+		BodyFatPercent: float64Ptr(m.BodyFatPercent),
+		Neck:           convert(float64Ptr(m.NeckCm), c.Length),
+		Chest:          convert(float64Ptr(m.ChestCm), c.Length),
+		Waist:          convert(float64Ptr(m.WaistCm), c.Length),
+		Hips:           convert(float64Ptr(m.HipsCm), c.Length),
+		Arm:            convert(float64Ptr(m.ArmCm), c.Length),
+		Thigh:          convert(float64Ptr(m.ThighCm), c.Length),
+		Calf:           convert(float64Ptr(m.CalfCm), c.Length),
+		Notes:          stringPtr(m.Notes),
+		Unit:           c.Name(),
+		CreateAt:       m.CreateAt,
+		UpdateAt:       m.UpdateAt,
+	}
+	if heightCm == nil || !m.WeightKg.Valid {
+		return res
+	}
+
+	bmi := body.BMI(m.WeightKg.Float64, *heightCm)
+	res.BMI = &bmi
+	if m.BodyFatPercent.Valid {
+		ffmi, normalized := body.FFMI(m.WeightKg.Float64, m.BodyFatPercent.Float64, *heightCm)
+		res.FFMI, res.NormalizedFFMI = &ffmi, &normalized
+	}
+	return res
+}
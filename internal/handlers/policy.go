@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/errors"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/problem"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/storage"
+)
+
+// Permissions checked by handlers. Which roles hold them is stored in the
+// role_permissions table.
+const (
+	PermUsersRead          = "users:read"
+	PermUsersSuspend       = "users:suspend"
+	PermUsersResetPassword = "users:reset_password"
+	PermUsersManageRoles   = "users:manage_roles"
+	PermAuditRead          = "audit:read"
+)
+
+// authorize loads the authenticated user and checks that their role grants
+// permission, responding with 403 otherwise.
+func (u UserHandler) authorize(w http.ResponseWriter, r *http.Request, permission string) (storage.User, bool) {
+	user, ok := u.currentUser(w, r)
+	if !ok {
+		return storage.User{}, false
+	}
+
+	allowed, err := u.Storage.HasPermission(r.Context(), storage.HasPermissionParams{
+		Role:       user.Role,
+		Permission: permission,
+	})
+	if err != nil {
+		u.Logger.Error("failed to check permission", "error", err)
+		problem.Write(w, r, errors.Internal("failed to check permission"))
+		return storage.User{}, false
+	}
+	if !allowed {
+		problem.Write(w, r, errors.Forbidden("forbidden"))
+		return storage.User{}, false
+	}
+	return user, true
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/errors"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/problem"
+	userprofile "github.com/Oyatillohgayratov/fitness-tracking-app/internal/profile"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/units"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/models"
+)
+
+// profile loads and decodes the profile of a user.
+func (u UserHandler) profile(ctx context.Context, userID int32) (models.Profile, error) {
+	user, err := u.Storage.GetUser(ctx, userID)
+	if err != nil {
+		return models.Profile{}, err
+	}
+	return userprofile.Decode(user.Profile.RawMessage)
+}
+
+// unitConverter picks the unit system of a request: unit from the request
+// body if given, else the unit query parameter, else the caller's profile
+// preference, else metric.
+func (u UserHandler) unitConverter(w http.ResponseWriter, r *http.Request, userID int32, unit *string) (units.Converter, bool) {
+	system := r.FormValue("unit")
+	if unit != nil {
+		system = *unit
+	}
+	if system == "" {
+		profile, err := u.profile(r.Context(), userID)
+		if err != nil {
+			u.Logger.Error("failed to get profile", "error", err)
+			problem.Write(w, r, errors.Internal("failed to get unit preference"))
+			return units.Converter{}, false
+		}
+		system = units.Metric
+		if profile.Units != nil {
+			system = *profile.Units
+		}
+	}
+	if !units.Valid(system) {
+		problem.Write(w, r, errors.Invalid("unit must be one of metric, imperial"))
+		return units.Converter{}, false
+	}
+	return units.Converter{System: system}, true
+}
+
+// location picks the timezone of a request: tz from the request body if
+// given, else the tz query parameter, else the caller's profile timezone,
+// else UTC.
+func (u UserHandler) location(w http.ResponseWriter, r *http.Request, userID int32, tz *string) (*time.Location, bool) {
+	name := r.FormValue("tz")
+	if tz != nil {
+		name = *tz
+	}
+	if name == "" {
+		profile, err := u.profile(r.Context(), userID)
+		if err != nil {
+			u.Logger.Error("failed to get profile", "error", err)
+			problem.Write(w, r, errors.Internal("failed to get timezone"))
+			return nil, false
+		}
+		if profile.Timezone == nil {
+			return time.UTC, true
+		}
+		name = *profile.Timezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		problem.Write(w, r, errors.Invalid("invalid timezone"))
+		return nil, false
+	}
+	return loc, true
+}
+
+// today returns the current date in loc.
+func today(loc *time.Location) time.Time {
+	return localDate(time.Now(), loc)
+}
+
+// localDate returns the calendar date t falls on in loc, as midnight UTC like
+// the dates read from DATE columns.
+func localDate(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
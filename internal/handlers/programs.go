@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/errors"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/middleware"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/problem"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/units"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/models"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/storage"
+)
+
+const defaultDeloadFactor = 0.6
+
+func (u UserHandler) CreateProgram(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+
+	var req models.ProgramRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	deloadFactor := defaultDeloadFactor
+	if req.DeloadFactor != nil {
+		deloadFactor = *req.DeloadFactor
+	}
+	if deloadFactor <= 0 || deloadFactor > 1 {
+		problem.Write(w, r, errors.Invalid("deload_factor must be in (0, 1]"))
+		return
+	}
+	c, ok := u.unitConverter(w, r, userID, req.Unit)
+	if !ok {
+		return
+	}
+	for _, s := range req.Sessions {
+		if s.Week != nil && (*s.Week < 1 || *s.Week > req.Weeks) {
+			problem.Write(w, r, errors.Invalid("session week is outside of the program"))
+			return
+		}
+		_, err := u.Storage.GetWorkoutTemplate(r.Context(), storage.GetWorkoutTemplateParams{ID: s.TemplateID, UserID: userID})
+		if err == sql.ErrNoRows {
+			problem.Write(w, r, errors.Invalid("template not found"))
+			return
+		}
+		if err != nil {
+			u.Logger.Error("failed to get template", "error", err)
+			problem.Write(w, r, errors.Internal("failed to create program"))
+			return
+		}
+	}
+
+	var program storage.Program
+	sessions := make([]storage.ProgramSession, 0, len(req.Sessions))
+	err := u.withTx(r.Context(), func(q *storage.Queries) error {
+		var err error
+		program, err = q.CreateProgram(r.Context(), storage.CreateProgramParams{
+			UserID:                userID,
+			Name:                  req.Name,
+			Description:           nullString(req.Description),
+			Weeks:                 req.Weeks,
+			WeeklyWeightIncrement: c.ToKilograms(req.WeeklyWeightIncrement),
+			DeloadEveryWeeks:      nullInt32(req.DeloadEveryWeeks),
+			DeloadFactor:          deloadFactor,
+		})
+		if err != nil {
+			return err
+		}
+		for _, s := range req.Sessions {
+			session, err := q.CreateProgramSession(r.Context(), storage.CreateProgramSessionParams{
+				ProgramID:  program.ID,
+				Week:       nullInt32(s.Week),
+				Day:        s.Day,
+				TemplateID: s.TemplateID,
+			})
+			if err != nil {
+				return err
+			}
+			sessions = append(sessions, session)
+		}
+		return nil
+	})
+	if err != nil {
+		u.Logger.Error("failed to create program", "error", err)
+		problem.Write(w, r, errors.Internal("failed to create program"))
+		return
+	}
+
+	res := programResponse(program, sessions, c)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&res)
+}
+
+func (u UserHandler) ListPrograms(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+	c, ok := u.unitConverter(w, r, userID, nil)
+	if !ok {
+		return
+	}
+
+	programs, err := u.Storage.ListPrograms(r.Context(), userID)
+	if err != nil {
+		u.Logger.Error("failed to list programs", "error", err)
+		problem.Write(w, r, errors.Internal("failed to get programs"))
+		return
+	}
+
+	res := make([]models.ProgramResponse, 0, len(programs))
+	for _, p := range programs {
+		res = append(res, programResponse(p, nil, c))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+func (u UserHandler) GetProgram(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+	c, ok := u.unitConverter(w, r, userID, nil)
+	if !ok {
+		return
+	}
+
+	program, err := u.Storage.GetProgram(r.Context(), storage.GetProgramParams{ID: id, UserID: userID})
+	if err == sql.ErrNoRows {
+		problem.Write(w, r, errors.NotFound("program not found"))
+		return
+	}
+	if err != nil {
+		u.Logger.Error("failed to get program", "error", err)
+		problem.Write(w, r, errors.Internal("failed to get program"))
+		return
+	}
+
+	sessions, err := u.Storage.GetProgramSessions(r.Context(), program.ID)
+	if err != nil {
+		u.Logger.Error("failed to get program sessions", "error", err)
+		problem.Write(w, r, errors.Internal("failed to get program"))
+		return
+	}
+
+	res := programResponse(program, sessions, c)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+func (u UserHandler) DeleteProgram(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+
+	n, err := u.Storage.DeleteProgram(r.Context(), storage.DeleteProgramParams{ID: id, UserID: userID})
+	if err != nil {
+		u.Logger.Error("failed to delete program", "error", err)
+		problem.Write(w, r, errors.Internal("failed to delete program"))
+		return
+	}
+	if n == 0 {
+		problem.Write(w, r, errors.NotFound("program not found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (u UserHandler) EnrollInProgram(w http.ResponseWriter, r *http.Request) {
+	programID, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+
+	var req models.EnrollmentRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	startDate, err := time.Parse(time.DateOnly, req.StartDate)
+	if err != nil {
+		problem.Write(w, r, errors.Field("start_date", "must be a date in YYYY-MM-DD format"))
+		return
+	}
+
+	_, err = u.Storage.GetProgram(r.Context(), storage.GetProgramParams{ID: programID, UserID: userID})
+	if err == sql.ErrNoRows {
+		problem.Write(w, r, errors.NotFound("program not found"))
+		return
+	}
+	if err != nil {
+		u.Logger.Error("failed to get program", "error", err)
+		problem.Write(w, r, errors.Internal("failed to enroll"))
+		return
+	}
+
+	enrollment, err := u.Storage.CreateProgramEnrollment(r.Context(), storage.CreateProgramEnrollmentParams{
+		UserID:    userID,
+		ProgramID: programID,
+		StartDate: startDate,
+	})
+	if err != nil {
+		u.Logger.Error("failed to create enrollment", "error", err)
+		problem.Write(w, r, errors.Internal("failed to enroll"))
+		return
+	}
+
+	res := enrollmentResponse(enrollment)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&res)
+}
+
+func (u UserHandler) ListEnrollments(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+
+	enrollments, err := u.Storage.ListProgramEnrollments(r.Context(), userID)
+	if err != nil {
+		u.Logger.Error("failed to list enrollments", "error", err)
+		problem.Write(w, r, errors.Internal("failed to get enrollments"))
+		return
+	}
+
+	res := make([]models.EnrollmentResponse, 0, len(enrollments))
+	for _, e := range enrollments {
+		res = append(res, enrollmentResponse(e))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+func (u UserHandler) DeleteEnrollment(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+
+	n, err := u.Storage.DeleteProgramEnrollment(r.Context(), storage.DeleteProgramEnrollmentParams{ID: id, UserID: userID})
+	if err != nil {
+		u.Logger.Error("failed to delete enrollment", "error", err)
+		problem.Write(w, r, errors.Internal("failed to delete enrollment"))
+		return
+	}
+	if n == 0 {
+		problem.Write(w, r, errors.NotFound("enrollment not found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func programResponse(program storage.Program, sessions []storage.ProgramSession, c units.Converter) models.ProgramResponse {
+	res := models.ProgramResponse{
+		ID:                    program.ID,
+		Name:                  program.Name,
+		Description:           stringPtr(program.Description),
+		Weeks:                 program.Weeks,
+		WeeklyWeightIncrement: c.Weight(program.WeeklyWeightIncrement),
+		DeloadEveryWeeks:      int32Ptr(program.DeloadEveryWeeks),
+		DeloadFactor:          program.DeloadFactor,
+		Unit:                  c.Name(),
+		CreateAt:              program.CreateAt,
+		UpdateAt:              program.UpdateAt,
+	}
+	for _, s := range sessions {
+		res.Sessions = append(res.Sessions, models.ProgramSessionResponse{
+			ID:         s.ID,
+			Week:       int32Ptr(s.Week),
+			Day:        s.Day,
+			TemplateID: s.TemplateID,
+		})
+	}
+	return res
+}
+
+func enrollmentResponse(enrollment storage.ProgramEnrollment) models.EnrollmentResponse {
+	return models.EnrollmentResponse{
+		ID:        enrollment.ID,
+		ProgramID: enrollment.ProgramID,
+		StartDate: enrollment.StartDate.Format(time.DateOnly),
+		CreateAt:  enrollment.CreateAt,
+	}
+}
@@ -0,0 +1,371 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"math"
+	"net/http"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/errors"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/middleware"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/problem"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/records"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/units"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/models"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/storage"
+)
+
+// ListRecords returns the caller's current personal records for every
+// exercise.
+func (u UserHandler) ListRecords(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+
+	c, ok := u.unitConverter(w, r, userID, nil)
+	if !ok {
+		return
+	}
+
+	current, err := u.Storage.ListCurrentPersonalRecords(r.Context(), userID)
+	if err != nil {
+		u.Logger.Error("failed to list personal records", "error", err)
+		problem.Write(w, r, errors.Internal("failed to get personal records"))
+		return
+	}
+
+	res := personalRecordResponses(bestRecords(current), c)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// GetExerciseRecords returns the current personal records of one exercise
+// together with every record ever set for it.
+func (u UserHandler) GetExerciseRecords(w http.ResponseWriter, r *http.Request) {
+	definitionID, ok := pathID(w, r, "exercise")
+	if !ok {
+		return
+	}
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+	c, ok := u.unitConverter(w, r, userID, nil)
+	if !ok {
+		return
+	}
+
+	current, err := u.Storage.GetCurrentPersonalRecords(r.Context(), storage.GetCurrentPersonalRecordsParams{
+		UserID:       userID,
+		DefinitionID: definitionID,
+	})
+	if err != nil {
+		u.Logger.Error("failed to get personal records", "error", err)
+		problem.Write(w, r, errors.Internal("failed to get personal records"))
+		return
+	}
+
+	history, err := u.Storage.GetPersonalRecordHistory(r.Context(), storage.GetPersonalRecordHistoryParams{
+		UserID:       userID,
+		DefinitionID: definitionID,
+	})
+	if err != nil {
+		u.Logger.Error("failed to get personal record history", "error", err)
+		problem.Write(w, r, errors.Internal("failed to get personal records"))
+		return
+	}
+
+	res := models.PersonalRecordHistoryResponse{
+		ExerciseDefinitionID: definitionID,
+		Current:              personalRecordResponses(bestRecords(current), c),
+		History:              personalRecordResponses(history, c),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+// detectRecords compares a freshly logged set with the caller's current
+// records for the exercise and stores every record the set improves on.
+func detectRecords(ctx context.Context, q *storage.Queries, userID int32, exercise storage.Exercise, set storage.Set) ([]storage.PersonalRecord, error) {
+	if !exercise.DefinitionID.Valid {
+		return nil, nil
+	}
+	definitionID := exercise.DefinitionID.Int32
+
+	current, err := q.GetCurrentPersonalRecords(ctx, storage.GetCurrentPersonalRecordsParams{
+		UserID:       userID,
+		DefinitionID: definitionID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var created []storage.PersonalRecord
+	candidates := records.SetCandidates(float64Ptr(set.Weight), int32Ptr(set.Repetitions), float64Ptr(set.DistanceMeters), int32Ptr(set.DurationSeconds))
+	for _, c := range candidates {
+		prev := currentBest(current, c.Type, sameGroup(c.Type, set.Weight, set.DistanceMeters))
+		if prev != nil && !records.Beats(c.Type, c.Value, prev.Value) {
+			continue
+		}
+
+		record, err := q.CreatePersonalRecord(ctx, storage.CreatePersonalRecordParams{
+			UserID:          userID,
+			DefinitionID:    definitionID,
+			RecordType:      c.Type,
+			Value:           c.Value,
+			PreviousValue:   previousValue(prev),
+			Weight:          set.Weight,
+			Repetitions:     set.Repetitions,
+			DistanceMeters:  set.DistanceMeters,
+			DurationSeconds: set.DurationSeconds,
+			WorkoutID:       nullInt32(&exercise.WorkoutID),
+			SetID:           nullInt32(&set.ID),
+		})
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, record)
+	}
+
+	if !set.Weight.Valid || !set.Repetitions.Valid {
+		return created, nil
+	}
+
+	volume, err := q.GetWorkoutExerciseVolume(ctx, storage.GetWorkoutExerciseVolumeParams{
+		WorkoutID:    exercise.WorkoutID,
+		DefinitionID: exercise.DefinitionID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if volume <= 0 {
+		return created, nil
+	}
+
+	prev := currentBest(current, records.SessionVolume, sameGroup(records.SessionVolume, set.Weight, set.DistanceMeters))
+	switch {
+	case prev != nil && !records.Beats(records.SessionVolume, volume, prev.Value):
+	case prev != nil && prev.WorkoutID.Valid && prev.WorkoutID.Int32 == exercise.WorkoutID:
+		// The session already holds the record; keep one entry per session.
+		record, err := q.UpdatePersonalRecord(ctx, storage.UpdatePersonalRecordParams{
+			ID:          prev.ID,
+			Value:       volume,
+			Weight:      set.Weight,
+			Repetitions: set.Repetitions,
+			SetID:       nullInt32(&set.ID),
+		})
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, record)
+	default:
+		record, err := q.CreatePersonalRecord(ctx, storage.CreatePersonalRecordParams{
+			UserID:        userID,
+			DefinitionID:  definitionID,
+			RecordType:    records.SessionVolume,
+			Value:         volume,
+			PreviousValue: previousValue(prev),
+			Weight:        set.Weight,
+			Repetitions:   set.Repetitions,
+			WorkoutID:     nullInt32(&exercise.WorkoutID),
+			SetID:         nullInt32(&set.ID),
+		})
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, record)
+	}
+
+	return created, nil
+}
+
+// reconcileRecords brings the current records of a user for an exercise in
+// line with the sets that are left after a set holding a record was changed
+// or removed. Only the best set per weight and distance and the best session
+// are loaded, and each one that differs from the current record of its kind
+// is stored as the new record.
+func reconcileRecords(ctx context.Context, q *storage.Queries, userID, definitionID int32) ([]storage.PersonalRecord, error) {
+	current, err := q.GetCurrentPersonalRecords(ctx, storage.GetCurrentPersonalRecordsParams{
+		UserID:       userID,
+		DefinitionID: definitionID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sets, err := q.ListRecordCandidateSets(ctx, storage.ListRecordCandidateSetsParams{
+		UserID:       userID,
+		DefinitionID: nullInt32(&definitionID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]records.Set, len(sets))
+	for i, s := range sets {
+		candidates[i] = records.Set{
+			Weight:   float64Ptr(s.Weight),
+			Reps:     int32Ptr(s.Repetitions),
+			Distance: float64Ptr(s.DistanceMeters),
+			Duration: int32Ptr(s.DurationSeconds),
+		}
+	}
+
+	var created []storage.PersonalRecord
+	for _, best := range records.BestOf(candidates) {
+		set := sets[best.Set]
+		prev := currentBest(current, best.Type, sameGroup(best.Type, set.Weight, set.DistanceMeters))
+		if prev != nil && sameValue(prev.Value, best.Value) {
+			continue
+		}
+		record, err := q.CreatePersonalRecord(ctx, storage.CreatePersonalRecordParams{
+			UserID:          userID,
+			DefinitionID:    definitionID,
+			RecordType:      best.Type,
+			Value:           best.Value,
+			PreviousValue:   previousValue(prev),
+			Weight:          set.Weight,
+			Repetitions:     set.Repetitions,
+			DistanceMeters:  set.DistanceMeters,
+			DurationSeconds: set.DurationSeconds,
+			WorkoutID:       nullInt32(&set.WorkoutID),
+			SetID:           nullInt32(&set.ID),
+		})
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, record)
+	}
+
+	session, err := q.GetBestSessionVolume(ctx, storage.GetBestSessionVolumeParams{
+		UserID:       userID,
+		DefinitionID: nullInt32(&definitionID),
+	})
+	if err == sql.ErrNoRows {
+		return created, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	prev := currentBest(current, records.SessionVolume, sameGroup(records.SessionVolume, sql.NullFloat64{}, sql.NullFloat64{}))
+	if prev != nil && sameValue(prev.Value, session.Volume) {
+		return created, nil
+	}
+	record, err := q.CreatePersonalRecord(ctx, storage.CreatePersonalRecordParams{
+		UserID:        userID,
+		DefinitionID:  definitionID,
+		RecordType:    records.SessionVolume,
+		Value:         session.Volume,
+		PreviousValue: previousValue(prev),
+		WorkoutID:     nullInt32(&session.WorkoutID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(created, record), nil
+}
+
+// currentBest returns the best of the current records of a type that match.
+func currentBest(current []storage.PersonalRecord, recordType string, match func(storage.PersonalRecord) bool) *storage.PersonalRecord {
+	var best *storage.PersonalRecord
+	for i := range current {
+		pr := &current[i]
+		if pr.RecordType != recordType || !match(*pr) {
+			continue
+		}
+		if best == nil || records.Beats(recordType, pr.Value, best.Value) {
+			best = pr
+		}
+	}
+	return best
+}
+
+// bestRecords reduces the latest records per weight and distance to the
+// ones a user thinks of as current: one per exercise and type, except for
+// reps at a weight and times over a distance, which are kept per weight and
+// per distance.
+func bestRecords(latest []storage.PersonalRecord) []storage.PersonalRecord {
+	type key struct {
+		definitionID int32
+		recordType   string
+	}
+	best := make(map[key]int)
+	var res []storage.PersonalRecord
+	for _, pr := range latest {
+		if pr.RecordType == records.RepsAtWeight || pr.RecordType == records.FastestTime {
+			res = append(res, pr)
+			continue
+		}
+		k := key{pr.DefinitionID, pr.RecordType}
+		i, ok := best[k]
+		if !ok {
+			best[k] = len(res)
+			res = append(res, pr)
+			continue
+		}
+		if records.Beats(pr.RecordType, pr.Value, res[i].Value) {
+			res[i] = pr
+		}
+	}
+	return res
+}
+
+// sameGroup matches the current records that a record of a type set with
+// the given weight and distance is compared with: reps at the same weight,
+// times over the same distance and every record of the other types.
+func sameGroup(recordType string, weight, distance sql.NullFloat64) func(storage.PersonalRecord) bool {
+	return func(pr storage.PersonalRecord) bool {
+		switch recordType {
+		case records.RepsAtWeight:
+			return sameValue(pr.Weight.Float64, weight.Float64)
+		case records.FastestTime:
+			return sameValue(pr.DistanceMeters.Float64, distance.Float64)
+		}
+		return true
+	}
+}
+
+func previousValue(prev *storage.PersonalRecord) sql.NullFloat64 {
+	if prev == nil {
+		return sql.NullFloat64{}
+	}
+	return nullFloat64(&prev.Value)
+}
+
+func sameValue(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+// personalRecordResponses presents records in the units of c. Reps at a
+// weight are counted in repetitions and fastest times in seconds, so only the
+// other record values are converted.
+func personalRecordResponses(prs []storage.PersonalRecord, c units.Converter) []models.PersonalRecordResponse {
+	res := make([]models.PersonalRecordResponse, 0, len(prs))
+	for _, pr := range prs {
+		value := func(v float64) float64 { return v }
+		if pr.RecordType != records.RepsAtWeight && pr.RecordType != records.FastestTime {
+			value = c.Weight
+		}
+		res = append(res, models.PersonalRecordResponse{
+			ID:                   pr.ID,
+			ExerciseDefinitionID: pr.DefinitionID,
+			RecordType:           pr.RecordType,
+			Value:                value(pr.Value),
+			PreviousValue:        convert(float64Ptr(pr.PreviousValue), value),
+			Weight:               convert(float64Ptr(pr.Weight), c.Weight),
+			Repetitions:          int32Ptr(pr.Repetitions),
+			Distance:             convert(float64Ptr(pr.DistanceMeters), c.Distance),
+			DurationSeconds:      int32Ptr(pr.DurationSeconds),
+			WorkoutID:            int32Ptr(pr.WorkoutID),
+			SetID:                int32Ptr(pr.SetID),
+			Unit:                 c.Name(),
+			AchievedAt:           pr.AchievedAt,
+		})
+	}
+	return res
+}
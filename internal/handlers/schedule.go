@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/errors"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/middleware"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/problem"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/models"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/storage"
+)
+
+const (
+	scheduleCompleted = "completed"
+	scheduleMissed    = "missed"
+	scheduleUpcoming  = "upcoming"
+
+	maxScheduleDays = 366
+)
+
+// GetSchedule resolves the sessions of every program the caller is enrolled
+// in to concrete dates between from and to, and marks each of them as
+// completed, missed or upcoming by matching it with the workouts logged from
+// the same template on that date.
+func (u UserHandler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+
+	from, err := time.Parse(time.DateOnly, r.FormValue("from"))
+	if err != nil {
+		problem.Write(w, r, errors.Invalid("invalid from parameter, expected YYYY-MM-DD"))
+		return
+	}
+	to, err := time.Parse(time.DateOnly, r.FormValue("to"))
+	if err != nil {
+		problem.Write(w, r, errors.Invalid("invalid to parameter, expected YYYY-MM-DD"))
+		return
+	}
+	if to.Before(from) {
+		problem.Write(w, r, errors.Invalid("to must not be before from"))
+		return
+	}
+	if days(from, to) >= maxScheduleDays {
+		problem.Write(w, r, errors.Invalid("date range is too long"))
+		return
+	}
+	c, ok := u.unitConverter(w, r, userID, nil)
+	if !ok {
+		return
+	}
+	loc, ok := u.location(w, r, userID, nil)
+	if !ok {
+		return
+	}
+
+	rows, err := u.Storage.GetScheduledSessions(r.Context(), storage.GetScheduledSessionsParams{
+		UserID:   userID,
+		ToDate:   to,
+		FromDate: from,
+	})
+	if err != nil {
+		u.Logger.Error("failed to get scheduled sessions", "error", err)
+		problem.Write(w, r, errors.Internal("failed to get schedule"))
+		return
+	}
+
+	workouts, err := u.Storage.GetWorkoutsByUserIDBetween(r.Context(), storage.GetWorkoutsByUserIDBetweenParams{
+		UserID:   userID,
+		FromDate: from,
+		ToDate:   to,
+	})
+	if err != nil {
+		u.Logger.Error("failed to get workouts", "error", err)
+		problem.Write(w, r, errors.Internal("failed to get schedule"))
+		return
+	}
+
+	res := models.ScheduleResponse{
+		From:     from.Format(time.DateOnly),
+		To:       to.Format(time.DateOnly),
+		Unit:     c.Name(),
+		Sessions: []models.ScheduledSession{},
+		Workouts: make([]models.WorkoutCreateResponse, 0, len(workouts)),
+	}
+
+	type logKey struct {
+		date       string
+		templateID int32
+	}
+	logged := make(map[logKey][]int32)
+	for _, wo := range workouts {
+		res.Workouts = append(res.Workouts, workoutResponse(wo))
+		if wo.TemplateID.Valid {
+			k := logKey{wo.Date.Format(time.DateOnly), wo.TemplateID.Int32}
+			logged[k] = append(logged[k], wo.ID)
+		}
+	}
+
+	templateExercises := make(map[int32][]storage.TemplateExercise)
+	today := today(loc).Format(time.DateOnly)
+
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		for _, row := range sessionsOn(rows, d) {
+			offset := days(row.StartDate, d)
+			week := int32(offset/7) + 1
+
+			exercises, ok := templateExercises[row.TemplateID]
+			if !ok {
+				exercises, err = u.Storage.GetTemplateExercises(r.Context(), row.TemplateID)
+				if err != nil {
+					u.Logger.Error("failed to get template exercises", "error", err)
+					problem.Write(w, r, errors.Internal("failed to get schedule"))
+					return
+				}
+				templateExercises[row.TemplateID] = exercises
+			}
+
+			weightOffset, weightScale, deload := progression(week, row.WeeklyWeightIncrement, row.DeloadEveryWeeks.Int32, row.DeloadFactor)
+
+			session := models.ScheduledSession{
+				Date:         d.Format(time.DateOnly),
+				EnrollmentID: row.EnrollmentID,
+				ProgramID:    row.ProgramID,
+				ProgramName:  row.ProgramName,
+				Week:         week,
+				Day:          row.Day,
+				Deload:       deload,
+				TemplateID:   row.TemplateID,
+				TemplateName: row.TemplateName,
+				Exercises:    make([]models.ScheduledExercise, 0, len(exercises)),
+			}
+			for _, e := range exercises {
+				session.Exercises = append(session.Exercises, models.ScheduledExercise{
+					ExerciseDefinitionID: e.DefinitionID,
+					TargetSets:           e.TargetSets,
+					TargetRepsMin:        int32Ptr(e.TargetRepsMin),
+					TargetRepsMax:        int32Ptr(e.TargetRepsMax),
+					TargetWeightMin:      convert(progressedWeight(e.TargetWeightMin, weightOffset, weightScale), c.Load),
+					TargetWeightMax:      convert(progressedWeight(e.TargetWeightMax, weightOffset, weightScale), c.Load),
+				})
+			}
+
+			k := logKey{session.Date, row.TemplateID}
+			switch {
+			case len(logged[k]) > 0:
+				workoutID := logged[k][0]
+				logged[k] = logged[k][1:]
+				session.Status = scheduleCompleted
+				session.WorkoutID = &workoutID
+			case session.Date < today:
+				session.Status = scheduleMissed
+			default:
+				session.Status = scheduleUpcoming
+			}
+
+			res.Sessions = append(res.Sessions, session)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+// sessionsOn returns the program sessions that fall on date. A session tied
+// to a specific week replaces the weekly sessions of its enrollment on the
+// same day.
+func sessionsOn(rows []storage.GetScheduledSessionsRow, date time.Time) []storage.GetScheduledSessionsRow {
+	var weekly, specific []storage.GetScheduledSessionsRow
+	overridden := make(map[int32]bool)
+
+	for _, row := range rows {
+		offset := days(row.StartDate, date)
+		if offset < 0 || offset >= int(row.Weeks)*7 {
+			continue
+		}
+		week, day := int32(offset/7)+1, int32(offset%7)+1
+		if row.Day != day {
+			continue
+		}
+		switch {
+		case !row.Week.Valid:
+			weekly = append(weekly, row)
+		case row.Week.Int32 == week:
+			specific = append(specific, row)
+			overridden[row.EnrollmentID] = true
+		}
+	}
+
+	sessions := specific
+	for _, row := range weekly {
+		if !overridden[row.EnrollmentID] {
+			sessions = append(sessions, row)
+		}
+	}
+	return sessions
+}
+
+// progression returns how template weights change in the given 1-based week
+// of a program: target = (weight + offset) * scale. Every non-deload week adds
+// one increment; deload weeks keep the progress made so far but scale it down.
+func progression(week int32, increment float64, deloadEvery int32, deloadFactor float64) (offset, scale float64, deload bool) {
+	progressed := week - 1
+	if deloadEvery > 1 {
+		progressed -= week / deloadEvery
+		deload = week%deloadEvery == 0
+	}
+
+	offset, scale = increment*float64(progressed), 1
+	if deload {
+		scale = deloadFactor
+	}
+	return offset, scale, deload
+}
+
+func progressedWeight(weight sql.NullFloat64, offset, scale float64) *float64 {
+	if !weight.Valid {
+		return nil
+	}
+	v := (weight.Float64 + offset) * scale
+	return &v
+}
+
+// days returns the number of whole days from a to b.
+func days(a, b time.Time) int {
+	return int(math.Round(b.Sub(a).Hours() / 24))
+}
@@ -0,0 +1,410 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/errors"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/middleware"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/problem"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/units"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/models"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/storage"
+)
+
+func (u UserHandler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+
+	var req models.TemplateRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if !u.validTemplate(w, r, userID, &req) {
+		return
+	}
+	c, ok := u.unitConverter(w, r, userID, req.Unit)
+	if !ok {
+		return
+	}
+
+	var template storage.WorkoutTemplate
+	var exercises []storage.TemplateExercise
+	err := u.withTx(r.Context(), func(q *storage.Queries) error {
+		var err error
+		template, err = q.CreateWorkoutTemplate(r.Context(), storage.CreateWorkoutTemplateParams{
+			UserID:      userID,
+			Name:        req.Name,
+			Description: nullString(req.Description),
+		})
+		if err != nil {
+			return err
+		}
+		exercises, err = createTemplateExercises(r.Context(), q, template.ID, req.Exercises, c)
+		return err
+	})
+	if err != nil {
+		u.Logger.Error("failed to create template", "error", err)
+		problem.Write(w, r, errors.Internal("failed to create template"))
+		return
+	}
+
+	res := templateResponse(template, exercises, c)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&res)
+}
+
+func (u UserHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+	c, ok := u.unitConverter(w, r, userID, nil)
+	if !ok {
+		return
+	}
+
+	templates, err := u.Storage.ListWorkoutTemplates(r.Context(), userID)
+	if err != nil {
+		u.Logger.Error("failed to list templates", "error", err)
+		problem.Write(w, r, errors.Internal("failed to get templates"))
+		return
+	}
+
+	res := make([]models.TemplateResponse, 0, len(templates))
+	for _, t := range templates {
+		res = append(res, templateResponse(t, nil, c))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+func (u UserHandler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+	c, ok := u.unitConverter(w, r, userID, nil)
+	if !ok {
+		return
+	}
+
+	template, err := u.Storage.GetWorkoutTemplate(r.Context(), storage.GetWorkoutTemplateParams{ID: id, UserID: userID})
+	if err == sql.ErrNoRows {
+		problem.Write(w, r, errors.NotFound("template not found"))
+		return
+	}
+	if err != nil {
+		u.Logger.Error("failed to get template", "error", err)
+		problem.Write(w, r, errors.Internal("failed to get template"))
+		return
+	}
+
+	exercises, err := u.Storage.GetTemplateExercises(r.Context(), template.ID)
+	if err != nil {
+		u.Logger.Error("failed to get template exercises", "error", err)
+		problem.Write(w, r, errors.Internal("failed to get template"))
+		return
+	}
+
+	res := templateResponse(template, exercises, c)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+// UpdateTemplate replaces the name, description and the whole exercise list
+// of a template.
+func (u UserHandler) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+
+	var req models.TemplateRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if !u.validTemplate(w, r, userID, &req) {
+		return
+	}
+	c, ok := u.unitConverter(w, r, userID, req.Unit)
+	if !ok {
+		return
+	}
+
+	var template storage.WorkoutTemplate
+	var exercises []storage.TemplateExercise
+	err := u.withTx(r.Context(), func(q *storage.Queries) error {
+		n, err := q.UpdateWorkoutTemplate(r.Context(), storage.UpdateWorkoutTemplateParams{
+			ID:          id,
+			UserID:      userID,
+			Name:        req.Name,
+			Description: nullString(req.Description),
+		})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return sql.ErrNoRows
+		}
+		if err := q.DeleteTemplateExercises(r.Context(), id); err != nil {
+			return err
+		}
+		exercises, err = createTemplateExercises(r.Context(), q, id, req.Exercises, c)
+		if err != nil {
+			return err
+		}
+		template, err = q.GetWorkoutTemplate(r.Context(), storage.GetWorkoutTemplateParams{ID: id, UserID: userID})
+		return err
+	})
+	if err == sql.ErrNoRows {
+		problem.Write(w, r, errors.NotFound("template not found"))
+		return
+	}
+	if err != nil {
+		u.Logger.Error("failed to update template", "error", err)
+		problem.Write(w, r, errors.Internal("failed to update template"))
+		return
+	}
+
+	res := templateResponse(template, exercises, c)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+func (u UserHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+
+	n, err := u.Storage.DeleteWorkoutTemplate(r.Context(), storage.DeleteWorkoutTemplateParams{ID: id, UserID: userID})
+	if err != nil {
+		u.Logger.Error("failed to delete template", "error", err)
+		problem.Write(w, r, errors.Internal("failed to delete template"))
+		return
+	}
+	if n == 0 {
+		problem.Write(w, r, errors.NotFound("template not found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateWorkoutFromTemplate starts a dated workout with the template's
+// exercises and one planned set per target set, carrying the targets so the
+// actual numbers can be filled in while training.
+func (u UserHandler) CreateWorkoutFromTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+
+	var req models.WorkoutFromTemplateRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	loc, ok := u.location(w, r, userID, req.Timezone)
+	if !ok {
+		return
+	}
+	startedAt := req.StartedAt
+	if startedAt == nil && req.Date != "" {
+		date, err := time.ParseInLocation(time.DateOnly, req.Date, loc)
+		if err != nil {
+			problem.Write(w, r, errors.Invalid("invalid date, expected YYYY-MM-DD"))
+			return
+		}
+		// Starting today's workout means starting it now.
+		if req.Date != today(loc).Format(time.DateOnly) {
+			startedAt = &date
+		}
+	}
+	timezone := loc.String()
+	times, ok := u.resolveWorkoutTimes(w, r, userID, startedAt, nil, &timezone)
+	if !ok {
+		return
+	}
+	c, ok := u.unitConverter(w, r, userID, nil)
+	if !ok {
+		return
+	}
+
+	template, err := u.Storage.GetWorkoutTemplate(r.Context(), storage.GetWorkoutTemplateParams{ID: templateID, UserID: userID})
+	if err == sql.ErrNoRows {
+		problem.Write(w, r, errors.NotFound("template not found"))
+		return
+	}
+	if err != nil {
+		u.Logger.Error("failed to get template", "error", err)
+		problem.Write(w, r, errors.Internal("failed to create workout"))
+		return
+	}
+
+	name := template.Name
+	if req.Name != nil {
+		name = *req.Name
+	}
+	description := template.Description
+	if req.Description != nil {
+		description = nullString(req.Description)
+	}
+
+	var res models.WorkoutDetailResponse
+	err = u.withTx(r.Context(), func(q *storage.Queries) error {
+		workout, err := q.CreateWorkout(r.Context(), storage.CreateWorkoutParams{
+			UserID:      userID,
+			Name:        name,
+			Description: description,
+			Date:        times.date,
+			TemplateID:  sql.NullInt32{Int32: template.ID, Valid: true},
+			StartedAt:   times.startedAt,
+			Timezone:    times.timezone,
+		})
+		if err != nil {
+			return err
+		}
+		err = q.CreateExercisesFromTemplate(r.Context(), storage.CreateExercisesFromTemplateParams{
+			WorkoutID:  workout.ID,
+			TemplateID: template.ID,
+		})
+		if err != nil {
+			return err
+		}
+		err = q.CreatePlannedSetsFromTemplate(r.Context(), storage.CreatePlannedSetsFromTemplateParams{
+			WorkoutID:  workout.ID,
+			TemplateID: template.ID,
+		})
+		if err != nil {
+			return err
+		}
+		res, err = workoutDetail(r.Context(), q, workout, c)
+		return err
+	})
+	if err != nil {
+		u.Logger.Error("failed to create workout from template", "error", err)
+		problem.Write(w, r, errors.Internal("failed to create workout"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&res)
+}
+
+// validTemplate checks a template request and writes a 400 response when it
+// is not valid.
+func (u UserHandler) validTemplate(w http.ResponseWriter, r *http.Request, userID int32, req *models.TemplateRequest) bool {
+	req.Name = strings.TrimSpace(req.Name)
+
+	for _, e := range req.Exercises {
+		if e.TargetRepsMin != nil && e.TargetRepsMax != nil && *e.TargetRepsMin > *e.TargetRepsMax {
+			problem.Write(w, r, errors.Invalid("target_reps_min must not exceed target_reps_max"))
+			return false
+		}
+		if e.TargetWeightMin != nil && e.TargetWeightMax != nil && *e.TargetWeightMin > *e.TargetWeightMax {
+			problem.Write(w, r, errors.Invalid("target_weight_min must not exceed target_weight_max"))
+			return false
+		}
+
+		_, err := u.Storage.GetExerciseDefinition(r.Context(), storage.GetExerciseDefinitionParams{
+			ID:     e.ExerciseDefinitionID,
+			UserID: sql.NullInt32{Int32: userID, Valid: true},
+		})
+		if err == sql.ErrNoRows {
+			problem.Write(w, r, errors.Invalid("exercise definition not found"))
+			return false
+		}
+		if err != nil {
+			u.Logger.Error("failed to get exercise definition", "error", err)
+			problem.Write(w, r, errors.Internal("failed to save template"))
+			return false
+		}
+	}
+	return true
+}
+
+// createTemplateExercises stores the exercises of a template, converting the
+// target weights from the units of c.
+func createTemplateExercises(ctx context.Context, q *storage.Queries, templateID int32, reqs []models.TemplateExerciseRequest, c units.Converter) ([]storage.TemplateExercise, error) {
+	exercises := make([]storage.TemplateExercise, 0, len(reqs))
+	for i, e := range reqs {
+		exercise, err := q.CreateTemplateExercise(ctx, storage.CreateTemplateExerciseParams{
+			TemplateID:      templateID,
+			DefinitionID:    e.ExerciseDefinitionID,
+			Position:        int32(i + 1),
+			Notes:           nullString(e.Notes),
+			TargetSets:      e.TargetSets,
+			TargetRepsMin:   nullInt32(e.TargetRepsMin),
+			TargetRepsMax:   nullInt32(e.TargetRepsMax),
+			TargetWeightMin: nullFloat64(convert(e.TargetWeightMin, c.ToKilograms)),
+			TargetWeightMax: nullFloat64(convert(e.TargetWeightMax, c.ToKilograms)),
+			RestSeconds:     nullInt32(e.RestSeconds),
+		})
+		if err != nil {
+			return nil, err
+		}
+		exercises = append(exercises, exercise)
+	}
+	return exercises, nil
+}
+
+func templateResponse(template storage.WorkoutTemplate, exercises []storage.TemplateExercise, c units.Converter) models.TemplateResponse {
+	res := models.TemplateResponse{
+		ID:          template.ID,
+		Name:        template.Name,
+		Description: stringPtr(template.Description),
+		Unit:        c.Name(),
+		CreateAt:    template.CreateAt,
+		UpdateAt:    template.UpdateAt,
+	}
+	for _, e := range exercises {
+		res.Exercises = append(res.Exercises, models.TemplateExerciseResponse{
+			ID:                   e.ID,
+			ExerciseDefinitionID: e.DefinitionID,
+			Position:             e.Position,
+			Notes:                stringPtr(e.Notes),
+			TargetSets:           e.TargetSets,
+			TargetRepsMin:        int32Ptr(e.TargetRepsMin),
+			TargetRepsMax:        int32Ptr(e.TargetRepsMax),
+			TargetWeightMin:      convert(float64Ptr(e.TargetWeightMin), c.Load),
+			TargetWeightMax:      convert(float64Ptr(e.TargetWeightMax), c.Load),
+			RestSeconds:          int32Ptr(e.RestSeconds),
+		})
+	}
+	return res
+}
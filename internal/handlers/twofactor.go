@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/errors"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/audit"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/hash"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/jwt"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/middleware"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/problem"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/token"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/totp"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/models"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/storage"
+)
+
+// recoveryCodeCount is how many recovery codes are issued when two-factor
+// authentication is enabled.
+const recoveryCodeCount = 10
+
+// EnrollTOTP starts enabling two-factor authentication by generating a new
+// secret. It only takes effect once ConfirmTOTP receives a code for it, so
+// enrolling again before that replaces the secret.
+func (u UserHandler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	user, ok := u.currentUser(w, r)
+	if !ok {
+		return
+	}
+	if user.TotpEnabledAt.Valid {
+		problem.Write(w, r, errors.Conflict("two-factor authentication is already enabled"))
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		u.Logger.Error("failed to generate totp secret", "error", err)
+		problem.Write(w, r, errors.Internal("failed to enroll"))
+		return
+	}
+	n, err := u.Storage.SetTOTPSecret(r.Context(), storage.SetTOTPSecretParams{
+		ID:         user.ID,
+		TotpSecret: sql.NullString{String: secret, Valid: true},
+	})
+	if err != nil {
+		u.Logger.Error("failed to save totp secret", "error", err)
+		problem.Write(w, r, errors.Internal("failed to enroll"))
+		return
+	}
+	if n == 0 {
+		problem.Write(w, r, errors.Conflict("two-factor authentication is already enabled"))
+		return
+	}
+
+	res := models.TOTPEnrollResponse{
+		Secret: secret,
+		URI:    totp.URI(u.Auth.TOTPIssuer, user.Email, secret),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+// ConfirmTOTP enables two-factor authentication once the user proves their
+// authenticator produces codes for the enrolled secret, and returns the
+// recovery codes. They are only shown this once.
+func (u UserHandler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	var req models.TOTPConfirmRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	user, ok := u.currentUser(w, r)
+	if !ok {
+		return
+	}
+	if user.TotpEnabledAt.Valid {
+		problem.Write(w, r, errors.Conflict("two-factor authentication is already enabled"))
+		return
+	}
+	if !user.TotpSecret.Valid {
+		problem.Write(w, r, errors.Conflict("two-factor authentication is not enrolled"))
+		return
+	}
+	step, ok := totp.Validate(user.TotpSecret.String, req.Code, time.Now())
+	if !ok {
+		problem.Write(w, r, errors.Field("code", "is invalid"))
+		return
+	}
+
+	codes, err := totp.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		u.Logger.Error("failed to generate recovery codes", "error", err)
+		problem.Write(w, r, errors.Internal("failed to enable two-factor authentication"))
+		return
+	}
+
+	err = u.withTx(r.Context(), func(q *storage.Queries) error {
+		n, err := q.EnableTOTP(r.Context(), storage.EnableTOTPParams{
+			ID:           user.ID,
+			TotpLastStep: sql.NullInt64{Int64: step, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return errors.Conflict("two-factor authentication was changed concurrently")
+		}
+		if err := replaceRecoveryCodes(r.Context(), q, user.ID, codes); err != nil {
+			return err
+		}
+		return audit.Record(r, q, audit.Event{
+			ActorID:    user.ID,
+			Action:     audit.ActionTOTPEnable,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID,
+		})
+	})
+	if err != nil {
+		e := errors.As(err)
+		if e.Kind == errors.ErrInternal {
+			u.Logger.Error("failed to enable totp", "error", err)
+			e = errors.Internal("failed to enable two-factor authentication")
+		}
+		problem.Write(w, r, e)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTOTP turns two-factor authentication off after checking the
+// password and a second factor again.
+func (u UserHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	var req models.TOTPDisableRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	user, ok := u.currentUser(w, r)
+	if !ok {
+		return
+	}
+	if !hash.VerifyPassword(req.Password, user.PasswordHash) {
+		problem.Write(w, r, errors.Unauthorized("invalid password"))
+		return
+	}
+
+	err := u.withTx(r.Context(), func(q *storage.Queries) error {
+		if user.TotpEnabledAt.Valid {
+			if err := checkSecondFactor(r.Context(), q, user, req.Code, req.RecoveryCode); err != nil {
+				return err
+			}
+		}
+		if err := q.DisableTOTP(r.Context(), user.ID); err != nil {
+			return err
+		}
+		if err := q.DeleteRecoveryCodes(r.Context(), user.ID); err != nil {
+			return err
+		}
+		return audit.Record(r, q, audit.Event{
+			ActorID:    user.ID,
+			Action:     audit.ActionTOTPDisable,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID,
+		})
+	})
+	if err != nil {
+		e := errors.As(err)
+		if e.Kind == errors.ErrInternal {
+			u.Logger.Error("failed to disable totp", "error", err)
+			e = errors.Internal("failed to disable two-factor authentication")
+		}
+		problem.Write(w, r, e)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LoginMFA is the second login step for accounts with two-factor
+// authentication: it exchanges the token Login returned for a token pair
+// when given a valid TOTP code or recovery code.
+func (u UserHandler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	var req models.MFALoginRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	claims, err := u.Tokens.ParseType(req.MFAToken, jwt.TypeMFA)
+	if err != nil {
+		problem.Write(w, r, errors.Unauthorized("invalid or expired mfa token"))
+		return
+	}
+	user, err := u.Storage.GetUser(r.Context(), claims.UserID)
+	if err != nil && err != sql.ErrNoRows {
+		u.Logger.Error("failed to get user", "error", err)
+		problem.Write(w, r, errors.Internal("failed to login"))
+		return
+	}
+	if err == sql.ErrNoRows || !user.TotpEnabledAt.Valid {
+		problem.Write(w, r, errors.Unauthorized("invalid or expired mfa token"))
+		return
+	}
+	if user.SuspendedAt.Valid {
+		u.recordLoginFailure(r, user.ID, "suspended")
+		problem.Write(w, r, errors.Forbidden("account is suspended"))
+		return
+	}
+	account := normalizeEmail(user.Email)
+	attempt, ok := u.beginLogin(w, r, account)
+	if !ok {
+		return
+	}
+
+	if err := checkSecondFactor(r.Context(), &u.Storage, user, req.Code, req.RecoveryCode); err != nil {
+		e := errors.As(err)
+		if e.Kind == errors.ErrUnauthorized {
+			u.loginFailed(r, attempt, user, "invalid_second_factor")
+		} else {
+			u.releaseLogin(r, account, attempt)
+		}
+		if e.Kind == errors.ErrInternal {
+			u.Logger.Error("failed to check second factor", "error", err)
+			e = errors.Internal("failed to login")
+		}
+		problem.Write(w, r, e)
+		return
+	}
+
+	u.loginSucceeded(r, account)
+	u.login(w, r, user.ID)
+}
+
+// checkSecondFactor accepts a TOTP code that was not used before, or uses
+// up a recovery code.
+func checkSecondFactor(ctx context.Context, q *storage.Queries, user storage.User, code, recoveryCode string) error {
+	var n int64
+	var err error
+	switch {
+	case code != "":
+		step, ok := totp.Validate(user.TotpSecret.String, code, time.Now())
+		if !ok {
+			return errors.Unauthorized("invalid two-factor code")
+		}
+		n, err = q.UseTOTPStep(ctx, storage.UseTOTPStepParams{
+			ID:           user.ID,
+			TotpLastStep: sql.NullInt64{Int64: step, Valid: true},
+		})
+	case recoveryCode != "":
+		n, err = q.UseRecoveryCode(ctx, storage.UseRecoveryCodeParams{
+			UserID:   user.ID,
+			CodeHash: token.Hash(totp.NormalizeRecoveryCode(recoveryCode)),
+		})
+	default:
+		return errors.Invalid("code or recovery_code is required",
+			errors.FieldError{Field: "code", Message: "is required"})
+	}
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errors.Unauthorized("invalid two-factor code")
+	}
+	return nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, q *storage.Queries, userID int32, codes []string) error {
+	if err := q.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return err
+	}
+	for _, code := range codes {
+		err := q.CreateRecoveryCode(ctx, storage.CreateRecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: token.Hash(totp.NormalizeRecoveryCode(code)),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// currentUser loads the authenticated user, rejecting suspended accounts.
+func (u UserHandler) currentUser(w http.ResponseWriter, r *http.Request) (storage.User, bool) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return storage.User{}, false
+	}
+	user, err := u.Storage.GetUser(r.Context(), userID)
+	if err == sql.ErrNoRows {
+		problem.Write(w, r, errors.Unauthorized("unknown user"))
+		return storage.User{}, false
+	}
+	if err != nil {
+		u.Logger.Error("failed to get user", "error", err)
+		problem.Write(w, r, errors.Internal("failed to get user"))
+		return storage.User{}, false
+	}
+	if user.SuspendedAt.Valid {
+		problem.Write(w, r, errors.Forbidden("account is suspended"))
+		return storage.User{}, false
+	}
+	return user, true
+}
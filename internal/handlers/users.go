@@ -1,65 +1,149 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"log/slog"
 	"net/http"
-	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Oyatillohgayratov/fitness-tracking-app/errors"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/audit"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/bruteforce"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/clientip"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/config"
 	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/email"
 	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/hash"
 	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/jwt"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/middleware"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/problem"
+	userprofile "github.com/Oyatillohgayratov/fitness-tracking-app/internal/profile"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/token"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/validate"
 	"github.com/Oyatillohgayratov/fitness-tracking-app/models"
 	"github.com/Oyatillohgayratov/fitness-tracking-app/storage"
+	"github.com/sqlc-dev/pqtype"
 )
 
 type UserHandler struct {
 	Logger  *slog.Logger
 	Storage storage.Queries
+	DB      *sql.DB
+	Auth    config.Auth
+	Mail    *email.Queue
+	Tokens  *jwt.Manager
+	Limiter *bruteforce.Limiter
 }
 
-func NewHandler(logger *slog.Logger, storage *storage.Queries) UserHandler {
+func NewHandler(logger *slog.Logger, db *sql.DB, storage *storage.Queries, auth config.Auth, mail *email.Queue, tokens *jwt.Manager, limiter *bruteforce.Limiter) UserHandler {
 	return UserHandler{
 		Logger:  logger,
 		Storage: *storage,
+		DB:      db,
+		Auth:    auth.WithDefaults(),
+		Mail:    mail,
+		Tokens:  tokens,
+		Limiter: limiter,
 	}
 }
 
+// withTx runs fn with queries bound to a single transaction, committing it
+// when fn succeeds and rolling it back otherwise.
+func (u UserHandler) withTx(ctx context.Context, fn func(q *storage.Queries) error) error {
+	tx, err := u.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(u.Storage.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 func (u UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var user models.UserRegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		u.Logger.Error("failed to decode user registration",
-			slog.Any("error", err))
-		http.Error(w, errors.ErrDecodeUserRegister.Error(), http.StatusBadRequest)
+	if !decode(w, r, &user) {
 		return
 	}
 
 	password, err := hash.GenerateFromPassword(user.Password)
 	if err != nil {
 		u.Logger.Error("failed to hash password", "error", err)
-		http.Error(w, "Failed to hash password", http.StatusInternalServerError)
+		problem.Write(w, r, errors.Internal("Failed to hash password"))
+		return
+	}
+
+	profile := models.Profile{Version: userprofile.CurrentVersion}
+	rawProfile, err := json.Marshal(profile)
+	if err != nil {
+		u.Logger.Error("failed to encode profile", "error", err)
+		problem.Write(w, r, errors.Internal("Failed to create user"))
 		return
 	}
 
 	userModel := storage.CreateUserParams{
-		Username:     user.Username,
+		Username:     strings.TrimSpace(user.Username),
 		PasswordHash: password,
-		Email:        user.Email,
+		Email:        normalizeEmail(user.Email),
+		Profile:      pqtype.NullRawMessage{RawMessage: rawProfile, Valid: true},
 	}
 
-	resuser, err := u.Storage.CreateUser(r.Context(), userModel)
+	var resuser storage.User
+	var link string
+	err = u.withTx(r.Context(), func(q *storage.Queries) error {
+		var err error
+		resuser, err = q.CreateUser(r.Context(), userModel)
+		if err != nil {
+			return err
+		}
+		link, err = u.createVerification(r.Context(), q, resuser)
+		return err
+	})
+	if conflict := userConflict(err); conflict != nil {
+		problem.Write(w, r, conflict)
+		return
+	}
 	if err != nil {
 		u.Logger.Error("failed to create user", "error", err)
-		http.Error(w, "Failed to create user", http.StatusInternalServerError)
+		problem.Write(w, r, errors.Internal("Failed to create user"))
 		return
 	}
+	u.sendVerification(resuser, link, email.MatchLocale(r.Header.Get("Accept-Language")))
 
 	res := models.UserRegisterResponse{
-		ID:       int(resuser.ID),
-		Username: resuser.Username,
-		Email:    resuser.Email,
+		ID:            int(resuser.ID),
+		Username:      resuser.Username,
+		Email:         resuser.Email,
+		EmailVerified: false,
+		Profile:       &profile,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+// CheckUsername reports whether a username can still be registered.
+func (u UserHandler) CheckUsername(w http.ResponseWriter, r *http.Request) {
+	req := models.UsernameAvailabilityRequest{Username: strings.TrimSpace(r.FormValue("username"))}
+	if fields := validate.Struct(&req); len(fields) > 0 {
+		problem.Write(w, r, errors.Invalid("request validation failed", fields...))
+		return
+	}
+
+	taken, err := u.Storage.UsernameTaken(r.Context(), storage.UsernameTakenParams{Lower: req.Username})
+	if err != nil {
+		u.Logger.Error("failed to check username", "error", err)
+		problem.Write(w, r, errors.Internal("failed to check username"))
+		return
+	}
+
+	res := models.UsernameAvailabilityResponse{
+		Username:  req.Username,
+		Available: !taken,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -67,71 +151,199 @@ func (u UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 }
 
 func (u UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
-	idStr := r.FormValue("id")
-	if idStr == "" {
-		http.Error(w, "missing id parameter", http.StatusBadRequest)
+	id, ok := callerID(w, r, "id")
+	if !ok {
 		return
 	}
-	id, err := strconv.Atoi(idStr)
+
+	user, err := u.Storage.GetUser(r.Context(), id)
 	if err != nil {
-		http.Error(w, "invalid id parameter", http.StatusBadRequest)
+		e := errors.FromDB(err, "user")
+		if e.Kind == errors.ErrInternal {
+			u.Logger.Error("failed to get user", "error", err)
+		}
+		problem.Write(w, r, e)
 		return
 	}
 
-	user, err := u.Storage.GetUser(r.Context(), int32(id))
+	profile, err := userprofile.Decode(user.Profile.RawMessage)
 	if err != nil {
-		u.Logger.Error("failed to get user", "error", err)
-		http.Error(w, "failed to get user", http.StatusInternalServerError)
+		u.Logger.Error("failed to decode profile", "error", err)
+		problem.Write(w, r, errors.Internal("failed to get user"))
 		return
 	}
 
+	res := models.UserResponse{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		Role:          user.Role,
+		EmailVerified: user.EmailVerifiedAt.Valid,
+		TwoFactor:     user.TotpEnabledAt.Valid,
+		Profile:       profile,
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(user)
+	json.NewEncoder(w).Encode(&res)
 }
 
 func (u UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	var updateUserReq models.UpdateUserRequest
-	if err := json.NewDecoder(r.Body).Decode(&updateUserReq); err != nil {
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+	if !decode(w, r, &updateUserReq) {
 		return
 	}
 
-	err := u.Storage.UpdateUser(r.Context(), storage.UpdateUserParams{
-		ID:       int32(updateUserReq.ID),
-		Username: updateUserReq.Username,
-		Email:    updateUserReq.Email,
+	id, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+	if updateUserReq.ID != 0 && int32(updateUserReq.ID) != id {
+		problem.Write(w, r, errors.Forbidden("forbidden"))
+		return
+	}
+
+	user, err := u.Storage.GetUser(r.Context(), id)
+	if err != nil {
+		u.Logger.Error("failed to get user", "error", err)
+		problem.Write(w, r, errors.Internal("failed to update user"))
+		return
+	}
+	before := userSnapshot{Username: user.Username, Email: user.Email, Profile: user.Profile.RawMessage}
+	if username := strings.TrimSpace(updateUserReq.Username); username != "" {
+		user.Username = username
+	}
+	// A verified address stays in use until the new one is confirmed
+	// through the link sent to it, so the account never falls back to
+	// unverified. An unverified address is simply replaced.
+	var newEmail string
+	if email := normalizeEmail(updateUserReq.Email); email != "" && email != user.Email {
+		taken, err := u.Storage.EmailTaken(r.Context(), storage.EmailTakenParams{Lower: email, ExceptID: id})
+		if err != nil {
+			u.Logger.Error("failed to check email", "error", err)
+			problem.Write(w, r, errors.Internal("failed to update user"))
+			return
+		}
+		if taken {
+			problem.Write(w, r, identityConflict("email"))
+			return
+		}
+		newEmail = email
+		if !user.EmailVerifiedAt.Valid {
+			user.Email = email
+		}
+	}
+
+	profile, err := userprofile.Decode(user.Profile.RawMessage)
+	if err != nil {
+		u.Logger.Error("failed to decode profile", "error", err)
+		problem.Write(w, r, errors.Internal("failed to update user"))
+		return
+	}
+	profile, err = userprofile.Merge(profile, updateUserReq.Profile)
+	if err != nil {
+		problem.Write(w, r, errors.Invalid(err.Error()))
+		return
+	}
+	if err := userprofile.Validate(profile); err != nil {
+		problem.Write(w, r, errors.Invalid("invalid profile: "+err.Error()))
+		return
+	}
+	rawProfile, err := json.Marshal(profile)
+	if err != nil {
+		u.Logger.Error("failed to encode profile", "error", err)
+		problem.Write(w, r, errors.Internal("failed to update user"))
+		return
+	}
+
+	var link string
+	err = u.withTx(r.Context(), func(q *storage.Queries) error {
+		err := q.UpdateUser(r.Context(), storage.UpdateUserParams{
+			ID:       id,
+			Username: user.Username,
+			Email:    user.Email,
+			Profile:  pqtype.NullRawMessage{RawMessage: rawProfile, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		changes, err := audit.Changes(before, userSnapshot{Username: user.Username, Email: user.Email, Profile: rawProfile})
+		if err != nil {
+			return err
+		}
+		err = audit.Record(r, q, audit.Event{
+			ActorID:    id,
+			Action:     audit.ActionUserUpdate,
+			TargetType: audit.TargetUser,
+			TargetID:   id,
+			Details:    changes,
+		})
+		if err != nil || newEmail == "" {
+			return err
+		}
+		pending := user
+		pending.Email = newEmail
+		link, err = u.createVerification(r.Context(), q, pending)
+		return err
 	})
+	if conflict := userConflict(err); conflict != nil {
+		problem.Write(w, r, conflict)
+		return
+	}
 	if err != nil {
-		http.Error(w, "failed to update user", http.StatusInternalServerError)
+		u.Logger.Error("failed to update user", "error", err)
+		problem.Write(w, r, errors.Internal("failed to update user"))
 		return
 	}
+	if newEmail != "" {
+		pending := user
+		pending.Email = newEmail
+		u.sendVerification(pending, link, email.MatchLocale(r.Header.Get("Accept-Language")))
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	_, err = w.Write([]byte(`{"message": "success update user"}`))
 	if err != nil {
 		u.Logger.Error("failed to write response", "error", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		problem.Write(w, r, errors.Internal("Internal Server Error"))
 		return
 	}
 }
 
 func (u UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Query().Get("id")
-	if idStr == "" {
-		http.Error(w, "missing id parameter", http.StatusBadRequest)
-		return
-	}
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		http.Error(w, "invalid id parameter", http.StatusBadRequest)
+	id, ok := callerID(w, r, "id")
+	if !ok {
 		return
 	}
 
-	err = u.Storage.DeleteUser(r.Context(), int32(id))
+	err := u.withTx(r.Context(), func(q *storage.Queries) error {
+		user, err := q.GetUser(r.Context(), id)
+		if err != nil {
+			return errors.FromDB(err, "user")
+		}
+		if err := q.DeleteUser(r.Context(), id); err != nil {
+			return err
+		}
+		changes, err := audit.Changes(userSnapshot{Username: user.Username, Email: user.Email, Profile: user.Profile.RawMessage}, nil)
+		if err != nil {
+			return err
+		}
+		return audit.Record(r, q, audit.Event{
+			ActorID:    id,
+			Action:     audit.ActionUserDelete,
+			TargetType: audit.TargetUser,
+			TargetID:   id,
+			Details:    changes,
+		})
+	})
 	if err != nil {
-		u.Logger.Error("failed to delete user", "error", err)
-		http.Error(w, "failed to delete user", http.StatusInternalServerError)
+		e := errors.As(err)
+		if e.Kind == errors.ErrInternal {
+			u.Logger.Error("failed to delete user", "error", err)
+			e = errors.Internal("failed to delete user")
+		}
+		problem.Write(w, r, e)
 		return
 	}
 
@@ -140,80 +352,143 @@ func (u UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	_, err = w.Write([]byte(`{"message": "success dleted user"}`))
 	if err != nil {
 		u.Logger.Error("failed to write response", "error", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		problem.Write(w, r, errors.Internal("Internal Server Error"))
 		return
 	}
 }
 
+// RequestPasswordReset emails a reset link to the account registered with
+// the given email. The response is the same whether such an account exists
+// or not, and the lookup happens in the background so that response times
+// do not tell either. Requests are throttled per email and client address,
+// again whether the account exists or not.
 func (u UserHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
 	var req models.PasswordResetRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+	if !decode(w, r, &req) {
 		return
 	}
 
-	user, err := u.Storage.GetUserByEmail(r.Context(), req.Email)
+	address := normalizeEmail(req.Email)
+	wait, err := u.Limiter.AllowReset(r.Context(), address, clientip.FromRequest(r))
 	if err != nil {
-		http.Error(w, "email not found", http.StatusNotFound)
+		u.Logger.Error("failed to check password reset requests", "error", err)
+		problem.Write(w, r, errors.Internal("failed to request password reset"))
+		return
+	}
+	if wait > 0 {
+		problem.Write(w, r, errors.RateLimited("too many password reset requests, try again later", wait))
 		return
 	}
 
-	resetToken, err := jwt.GenerateJWT(int32(user.ID))
+	go u.sendPasswordReset(context.WithoutCancel(r.Context()), address, email.MatchLocale(r.Header.Get("Accept-Language")))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_, err = w.Write([]byte(`{"message": "if an account with this email exists, a reset link has been sent to it"}`))
 	if err != nil {
-		http.Error(w, "failed to generate JWT", http.StatusInternalServerError)
-		return
+		u.Logger.Error("failed to write response", "error", err)
 	}
+}
 
-	err = u.Storage.SavePasswordResetToken(r.Context(), storage.SavePasswordResetTokenParams{
-		UserID: sql.NullInt32{Int32: user.ID, Valid: true},
-		Token:  resetToken,
-	})
+// sendPasswordReset replaces any outstanding reset token of the user with
+// the given email by a new one and emails the link to it.
+func (u UserHandler) sendPasswordReset(ctx context.Context, address, locale string) {
+	user, err := u.Storage.GetUserByEmail(ctx, address)
+	if err == sql.ErrNoRows {
+		return
+	}
 	if err != nil {
-		http.Error(w, "failed to save reset token", http.StatusInternalServerError)
+		u.Logger.Error("failed to get user", "error", err)
 		return
 	}
 
-	err = email.SendResetEmail(req.Email, resetToken)
+	t, err := token.Generate()
+	if err != nil {
+		u.Logger.Error("failed to generate reset token", "error", err)
+		return
+	}
+	err = u.withTx(ctx, func(q *storage.Queries) error {
+		if err := q.DeletePasswordResetTokens(ctx, user.ID); err != nil {
+			return err
+		}
+		return q.CreatePasswordResetToken(ctx, storage.CreatePasswordResetTokenParams{
+			UserID:    user.ID,
+			TokenHash: token.Hash(t),
+			ExpiresAt: time.Now().Add(u.Auth.PasswordResetTTL),
+		})
+	})
 	if err != nil {
-		u.Logger.Error("failed to send reset email", "error", err)
-		http.Error(w, "failed to send reset email", http.StatusInternalServerError)
+		u.Logger.Error("failed to save reset token", "error", err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	_, err = w.Write([]byte(`{"message": "success"}`))
+	link, err := tokenLink(u.Auth.ResetURL, t)
 	if err != nil {
-		u.Logger.Error("failed to write response", "error", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		u.Logger.Error("failed to build reset link", "error", err)
 		return
 	}
+	err = u.queueEmail(user.Email, email.TemplateReset, locale, email.ResetData{
+		Username:  user.Username,
+		Link:      link,
+		ExpiresIn: u.Auth.PasswordResetTTL,
+	})
+	if err != nil {
+		u.Logger.Error("failed to queue reset email", "error", err)
+	}
 }
+
+// ResetPassword sets a new password with a reset token. The token and any
+// other outstanding ones are consumed, and every refresh token of the user is
+// revoked so existing sessions end once their access token expires.
 func (u UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 	var req models.PasswordResetSubmitRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid request body", http.StatusBadRequest)
-		return
-	}
-
-	resetToken, err := u.Storage.GetPasswordResetToken(r.Context(), req.Token)
-	if err != nil {
-		http.Error(w, "invalid or expired token", http.StatusBadRequest)
+	if !decode(w, r, &req) {
 		return
 	}
 
 	hashedPassword, err := hash.GenerateFromPassword(req.NewPassword)
 	if err != nil {
-		http.Error(w, "failed to hash password", http.StatusInternalServerError)
+		u.Logger.Error("failed to hash password", "error", err)
+		problem.Write(w, r, errors.Internal("failed to hash password"))
 		return
 	}
 
-	err = u.Storage.UpdatePassword(r.Context(), storage.UpdatePasswordParams{
-		ID:           resetToken.UserID.Int32,
-		PasswordHash: hashedPassword,
+	err = u.withTx(r.Context(), func(q *storage.Queries) error {
+		reset, err := q.UsePasswordResetToken(r.Context(), token.Hash(req.Token))
+		if err == sql.ErrNoRows {
+			return errors.Invalid("invalid or expired token")
+		}
+		if err != nil {
+			return err
+		}
+
+		err = q.UpdatePassword(r.Context(), storage.UpdatePasswordParams{
+			ID:           reset.UserID,
+			PasswordHash: hashedPassword,
+		})
+		if err != nil {
+			return err
+		}
+		if err := q.DeletePasswordResetTokens(r.Context(), reset.UserID); err != nil {
+			return err
+		}
+		if err := q.RevokeUserRefreshTokens(r.Context(), reset.UserID); err != nil {
+			return err
+		}
+		return audit.Record(r, q, audit.Event{
+			ActorID:    reset.UserID,
+			Action:     audit.ActionPasswordReset,
+			TargetType: audit.TargetUser,
+			TargetID:   reset.UserID,
+		})
 	})
 	if err != nil {
-		http.Error(w, "failed to update password", http.StatusInternalServerError)
+		e := errors.As(err)
+		if e.Kind == errors.ErrInternal {
+			u.Logger.Error("failed to reset password", "error", err)
+			e = errors.Internal("failed to update password")
+		}
+		problem.Write(w, r, e)
 		return
 	}
 
@@ -222,7 +497,46 @@ func (u UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 	_, err = w.Write([]byte(`{"message": "success"}`))
 	if err != nil {
 		u.Logger.Error("failed to write response", "error", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		problem.Write(w, r, errors.Internal("Internal Server Error"))
 		return
 	}
 }
+
+// userSnapshot is what the audit log records of an account.
+type userSnapshot struct {
+	Username string          `json:"username"`
+	Email    string          `json:"email"`
+	Profile  json.RawMessage `json:"profile,omitempty"`
+}
+
+// normalizeEmail returns the form emails are stored and compared in.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// userConflict turns a violation of the unique username or email index into
+// a conflict naming the taken field, and returns nil for any other error.
+func userConflict(err error) *errors.Error {
+	var field string
+	switch errors.UniqueConstraint(err) {
+	case "users_username_lower_key":
+		field = "username"
+	case "users_email_lower_key":
+		field = "email"
+	default:
+		return nil
+	}
+	return identityConflict(field).Wrap(err)
+}
+
+// identityConflict reports that the username or email field names an
+// identity another account already uses.
+func identityConflict(field string) *errors.Error {
+	msg := "username is already taken"
+	if field == "email" {
+		msg = "email is already registered"
+	}
+	e := errors.Conflict(msg)
+	e.Fields = []errors.FieldError{{Field: field, Message: "is already taken"}}
+	return e
+}
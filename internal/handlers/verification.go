@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/errors"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/audit"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/email"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/middleware"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/problem"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/token"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/models"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/storage"
+)
+
+// VerifyEmail confirms the address a verification link was sent to. Links
+// work once. A link for the address the account uses verifies it; a link
+// for a new address of a verified account replaces the old address, which
+// stays in use until then.
+func (u UserHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req models.VerifyEmailRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	err := u.withTx(r.Context(), func(q *storage.Queries) error {
+		verification, err := q.UseEmailVerificationToken(r.Context(), token.Hash(req.Token))
+		if err == sql.ErrNoRows {
+			return errors.Invalid("invalid or expired token")
+		}
+		if err != nil {
+			return err
+		}
+		user, err := q.GetUser(r.Context(), verification.UserID)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case user.Email == verification.Email:
+			_, err := q.VerifyUserEmail(r.Context(), storage.VerifyUserEmailParams{
+				ID:    user.ID,
+				Email: verification.Email,
+			})
+			return err
+		case user.EmailVerifiedAt.Valid:
+			err := q.ChangeUserEmail(r.Context(), storage.ChangeUserEmailParams{
+				ID:    user.ID,
+				Email: verification.Email,
+			})
+			if conflict := userConflict(err); conflict != nil {
+				return conflict
+			}
+			if err != nil {
+				return err
+			}
+			return audit.Record(r, q, audit.Event{
+				ActorID:    user.ID,
+				Action:     audit.ActionUserUpdate,
+				TargetType: audit.TargetUser,
+				TargetID:   user.ID,
+				Details:    map[string]audit.Change{"email": {Before: user.Email, After: verification.Email}},
+			})
+		default:
+			// The link was for an address the unverified account no
+			// longer uses.
+			return errors.Invalid("invalid or expired token")
+		}
+	})
+	if err != nil {
+		e := errors.As(err)
+		if e.Kind == errors.ErrInternal {
+			u.Logger.Error("failed to verify email", "error", err)
+			e = errors.Internal("failed to verify email")
+		}
+		problem.Write(w, r, e)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ResendVerification sends a new verification link to the caller, at most
+// once per resend interval.
+func (u UserHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+
+	user, err := u.Storage.GetUser(r.Context(), userID)
+	if err != nil {
+		u.Logger.Error("failed to get user", "error", err)
+		problem.Write(w, r, errors.Internal("failed to resend verification"))
+		return
+	}
+	if user.EmailVerifiedAt.Valid {
+		problem.Write(w, r, errors.Conflict("email is already verified"))
+		return
+	}
+
+	sentAt, err := u.Storage.GetLastEmailVerificationSentAt(r.Context(), userID)
+	if err != nil {
+		u.Logger.Error("failed to get last verification", "error", err)
+		problem.Write(w, r, errors.Internal("failed to resend verification"))
+		return
+	}
+	if sentAt.Valid {
+		if wait := time.Until(sentAt.Time.Add(u.Auth.VerificationResendInterval)); wait > 0 {
+			problem.Write(w, r, errors.RateLimited("verification email was sent recently", wait))
+			return
+		}
+	}
+
+	link, err := u.createVerification(r.Context(), &u.Storage, user)
+	if err != nil {
+		u.Logger.Error("failed to create verification", "error", err)
+		problem.Write(w, r, errors.Internal("failed to resend verification"))
+		return
+	}
+	if err := u.queueVerification(user, link, email.MatchLocale(r.Header.Get("Accept-Language"))); err != nil {
+		u.Logger.Error("failed to queue verification email", "error", err)
+		problem.Write(w, r, errors.Internal("failed to send verification email"))
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// RequireVerified rejects requests from suspended users and from users who
+// have not verified their email yet. The latter can still sign in, manage
+// their account and ask for a new verification link.
+func (u UserHandler) RequireVerified(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.UserIDFromContext(r.Context())
+		if !ok {
+			problem.Write(w, r, errors.Unauthorized("unauthorized"))
+			return
+		}
+
+		user, err := u.Storage.GetUser(r.Context(), userID)
+		if err == sql.ErrNoRows {
+			problem.Write(w, r, errors.Unauthorized("unknown user"))
+			return
+		}
+		if err != nil {
+			u.Logger.Error("failed to get user", "error", err)
+			problem.Write(w, r, errors.Internal("failed to check verification"))
+			return
+		}
+		if user.SuspendedAt.Valid {
+			problem.Write(w, r, errors.Forbidden("account is suspended"))
+			return
+		}
+		if !user.EmailVerifiedAt.Valid {
+			problem.Write(w, r, errors.Forbidden("email address is not verified"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// createVerification stores a new verification token for the user's current
+// email and returns the link to send.
+func (u UserHandler) createVerification(ctx context.Context, q *storage.Queries, user storage.User) (string, error) {
+	t, err := token.Generate()
+	if err != nil {
+		return "", err
+	}
+	_, err = q.CreateEmailVerificationToken(ctx, storage.CreateEmailVerificationTokenParams{
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenHash: token.Hash(t),
+		ExpiresAt: time.Now().Add(u.Auth.VerificationTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return tokenLink(u.Auth.VerifyURL, t)
+}
+
+// tokenLink appends t to the base URL of a page as the token parameter.
+func tokenLink(base, t string) (string, error) {
+	link, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	query := link.Query()
+	query.Set("token", t)
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}
+
+// sendVerification emails a verification link, logging instead of failing
+// the request when that is not possible since the user can ask for another
+// one.
+func (u UserHandler) sendVerification(user storage.User, link, locale string) {
+	if err := u.queueVerification(user, link, locale); err != nil {
+		u.Logger.Error("failed to queue verification email", "error", err)
+	}
+}
+
+func (u UserHandler) queueVerification(user storage.User, link, locale string) error {
+	return u.queueEmail(user.Email, email.TemplateVerification, locale, email.VerificationData{
+		Username:  user.Username,
+		Link:      link,
+		ExpiresIn: u.Auth.VerificationTTL,
+	})
+}
+
+// queueEmail renders a template and queues the message for delivery.
+func (u UserHandler) queueEmail(to, template, locale string, data any) error {
+	msg, err := email.Render(to, template, locale, data)
+	if err != nil {
+		return err
+	}
+	return u.Mail.Enqueue(msg)
+}
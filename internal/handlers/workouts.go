@@ -1,186 +1,351 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
-	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/Oyatillohgayratov/fitness-tracking-app/errors"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/audit"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/middleware"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/pagination"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/problem"
 	"github.com/Oyatillohgayratov/fitness-tracking-app/models"
 	"github.com/Oyatillohgayratov/fitness-tracking-app/storage"
 )
 
 func (u UserHandler) CreateWorkout(w http.ResponseWriter, r *http.Request) {
 	workout := models.WorkoutCreateRequest{}
-	if err := json.NewDecoder(r.Body).Decode(&workout); err != nil {
-		u.Logger.Error("failed to decode user registration",
-			slog.Any("error", err))
-		http.Error(w, errors.ErrDecodeUserRegister.Error(), http.StatusBadRequest)
+	if !decode(w, r, &workout) {
+		return
+	}
+
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
+		return
+	}
+	if workout.UserID != 0 && workout.UserID != userID {
+		problem.Write(w, r, errors.Forbidden("forbidden"))
+		return
+	}
+
+	times, ok := u.resolveWorkoutTimes(w, r, userID, workout.StartedAt, workout.EndedAt, workout.Timezone)
+	if !ok {
 		return
 	}
 
 	workoutRes, err := u.Storage.CreateWorkout(r.Context(), storage.CreateWorkoutParams{
-		UserID:      workout.UserID,
+		UserID:      userID,
 		Name:        workout.Name,
-		Description: workout.Description,
+		Description: nullString(workout.Description),
+		Date:        times.date,
+		StartedAt:   times.startedAt,
+		EndedAt:     times.endedAt,
+		Timezone:    times.timezone,
 	})
 	if err != nil {
 		u.Logger.Error("failed to create workout", "error", err)
-		http.Error(w, "Failed to create workout", http.StatusInternalServerError)
+		problem.Write(w, r, errors.Internal("Failed to create workout"))
 		return
 	}
 
-	res := models.WorkoutCreateResponse{
-		ID:          workoutRes.ID,
-		UserID:      workoutRes.UserID,
-		Name:        workoutRes.Name,
-		Description: workoutRes.Description,
-		Date:        workoutRes.Date,
-		CreateAt:    workoutRes.CreateAt,
-		UpdateAt:    workoutRes.UpdateAt,
-	}
+	res := workoutResponse(workoutRes)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(&res)
 }
 
 func (u UserHandler) GetWorkoutsByUserID(w http.ResponseWriter, r *http.Request) {
-	idStr := r.FormValue("id")
-	if idStr == "" {
-		http.Error(w, "missing id parameter", http.StatusBadRequest)
+	userID, ok := callerID(w, r, "user_id")
+	if !ok {
 		return
 	}
-	id, err := strconv.Atoi(idStr)
+
+	// Sorting by date pages on started_at, while the from and to filters
+	// use the date column. The date is the day of started_at in the timezone
+	// of the workout, so across timezones the order follows the start time
+	// rather than the day; the filters only narrow the rows and every page
+	// applies them alike, so none is skipped or repeated.
+	page, err := pagination.Parse(r, []string{"date", "updated"}, "-date")
 	if err != nil {
-		http.Error(w, "invalid id parameter", http.StatusBadRequest)
+		problem.Write(w, r, errors.Invalid(err.Error()))
 		return
 	}
 
-	workouts, err := u.Storage.GetWorkoutsByUserID(r.Context(), int32(id))
+	params := storage.ListWorkoutsParams{
+		UserID:    userID,
+		SortDesc:  page.Desc,
+		SortBy:    page.Sort,
+		PageLimit: int32(page.Limit + 1),
+	}
+	if q := r.FormValue("q"); q != "" {
+		params.Name = sql.NullString{String: q, Valid: true}
+	}
+	for _, f := range []struct {
+		name string
+		dst  *sql.NullTime
+	}{{"from", &params.FromDate}, {"to", &params.ToDate}} {
+		if v := r.FormValue(f.name); v != "" {
+			d, err := time.Parse(time.DateOnly, v)
+			if err != nil {
+				problem.Write(w, r, errors.Invalid("invalid "+f.name+" parameter"))
+				return
+			}
+			*f.dst = sql.NullTime{Time: d, Valid: true}
+		}
+	}
+	for _, f := range []struct {
+		name string
+		dst  *sql.NullInt32
+	}{{"template", &params.TemplateID}, {"exercise", &params.DefinitionID}} {
+		if v := r.FormValue(f.name); v != "" {
+			id, err := strconv.Atoi(v)
+			if err != nil {
+				problem.Write(w, r, errors.Invalid("invalid "+f.name+" parameter"))
+				return
+			}
+			*f.dst = sql.NullInt32{Int32: int32(id), Valid: true}
+		}
+	}
+	if page.After != nil {
+		key, err := time.Parse(time.RFC3339Nano, page.After.Key)
+		if err != nil {
+			problem.Write(w, r, errors.Invalid(pagination.ErrInvalidCursor.Error()))
+			return
+		}
+		params.AfterKey = sql.NullTime{Time: key, Valid: true}
+		params.AfterID = page.After.ID
+	}
+
+	workouts, err := u.Storage.ListWorkouts(r.Context(), params)
 	if err != nil {
 		u.Logger.Error("failed to get workouts", "error", err)
-		http.Error(w, "failed to get workouts", http.StatusInternalServerError)
+		problem.Write(w, r, errors.Internal("failed to get workouts"))
 		return
 	}
 
+	res := models.Page[models.WorkoutCreateResponse]{
+		Items: make([]models.WorkoutCreateResponse, 0, page.Limit),
+	}
+	for _, wo := range workouts[:min(len(workouts), page.Limit)] {
+		res.Items = append(res.Items, workoutResponse(wo))
+	}
+	if len(workouts) > page.Limit {
+		last := workouts[page.Limit-1]
+		key := last.StartedAt
+		if page.Sort == "updated" {
+			key = last.UpdateAt
+		}
+		next := page.Next(key.Format(time.RFC3339Nano), last.ID)
+		res.NextCursor = &next
+		pagination.SetLink(w, r, next)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(workouts)
+	json.NewEncoder(w).Encode(&res)
 }
 
 func (u UserHandler) GetWorkoutByUserID(w http.ResponseWriter, r *http.Request) {
 	idStr := r.FormValue("id")
 	if idStr == "" {
-		http.Error(w, "missing id parameter", http.StatusBadRequest)
+		problem.Write(w, r, errors.Invalid("missing id parameter"))
 		return
 	}
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "invalid id parameter", http.StatusBadRequest)
+		problem.Write(w, r, errors.Invalid("invalid id parameter"))
 		return
 	}
-	userId := r.FormValue("user_id")
-	if userId == "" {
-		http.Error(w, "missing user_id parameter", http.StatusBadRequest)
+	userID, ok := callerID(w, r, "user_id")
+	if !ok {
 		return
 	}
-	userIdInt, err := strconv.Atoi(userId)
-	if err != nil {
-		http.Error(w, "invalid user_id parameter", http.StatusBadRequest)
+
+	workout, err := u.Storage.GetWorkoutByUserID(r.Context(), storage.GetWorkoutByUserIDParams{ID: int32(id), UserID: userID})
+	if err == sql.ErrNoRows {
+		problem.Write(w, r, errors.NotFound("workout not found"))
 		return
 	}
-
-	workout, err := u.Storage.GetWorkoutByUserID(r.Context(), storage.GetWorkoutByUserIDParams{ID: int32(id), UserID: int32(userIdInt)})
 	if err != nil {
 		u.Logger.Error("failed to get workout", "error", err)
-		http.Error(w, "failed to get workout", http.StatusInternalServerError)
+		problem.Write(w, r, errors.Internal("failed to get workout"))
 		return
 	}
 
+	res := workoutResponse(workout)
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(workout)
+	json.NewEncoder(w).Encode(&res)
 
 }
 
 func (u UserHandler) UpdateWorkout(w http.ResponseWriter, r *http.Request) {
-	idStr := r.FormValue("id")
-	if idStr == "" {
-		http.Error(w, "missing id parameter", http.StatusBadRequest)
+	id, ok := pathID(w, r, "id")
+	if !ok {
 		return
 	}
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		http.Error(w, "invalid id parameter", http.StatusBadRequest)
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
 		return
 	}
-	useridStr := r.FormValue("id")
-	if idStr == "" {
-		http.Error(w, "missing id parameter", http.StatusBadRequest)
+
+	var req models.WorkoutUpdateRequest
+	if !decode(w, r, &req) {
 		return
 	}
-	userid, err := strconv.Atoi(useridStr)
-	if err != nil {
-		http.Error(w, "invalid id parameter", http.StatusBadRequest)
+	if req.Name != nil && *req.Name == "" {
+		problem.Write(w, r, errors.Invalid("name must not be empty"))
 		return
 	}
 
-	var updateWorkout models.WorkoutUpdateRequest
-	if err := json.NewDecoder(r.Body).Decode(&updateWorkout); err != nil {
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+	workout, err := u.Storage.GetWorkoutByUserID(r.Context(), storage.GetWorkoutByUserIDParams{ID: id, UserID: userID})
+	if err == sql.ErrNoRows {
+		problem.Write(w, r, errors.NotFound("workout not found"))
 		return
 	}
-
-	err = u.Storage.UpdateWorkout(r.Context(), storage.UpdateWorkoutParams{
-		ID:          int32(id),
-		UserID:      int32(userid),
-		Name:        updateWorkout.Name,
-		Description: updateWorkout.Description,
-		Date:        updateWorkout.Date,
-	})
 	if err != nil {
-		u.Logger.Error("failed to update workout", "error", err)
-		http.Error(w, "failed to update workout", http.StatusInternalServerError)
+		u.Logger.Error("failed to get workout", "error", err)
+		problem.Write(w, r, errors.Internal("failed to update workout"))
 		return
 	}
-}
 
-func (u UserHandler) DeleteWorkout(w http.ResponseWriter, r http.Request) {
-	idStr := r.FormValue("id")
-	if idStr == "" {
-		http.Error(w, "missing id parameter", http.StatusBadRequest)
+	// Fields left out of the request keep their current values.
+	if req.Name != nil {
+		workout.Name = *req.Name
+	}
+	if req.Description.Set {
+		workout.Description = nullString(req.Description.Value)
+	}
+	startedAt, endedAt, tz := req.StartedAt, timePtr(workout.EndedAt), req.Timezone
+	if startedAt == nil {
+		startedAt = &workout.StartedAt
+	}
+	if req.EndedAt.Set {
+		endedAt = req.EndedAt.Value
+	}
+	if tz == nil {
+		tz = &workout.Timezone
+	}
+	times, ok := u.resolveWorkoutTimes(w, r, userID, startedAt, endedAt, tz)
+	if !ok {
 		return
 	}
-	id, err := strconv.Atoi(idStr)
+
+	n, err := u.Storage.UpdateWorkout(r.Context(), storage.UpdateWorkoutParams{
+		ID:          id,
+		UserID:      userID,
+		Name:        workout.Name,
+		Description: workout.Description,
+		Date:        times.date,
+		StartedAt:   times.startedAt,
+		EndedAt:     times.endedAt,
+		Timezone:    times.timezone,
+	})
 	if err != nil {
-		http.Error(w, "invalid id parameter", http.StatusBadRequest)
+		u.Logger.Error("failed to update workout", "error", err)
+		problem.Write(w, r, errors.Internal("failed to update workout"))
 		return
 	}
-	useridStr := r.FormValue("id")
-	if idStr == "" {
-		http.Error(w, "missing id parameter", http.StatusBadRequest)
+	if n == 0 {
+		problem.Write(w, r, errors.NotFound("workout not found"))
 		return
 	}
-	userid, err := strconv.Atoi(useridStr)
-	if err != nil {
-		http.Error(w, "invalid id parameter", http.StatusBadRequest)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (u UserHandler) DeleteWorkout(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
 		return
 	}
-
-	var updateWorkout models.WorkoutUpdateRequest
-	if err := json.NewDecoder(r.Body).Decode(&updateWorkout); err != nil {
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, errors.Unauthorized("unauthorized"))
 		return
 	}
 
-	err = u.Storage.DeleteWorkout(r.Context(), storage.DeleteWorkoutParams{
-		ID:     int32(id),
-		UserID: int32(userid),
+	err := u.withTx(r.Context(), func(q *storage.Queries) error {
+		definitionIDs, err := q.ListWorkoutDefinitionIDs(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		// Records keep their row when the workout goes, so the ones set in
+		// it are removed first and the best of the other workouts found.
+		err = q.DeleteWorkoutPersonalRecords(r.Context(), storage.DeleteWorkoutPersonalRecordsParams{
+			WorkoutID: nullInt32(&id),
+			UserID:    userID,
+		})
+		if err != nil {
+			return err
+		}
+		workout, err := q.DeleteWorkout(r.Context(), storage.DeleteWorkoutParams{ID: id, UserID: userID})
+		if err != nil {
+			return errors.FromDB(err, "workout")
+		}
+		for _, definitionID := range definitionIDs {
+			if _, err := reconcileRecords(r.Context(), q, userID, definitionID.Int32); err != nil {
+				return err
+			}
+		}
+		changes, err := audit.Changes(workoutResponse(workout), nil)
+		if err != nil {
+			return err
+		}
+		return audit.Record(r, q, audit.Event{
+			ActorID:    userID,
+			Action:     audit.ActionWorkoutDelete,
+			TargetType: audit.TargetWorkout,
+			TargetID:   workout.ID,
+			Details:    changes,
+		})
 	})
 	if err != nil {
-		u.Logger.Error("failed to delete workout", "error", err)
-		http.Error(w, "failed to delete workout", http.StatusInternalServerError)
+		e := errors.As(err)
+		if e.Kind == errors.ErrInternal {
+			u.Logger.Error("failed to delete workout", "error", err)
+			e = errors.Internal("failed to delete workout")
+		}
+		problem.Write(w, r, e)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-}
\ No newline at end of file
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// workoutTimes holds when a workout took place.
+type workoutTimes struct {
+	date      time.Time
+	startedAt time.Time
+	endedAt   sql.NullTime
+	timezone  string
+}
+
+// resolveWorkoutTimes fills in the defaults for the start and timezone of a
+// workout and derives the calendar date it belongs to in that timezone, so a
+// session started at 23:30 in New York stays on that day whatever the offset
+// to UTC is at the time.
+func (u UserHandler) resolveWorkoutTimes(w http.ResponseWriter, r *http.Request, userID int32, startedAt, endedAt *time.Time, tz *string) (workoutTimes, bool) {
+	loc, ok := u.location(w, r, userID, tz)
+	if !ok {
+		return workoutTimes{}, false
+	}
+
+	t := workoutTimes{startedAt: time.Now(), timezone: loc.String()}
+	if startedAt != nil {
+		t.startedAt = *startedAt
+	}
+	if endedAt != nil {
+		if endedAt.Before(t.startedAt) {
+			problem.Write(w, r, errors.Invalid("ended_at must not be before started_at"))
+			return workoutTimes{}, false
+		}
+		t.endedAt = sql.NullTime{Time: *endedAt, Valid: true}
+	}
+	t.date = localDate(t.startedAt, loc)
+	return t, true
+}
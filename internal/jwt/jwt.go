@@ -1,31 +1,190 @@
+// Package jwt issues and verifies the JSON Web Tokens used as access tokens.
+//
+// Tokens are signed with HS256, RS256 or EdDSA. Every key has an id that is
+// written to the kid header of the tokens it signs. New tokens are signed with
+// a single key, while every configured key is accepted when verifying, so a
+// key can be rotated by adding its successor, switching the signing key and
+// removing the old one once the tokens it signed have expired.
 package jwt
 
 import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
 	"time"
-	"github.com/dgrijalva/jwt-go"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/config"
 )
 
-var jwtSecret = []byte("your-secret-key")
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpired      = errors.New("token expired")
+	ErrNotYetValid  = errors.New("token not valid yet")
+	ErrUnknownKey   = errors.New("unknown signing key")
+)
 
-func GenerateJWT(userID int32) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
-	claims := token.Claims.(jwt.MapClaims)
-	claims["user_id"] = userID
-	claims["exp"] = time.Now().Add(time.Hour * 24).Unix()
+// Token types, sent in the typ claim.
+const (
+	TypeAccess = "access"
+	// TypeMFA tokens prove the password of an account with two-factor
+	// authentication was checked. They are exchanged for an access token
+	// together with the second factor.
+	TypeMFA = "mfa"
+)
 
-	tokenString, err := token.SignedString(jwtSecret)
-	if err != nil {
-		return "", err
+// Claims are the claims of the tokens issued by Manager.
+type Claims struct {
+	gojwt.RegisteredClaims
+	// Type tells access tokens apart from other tokens signed with the
+	// same keys.
+	Type   string `json:"typ,omitempty"`
+	UserID int32  `json:"user_id"`
+}
+
+// Manager signs and verifies tokens with the configured keys.
+type Manager struct {
+	signing  *Key
+	keys     map[string]*Key
+	parser   *gojwt.Parser
+	issuer   string
+	audience string
+	ttl      time.Duration
+}
+
+// New loads the keys of cfg. Defaults must already be applied.
+func New(cfg config.JWT) (*Manager, error) {
+	m := &Manager{
+		keys:     map[string]*Key{},
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		ttl:      cfg.AccessTokenTTL,
 	}
-	return tokenString, nil
+	var algorithms []string
+	for _, kc := range cfg.Keys {
+		k, err := LoadKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", kc.ID, err)
+		}
+		if _, ok := m.keys[k.ID]; ok {
+			return nil, fmt.Errorf("jwt key %q: duplicate id", k.ID)
+		}
+		m.keys[k.ID] = k
+		if !slices.Contains(algorithms, k.Algorithm) {
+			algorithms = append(algorithms, k.Algorithm)
+		}
+	}
+
+	m.signing = m.keys[cfg.SigningKey]
+	if m.signing == nil {
+		return nil, fmt.Errorf("jwt signing key %q is not configured", cfg.SigningKey)
+	}
+	if !m.signing.CanSign() {
+		return nil, fmt.Errorf("jwt signing key %q has no private key", cfg.SigningKey)
+	}
+
+	m.parser = gojwt.NewParser(
+		gojwt.WithValidMethods(algorithms),
+		gojwt.WithIssuer(cfg.Issuer),
+		gojwt.WithAudience(cfg.Audience),
+		gojwt.WithLeeway(cfg.Leeway),
+		gojwt.WithExpirationRequired(),
+	)
+	return m, nil
+}
+
+// AccessTokenTTL is how long access tokens stay valid.
+func (m *Manager) AccessTokenTTL() time.Duration {
+	return m.ttl
+}
+
+// GenerateAccessToken issues an access token for the given user.
+func (m *Manager) GenerateAccessToken(userID int32) (string, error) {
+	return m.Generate(TypeAccess, userID, m.ttl)
 }
 
-func ValidateJWT(tokenString string) (*jwt.Token, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
+// ParseAccessToken verifies an access token issued by GenerateAccessToken.
+func (m *Manager) ParseAccessToken(token string) (*Claims, error) {
+	return m.ParseType(token, TypeAccess)
+}
+
+// Generate issues a token of the given type for a user, valid for ttl.
+func (m *Manager) Generate(typ string, userID int32, ttl time.Duration) (string, error) {
+	now := time.Now()
+	return m.Sign(Claims{
+		RegisteredClaims: gojwt.RegisteredClaims{
+			Subject:   strconv.Itoa(int(userID)),
+			ExpiresAt: gojwt.NewNumericDate(now.Add(ttl)),
+			NotBefore: gojwt.NewNumericDate(now),
+		},
+		Type:   typ,
+		UserID: userID,
 	})
+}
+
+// ParseType is Parse for tokens that must be of the given type.
+func (m *Manager) ParseType(token, typ string) (*Claims, error) {
+	claims, err := m.Parse(token)
 	if err != nil {
 		return nil, err
 	}
-	return token, nil
+	if claims.Type != typ {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// Sign signs claims with the signing key, filling in the issuer, audience
+// and issue time.
+func (m *Manager) Sign(claims Claims) (string, error) {
+	claims.Issuer = m.issuer
+	claims.Audience = gojwt.ClaimStrings{m.audience}
+	if claims.IssuedAt == nil {
+		claims.IssuedAt = gojwt.NewNumericDate(time.Now())
+	}
+
+	token := gojwt.NewWithClaims(m.signing.method(), claims)
+	token.Header["kid"] = m.signing.ID
+	return token.SignedString(m.signing.signingKey())
+}
+
+// Parse verifies the signature of token and checks its expiry, not-before
+// time, issuer and audience. The key is picked by the kid header and must
+// use the algorithm the header names.
+func (m *Manager) Parse(token string) (*Claims, error) {
+	var claims Claims
+	_, err := m.parser.ParseWithClaims(token, &claims, m.verificationKey)
+	switch {
+	case err == nil:
+		return &claims, nil
+	case errors.Is(err, ErrUnknownKey):
+		return nil, ErrUnknownKey
+	case errors.Is(err, gojwt.ErrTokenExpired):
+		return nil, ErrExpired
+	case errors.Is(err, gojwt.ErrTokenNotValidYet):
+		return nil, ErrNotYetValid
+	}
+	return nil, ErrInvalidToken
+}
+
+// verificationKey returns the key named by the kid header of token, which
+// must be meant for the algorithm of the token. Otherwise a token could make
+// an RSA public key be used as an HMAC secret.
+func (m *Manager) verificationKey(token *gojwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	k, ok := m.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if token.Method.Alg() != k.Algorithm {
+		return nil, ErrInvalidToken
+	}
+	return k.verificationKey(), nil
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
 }
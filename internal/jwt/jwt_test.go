@@ -0,0 +1,279 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/config"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func hmacKey(id string) config.JWTKey {
+	return config.JWTKey{ID: id, Algorithm: HS256, Secret: testSecret + id}
+}
+
+// writePEM writes a PEM file of the given type to a temporary directory.
+func writePEM(t *testing.T, typ string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// rsaKeys returns a signing key and the matching verification-only key.
+func rsaKeys(t *testing.T, id string) (config.JWTKey, config.JWTKey) {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config.JWTKey{ID: id, Algorithm: RS256, PrivateKeyFile: writePEM(t, "PRIVATE KEY", privateDER)},
+		config.JWTKey{ID: id, Algorithm: RS256, PublicKeyFile: writePEM(t, "PUBLIC KEY", publicDER)}
+}
+
+func ed25519Key(t *testing.T, id string) config.JWTKey {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config.JWTKey{ID: id, Algorithm: EdDSA, PrivateKeyFile: writePEM(t, "PRIVATE KEY", der)}
+}
+
+func newManager(t *testing.T, signing string, keys ...config.JWTKey) *Manager {
+	t.Helper()
+	m, err := New(config.JWT{
+		Issuer:         "fitness",
+		Audience:       "fitness-api",
+		AccessTokenTTL: time.Minute,
+		Leeway:         30 * time.Second,
+		SigningKey:     signing,
+		Keys:           keys,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// forge signs header and claims with HMAC-SHA256 over secret, the way an
+// attacker would build a token without the library.
+func forge(t *testing.T, header, claims map[string]any, secret []byte) string {
+	t.Helper()
+	h, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := encode(h) + "." + encode(c)
+	if secret == nil {
+		return signed + "."
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return signed + "." + encode(mac.Sum(nil))
+}
+
+func validClaims() map[string]any {
+	now := time.Now()
+	return map[string]any{
+		"iss":     "fitness",
+		"aud":     "fitness-api",
+		"exp":     now.Add(time.Minute).Unix(),
+		"nbf":     now.Unix(),
+		"typ":     TypeAccess,
+		"user_id": 42,
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	private, _ := rsaKeys(t, "rsa")
+	for _, key := range []config.JWTKey{hmacKey("hmac"), private, ed25519Key(t, "ed")} {
+		t.Run(key.Algorithm, func(t *testing.T) {
+			m := newManager(t, key.ID, key)
+			token, err := m.GenerateAccessToken(42)
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, err := m.ParseAccessToken(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.UserID != 42 || claims.Subject != "42" {
+				t.Errorf("claims = %+v, want user 42", claims)
+			}
+		})
+	}
+}
+
+func TestParseRejectsOtherType(t *testing.T) {
+	m := newManager(t, "hmac", hmacKey("hmac"))
+	token, err := m.Generate(TypeMFA, 42, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.ParseAccessToken(token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("err = %v, want %v", err, ErrInvalidToken)
+	}
+	if _, err := m.ParseType(token, TypeMFA); err != nil {
+		t.Errorf("err = %v, want nil", err)
+	}
+}
+
+func TestParseRejectsAlgorithmConfusion(t *testing.T) {
+	_, public := rsaKeys(t, "rsa")
+	m := newManager(t, "hmac", hmacKey("hmac"), public)
+	pemBytes, err := os.ReadFile(public.PublicKeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		header map[string]any
+		secret []byte
+	}{
+		{"HS256 with RSA public key", map[string]any{"alg": HS256, "kid": "rsa"}, pemBytes},
+		{"none for RSA key", map[string]any{"alg": "none", "kid": "rsa"}, nil},
+		{"none for HMAC key", map[string]any{"alg": "none", "kid": "hmac"}, nil},
+		{"RS256 for HMAC key", map[string]any{"alg": RS256, "kid": "hmac"}, []byte(testSecret + "hmac")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := forge(t, tt.header, validClaims(), tt.secret)
+			if _, err := m.Parse(token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("err = %v, want %v", err, ErrInvalidToken)
+			}
+		})
+	}
+}
+
+func TestParseRejectsUnknownKey(t *testing.T) {
+	m := newManager(t, "hmac", hmacKey("hmac"))
+	for _, kid := range []any{"other", nil} {
+		header := map[string]any{"alg": HS256}
+		if kid != nil {
+			header["kid"] = kid
+		}
+		token := forge(t, header, validClaims(), []byte(testSecret+"hmac"))
+		if _, err := m.Parse(token); !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("kid %v: err = %v, want %v", kid, err, ErrUnknownKey)
+		}
+	}
+}
+
+func TestParseChecksTimesWithLeeway(t *testing.T) {
+	m := newManager(t, "hmac", hmacKey("hmac"))
+	now := time.Now()
+	tests := []struct {
+		name     string
+		exp, nbf time.Time
+		want     error
+	}{
+		{"valid", now.Add(time.Minute), now, nil},
+		{"expired within leeway", now.Add(-10 * time.Second), now.Add(-time.Minute), nil},
+		{"expired", now.Add(-time.Minute), now.Add(-2 * time.Minute), ErrExpired},
+		{"not yet valid within leeway", now.Add(time.Minute), now.Add(10 * time.Second), nil},
+		{"not yet valid", now.Add(2 * time.Minute), now.Add(time.Minute), ErrNotYetValid},
+		{"no expiry", time.Time{}, now, ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := Claims{RegisteredClaims: gojwt.RegisteredClaims{NotBefore: gojwt.NewNumericDate(tt.nbf)}, Type: TypeAccess}
+			if !tt.exp.IsZero() {
+				claims.ExpiresAt = gojwt.NewNumericDate(tt.exp)
+			}
+			token, err := m.Sign(claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := m.Parse(token); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseChecksIssuerAndAudience(t *testing.T) {
+	m := newManager(t, "hmac", hmacKey("hmac"))
+	for _, claim := range []string{"iss", "aud"} {
+		t.Run(claim, func(t *testing.T) {
+			claims := validClaims()
+			claims[claim] = "someone-else"
+			token := forge(t, map[string]any{"alg": HS256, "kid": "hmac"}, claims, []byte(testSecret+"hmac"))
+			if _, err := m.Parse(token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("err = %v, want %v", err, ErrInvalidToken)
+			}
+		})
+	}
+
+	token := forge(t, map[string]any{"alg": HS256, "kid": "hmac"}, validClaims(), []byte(testSecret+"hmac"))
+	if _, err := m.Parse(token); err != nil {
+		t.Errorf("forged valid token: err = %v, want nil", err)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	oldKey, newKey := hmacKey("old"), ed25519Key(t, "new")
+	before := newManager(t, "old", oldKey)
+	during := newManager(t, "new", oldKey, newKey)
+	after := newManager(t, "new", newKey)
+
+	oldToken, err := before.GenerateAccessToken(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newToken, err := during.GenerateAccessToken(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := during.ParseAccessToken(oldToken); err != nil {
+		t.Errorf("token of the old key during rotation: err = %v, want nil", err)
+	}
+	if _, err := after.ParseAccessToken(newToken); err != nil {
+		t.Errorf("token of the new key after rotation: err = %v, want nil", err)
+	}
+	if _, err := after.ParseAccessToken(oldToken); err == nil {
+		t.Error("token of the removed key was accepted")
+	}
+}
+
+func TestNewRejectsVerificationOnlySigningKey(t *testing.T) {
+	_, public := rsaKeys(t, "rsa")
+	_, err := New(config.JWT{Issuer: "fitness", Audience: "fitness-api", SigningKey: "rsa", Keys: []config.JWTKey{public}})
+	if err == nil {
+		t.Error("New accepted a signing key without a private key")
+	}
+}
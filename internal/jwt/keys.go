@@ -0,0 +1,197 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/config"
+)
+
+// Signing algorithms.
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	EdDSA = "EdDSA"
+)
+
+const (
+	minSecretLen = 32
+	minRSABits   = 2048
+)
+
+// Key is a signing or verification key.
+type Key struct {
+	ID        string
+	Algorithm string
+
+	secret  []byte
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// LoadKey reads the secret or PEM files cfg points to. RS256 and EdDSA keys
+// configured with only a public key can verify tokens but not sign them.
+func LoadKey(cfg config.JWTKey) (*Key, error) {
+	if cfg.ID == "" {
+		return nil, errors.New("missing id")
+	}
+	k := &Key{ID: cfg.ID, Algorithm: cfg.Algorithm}
+
+	switch cfg.Algorithm {
+	case HS256:
+		secret := cfg.Secret
+		if cfg.SecretEnv != "" {
+			secret = os.Getenv(cfg.SecretEnv)
+		}
+		if len(secret) < minSecretLen {
+			return nil, fmt.Errorf("secret must have at least %d bytes", minSecretLen)
+		}
+		k.secret = []byte(secret)
+		return k, nil
+	case RS256, EdDSA:
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", cfg.Algorithm)
+	}
+
+	if cfg.PrivateKeyFile != "" {
+		der, err := readPEM(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		private, err := x509.ParsePKCS8PrivateKey(der)
+		if err != nil {
+			if private, err = x509.ParsePKCS1PrivateKey(der); err != nil {
+				return nil, fmt.Errorf("parse private key: %w", err)
+			}
+		}
+		signer, ok := private.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("unsupported private key type")
+		}
+		k.private = signer
+		k.public = signer.Public()
+	}
+	if cfg.PublicKeyFile != "" {
+		der, err := readPEM(cfg.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if k.public, err = x509.ParsePKIXPublicKey(der); err != nil {
+			return nil, fmt.Errorf("parse public key: %w", err)
+		}
+	}
+
+	switch public := k.public.(type) {
+	case nil:
+		return nil, errors.New("missing private_key_file or public_key_file")
+	case ed25519.PublicKey:
+		if k.Algorithm != EdDSA {
+			return nil, errors.New("Ed25519 keys can only be used with EdDSA")
+		}
+	case *rsa.PublicKey:
+		if k.Algorithm != RS256 {
+			return nil, errors.New("RSA keys can only be used with RS256")
+		}
+		if public.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("RSA keys must have at least %d bits", minRSABits)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", public)
+	}
+	return k, nil
+}
+
+func readPEM(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data", path)
+	}
+	return block.Bytes, nil
+}
+
+// CanSign reports whether k has the secret or private key needed to sign.
+func (k *Key) CanSign() bool {
+	return k.secret != nil || k.private != nil
+}
+
+// method returns the signing method of the algorithm of k.
+func (k *Key) method() gojwt.SigningMethod {
+	switch k.Algorithm {
+	case HS256:
+		return gojwt.SigningMethodHS256
+	case RS256:
+		return gojwt.SigningMethodRS256
+	}
+	return gojwt.SigningMethodEdDSA
+}
+
+// signingKey returns the key the signing method of k signs with.
+func (k *Key) signingKey() any {
+	if k.secret != nil {
+		return k.secret
+	}
+	return k.private
+}
+
+// verificationKey returns the key the signing method of k verifies with.
+func (k *Key) verificationKey() any {
+	if k.secret != nil {
+		return k.secret
+	}
+	return k.public
+}
+
+// JWK is a public key in JSON Web Key format.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	// Crv and X describe Ed25519 keys.
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	// N and E describe RSA keys.
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys clients can verify tokens with. HS256 keys
+// are secret and never included.
+func (m *Manager) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, k := range m.keys {
+		jwk := JWK{Kid: k.ID, Alg: k.Algorithm, Use: "sig"}
+		switch public := k.public.(type) {
+		case ed25519.PublicKey:
+			jwk.Kty, jwk.Crv, jwk.X = "OKP", "Ed25519", encode(public)
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = encode(public.N.Bytes())
+			jwk.E = encode(big.NewInt(int64(public.E)).Bytes())
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
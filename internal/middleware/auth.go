@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/errors"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/jwt"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/problem"
+)
+
+type contextKey string
+
+const userIDKey contextKey = "user_id"
+
+// Auth rejects requests without a valid bearer access token and stores the
+// id of the authenticated user in the request context.
+func Auth(logger *slog.Logger, tokens *jwt.Manager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			tokenString, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok || tokenString == "" {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				problem.Write(w, r, errors.Unauthorized("missing bearer token"))
+				return
+			}
+
+			claims, err := tokens.ParseAccessToken(tokenString)
+			if err != nil {
+				logger.Debug("rejected access token", "error", err)
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				problem.Write(w, r, errors.Unauthorized("invalid or expired token"))
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), userIDKey, claims.UserID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// UserIDFromContext returns the id of the user authenticated by Auth.
+func UserIDFromContext(ctx context.Context) (int32, bool) {
+	userID, ok := ctx.Value(userIDKey).(int32)
+	return userID, ok
+}
@@ -0,0 +1,144 @@
+// Package pagination implements keyset pagination for listings. A listing
+// is ordered by a sort key and then by row id, which makes the order total,
+// and a page is the first Limit rows strictly after the cursor of the
+// previous one. The cursor holds the key and id of the last row handed out
+// rather than an offset, so rows added or removed before it do not shift
+// the pages that follow.
+//
+// Listings using the package keep to this contract:
+//
+//   - the key in a cursor is the value of the very column the query orders
+//     by, and the query compares (key, id) as a row value in the direction
+//     of the sort;
+//   - filters only narrow the rows and take no part in the order, so a
+//     filter on another column cannot make pages skip or repeat rows; the
+//     cursor does not record them and they must be repeated unchanged with
+//     every cursor, as the Link header written by SetLink does;
+//   - a row whose key changes while a client is paging, such as a workout
+//     edited while paging by update time, may be missed or seen twice.
+//
+// Cursors are opaque to clients but not signed, so they are parsed like any
+// other input.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor points just past the last item of a page. Key is the value of the
+// sort field of that item and ID breaks ties between equal keys. Sort records
+// the order the cursor was issued for, so it cannot be replayed against a
+// different one.
+type Cursor struct {
+	Sort string `json:"s"`
+	Key  string `json:"k"`
+	ID   int32  `json:"i"`
+}
+
+// Encode returns the opaque form of c handed to clients.
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Decode parses a cursor produced by Encode.
+func Decode(s string) (Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// Params describes the page a client asked for.
+type Params struct {
+	Limit int
+	// Sort is the field to order by and Desc its direction.
+	Sort string
+	Desc bool
+	// After is the cursor of the previous page, nil for the first page.
+	After *Cursor
+}
+
+// Parse reads the limit, sort and cursor query parameters. sort is one of
+// sorts, prefixed with "-" for descending order; when a cursor is given the
+// sort defaults to the one it was issued for.
+func Parse(r *http.Request, sorts []string, defaultSort string) (Params, error) {
+	p := Params{Limit: DefaultLimit}
+	if v := r.FormValue("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxLimit {
+			return Params{}, fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+		}
+		p.Limit = n
+	}
+
+	sort := r.FormValue("sort")
+	if v := r.FormValue("cursor"); v != "" {
+		c, err := Decode(v)
+		if err != nil {
+			return Params{}, err
+		}
+		if sort == "" {
+			sort = c.Sort
+		}
+		if c.Sort != sort {
+			return Params{}, errors.New("cursor was issued for a different sort")
+		}
+		p.After = &c
+	}
+	if sort == "" {
+		sort = defaultSort
+	}
+
+	p.Sort, p.Desc = strings.TrimPrefix(sort, "-"), strings.HasPrefix(sort, "-")
+	if !slices.Contains(sorts, p.Sort) {
+		return Params{}, fmt.Errorf("sort must be one of %s, optionally prefixed with -", strings.Join(sorts, ", "))
+	}
+	return p, nil
+}
+
+// SortParam returns the sort as written in the query string.
+func (p Params) SortParam() string {
+	if p.Desc {
+		return "-" + p.Sort
+	}
+	return p.Sort
+}
+
+// Next returns the cursor for the page following the item identified by
+// key and id. Callers fetch Limit+1 rows and only hand out a cursor when the
+// extra row shows that another page exists.
+func (p Params) Next(key string, id int32) string {
+	return Cursor{Sort: p.SortParam(), Key: key, ID: id}.Encode()
+}
+
+// SetLink adds an RFC 8288 Link header pointing to the next page.
+func SetLink(w http.ResponseWriter, r *http.Request, next string) {
+	if next == "" {
+		return
+	}
+	u := *r.URL
+	q := u.Query()
+	q.Set("cursor", next)
+	u.RawQuery = q.Encode()
+	w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="next"`, u.RequestURI()))
+}
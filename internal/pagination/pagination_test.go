@@ -0,0 +1,130 @@
+package pagination
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+var sorts = []string{"date", "name"}
+
+func parse(t *testing.T, query url.Values) (Params, error) {
+	t.Helper()
+	r := httptest.NewRequest("GET", "/workouts?"+query.Encode(), nil)
+	return Parse(r, sorts, "-date")
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	c := Cursor{Sort: "-date", Key: "2024-05-01, \"quoted\"", ID: 42}
+	got, err := Decode(c.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != c {
+		t.Errorf("Decode(Encode(%+v)) = %+v", c, got)
+	}
+}
+
+func TestDecodeRejectsGarbage(t *testing.T) {
+	for _, s := range []string{"not base64!", "bm90IGpzb24", Cursor{}.Encode() + "=="} {
+		if _, err := Decode(s); err != ErrInvalidCursor {
+			t.Errorf("Decode(%q) = %v, want %v", s, err, ErrInvalidCursor)
+		}
+	}
+}
+
+func TestParseDefaults(t *testing.T) {
+	p, err := parse(t, url.Values{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Limit != DefaultLimit || p.Sort != "date" || !p.Desc || p.After != nil {
+		t.Errorf("Parse = %+v", p)
+	}
+	if p.SortParam() != "-date" {
+		t.Errorf("SortParam = %q, want -date", p.SortParam())
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	for _, limit := range []string{"0", "-1", "101", "ten"} {
+		if _, err := parse(t, url.Values{"limit": {limit}}); err == nil {
+			t.Errorf("limit %s accepted", limit)
+		}
+	}
+	p, err := parse(t, url.Values{"limit": {"100"}})
+	if err != nil || p.Limit != 100 {
+		t.Errorf("limit 100: %+v, %v", p, err)
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	p, err := parse(t, url.Values{"sort": {"name"}})
+	if err != nil || p.Sort != "name" || p.Desc {
+		t.Errorf("sort name: %+v, %v", p, err)
+	}
+	if _, err := parse(t, url.Values{"sort": {"-id"}}); err == nil {
+		t.Error("unknown sort accepted")
+	}
+}
+
+func TestParseNextCursor(t *testing.T) {
+	first, err := parse(t, url.Values{"sort": {"name"}, "limit": {"5"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := first.Next("bench", 7)
+
+	// The cursor keeps the sort, whether or not the client repeats it.
+	for _, query := range []url.Values{
+		{"cursor": {next}},
+		{"cursor": {next}, "sort": {"name"}},
+	} {
+		p, err := parse(t, query)
+		if err != nil {
+			t.Fatalf("%v: %v", query, err)
+		}
+		want := Cursor{Sort: "name", Key: "bench", ID: 7}
+		if p.Sort != "name" || p.Desc || p.After == nil || *p.After != want {
+			t.Errorf("%v: Parse = %+v", query, p)
+		}
+	}
+}
+
+func TestParseRejectsCursorForOtherSort(t *testing.T) {
+	next := Params{Sort: "name"}.Next("bench", 7)
+	for _, sort := range []string{"-name", "date"} {
+		_, err := parse(t, url.Values{"cursor": {next}, "sort": {sort}})
+		if err == nil || !strings.Contains(err.Error(), "different sort") {
+			t.Errorf("sort %s: err = %v, want a sort mismatch", sort, err)
+		}
+	}
+}
+
+func TestParseRejectsInvalidCursor(t *testing.T) {
+	if _, err := parse(t, url.Values{"cursor": {"garbage!"}}); err != ErrInvalidCursor {
+		t.Errorf("err = %v, want %v", err, ErrInvalidCursor)
+	}
+	// A cursor for a sort that is not offered is rejected like the sort.
+	forged := Cursor{Sort: "password", Key: "a", ID: 1}.Encode()
+	if _, err := parse(t, url.Values{"cursor": {forged}}); err == nil {
+		t.Error("cursor for an unknown sort accepted")
+	}
+}
+
+func TestSetLink(t *testing.T) {
+	// Filters are kept, since the cursor does not record them.
+	r := httptest.NewRequest("GET", "/workouts?limit=5&from=2024-05-01&cursor=old", nil)
+	w := httptest.NewRecorder()
+	SetLink(w, r, "new")
+	if got, want := w.Header().Get("Link"), `</workouts?cursor=new&from=2024-05-01&limit=5>; rel="next"`; got != want {
+		t.Errorf("Link = %s, want %s", got, want)
+	}
+
+	w = httptest.NewRecorder()
+	SetLink(w, r, "")
+	if got := w.Header().Get("Link"); got != "" {
+		t.Errorf("Link without next page = %s", got)
+	}
+}
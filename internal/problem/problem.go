@@ -0,0 +1,71 @@
+// Package problem writes errors as RFC 9457 problem details.
+package problem
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/errors"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/requestid"
+)
+
+const ContentType = "application/problem+json"
+
+// Details is the body of an error response.
+type Details struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Detail    string              `json:"detail,omitempty"`
+	Instance  string              `json:"instance,omitempty"`
+	RequestID string              `json:"request_id,omitempty"`
+	Errors    []errors.FieldError `json:"errors,omitempty"`
+}
+
+// Status returns the HTTP status a domain error is reported with.
+func Status(err error) int {
+	switch {
+	case stderrors.Is(err, errors.ErrNotFound):
+		return http.StatusNotFound
+	case stderrors.Is(err, errors.ErrConflict):
+		return http.StatusConflict
+	case stderrors.Is(err, errors.ErrValidation):
+		return http.StatusBadRequest
+	case stderrors.Is(err, errors.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case stderrors.Is(err, errors.ErrForbidden):
+		return http.StatusForbidden
+	case stderrors.Is(err, errors.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case stderrors.Is(err, errors.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Write reports err to the client. Errors that are not domain errors are
+// reported as internal errors without exposing their message.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	e := errors.As(err)
+	status := Status(e)
+	if e.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds()))))
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&Details{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    e.Message,
+		Instance:  r.URL.Path,
+		RequestID: requestid.FromContext(r.Context()),
+		Errors:    e.Fields,
+	})
+}
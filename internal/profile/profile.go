@@ -0,0 +1,214 @@
+package profile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/models"
+)
+
+// CurrentVersion is the version of models.Profile. Documents without a
+// version were written when the profile was a free-form map and are treated
+// as version 1.
+const CurrentVersion = 2
+
+// ErrUnknownVersion is returned for documents of a version newer than
+// CurrentVersion, such as ones written by a newer release. Reading them as an
+// older version would drop the fields this one does not know, and the next
+// update would store the loss.
+var ErrUnknownVersion = errors.New("unknown profile version")
+
+const (
+	minHeightCm = 50
+	maxHeightCm = 300
+)
+
+var avatarImageID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+
+// migrations[v] upgrades a document of version v to version v+1.
+var migrations = map[int]func(doc map[string]any){
+	1: migrateV1,
+}
+
+// Decode reads a stored profile document, migrating it to CurrentVersion.
+// Documents that are not JSON objects are read as empty ones, and values
+// that do not pass Validate are dropped, so a bad stored value never makes
+// the profile unreadable and the user can always fix it with an update.
+func Decode(raw []byte) (models.Profile, error) {
+	var decoded any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		decoded = nil
+	}
+	doc, ok := decoded.(map[string]any)
+	if !ok {
+		doc = map[string]any{}
+	}
+
+	version, err := documentVersion(doc)
+	if err != nil {
+		return models.Profile{}, err
+	}
+	for ; version < CurrentVersion; version++ {
+		migrations[version](doc)
+	}
+	dropInvalid(doc)
+	doc["version"] = CurrentVersion
+
+	migrated, err := json.Marshal(doc)
+	if err != nil {
+		return models.Profile{}, err
+	}
+	var p models.Profile
+	if err := json.Unmarshal(migrated, &p); err != nil {
+		return models.Profile{}, err
+	}
+	return p, nil
+}
+
+// documentVersion returns the version of doc. Versions start at 2, so a
+// version key that is not a whole number from 2 up was one a client kept in
+// its free-form version 1 document.
+func documentVersion(doc map[string]any) (int, error) {
+	v, ok := doc["version"].(float64)
+	switch {
+	case ok && v > CurrentVersion:
+		return 0, fmt.Errorf("%w %v", ErrUnknownVersion, v)
+	case !ok || v < 2 || v != math.Trunc(v):
+		return 1, nil
+	}
+	return int(v), nil
+}
+
+// Merge applies a partial update to p: fields missing from patch are kept,
+// fields set to null are cleared and unknown fields are rejected.
+func Merge(p models.Profile, patch json.RawMessage) (models.Profile, error) {
+	if len(patch) == 0 {
+		return p, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(patch))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return models.Profile{}, fmt.Errorf("invalid profile: %w", err)
+	}
+	p.Version = CurrentVersion
+	return p, nil
+}
+
+// Validate checks every field of p that is set.
+func Validate(p models.Profile) error {
+	if p.BirthDate != nil {
+		d, err := time.Parse(time.DateOnly, *p.BirthDate)
+		if err != nil {
+			return errors.New("birth_date must be formatted as YYYY-MM-DD")
+		}
+		if d.Year() < 1900 || d.After(time.Now()) {
+			return errors.New("birth_date is out of range")
+		}
+	}
+	if p.Sex != nil && !slices.Contains(models.Sexes, *p.Sex) {
+		return fmt.Errorf("sex must be one of %v", models.Sexes)
+	}
+	if p.HeightCm != nil && (*p.HeightCm < minHeightCm || *p.HeightCm > maxHeightCm) {
+		return fmt.Errorf("height_cm must be between %d and %d", minHeightCm, maxHeightCm)
+	}
+	if p.Units != nil && !slices.Contains(models.UnitSystems, *p.Units) {
+		return fmt.Errorf("units must be one of %v", models.UnitSystems)
+	}
+	if p.Timezone != nil {
+		if _, err := time.LoadLocation(*p.Timezone); err != nil || *p.Timezone == "" || *p.Timezone == "Local" {
+			return errors.New("timezone must be an IANA time zone name")
+		}
+	}
+	if p.ExperienceLevel != nil && !slices.Contains(models.ExperienceLevels, *p.ExperienceLevel) {
+		return fmt.Errorf("experience_level must be one of %v", models.ExperienceLevels)
+	}
+	if len(p.Goals) > len(models.Goals) {
+		return errors.New("too many goals")
+	}
+	for i, g := range p.Goals {
+		if !slices.Contains(models.Goals, g) {
+			return fmt.Errorf("goals must be one of %v", models.Goals)
+		}
+		if slices.Contains(p.Goals[:i], g) {
+			return fmt.Errorf("duplicate goal %q", g)
+		}
+	}
+	if p.AvatarImageID != nil && !avatarImageID.MatchString(*p.AvatarImageID) {
+		return errors.New("invalid avatar_image_id")
+	}
+	return nil
+}
+
+// migrateV1 maps the keys clients used to store in the free-form profile onto
+// the typed fields and drops everything else.
+func migrateV1(doc map[string]any) {
+	aliases := map[string][]string{
+		"birth_date":       {"birth_date", "birthdate", "birthday", "dob"},
+		"sex":              {"sex", "gender"},
+		"height_cm":        {"height_cm", "height"},
+		"units":            {"units", "unit", "unit_system"},
+		"timezone":         {"timezone", "tz", "time_zone"},
+		"experience_level": {"experience_level", "experience", "level"},
+		"goals":            {"goals", "goal"},
+		"avatar_image_id":  {"avatar_image_id", "avatar_id", "avatar"},
+	}
+
+	migrated := map[string]any{}
+	for field, keys := range aliases {
+		for _, k := range keys {
+			if v, ok := doc[k]; ok && v != nil {
+				migrated[field] = v
+				break
+			}
+		}
+	}
+	if g, ok := migrated["goals"].(string); ok {
+		migrated["goals"] = []any{g}
+	}
+	for _, field := range []string{"sex", "units", "experience_level"} {
+		if s, ok := migrated[field].(string); ok {
+			migrated[field] = normalize(s)
+		}
+	}
+	if s, ok := migrated["goals"].([]any); ok {
+		for i, g := range s {
+			if gs, ok := g.(string); ok {
+				s[i] = normalize(gs)
+			}
+		}
+	}
+
+	for k := range doc {
+		delete(doc, k)
+	}
+	for field, v := range migrated {
+		doc[field] = v
+	}
+}
+
+// dropInvalid removes the fields of a document that would not pass Validate,
+// so that one bad value does not make the profile unusable.
+func dropInvalid(doc map[string]any) {
+	for field, v := range doc {
+		raw, err := json.Marshal(map[string]any{field: v})
+		if err != nil {
+			delete(doc, field)
+			continue
+		}
+		var p models.Profile
+		if json.Unmarshal(raw, &p) != nil || Validate(p) != nil {
+			delete(doc, field)
+		}
+	}
+}
+
+func normalize(s string) string {
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(s)))
+}
@@ -0,0 +1,157 @@
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/models"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want models.Profile
+	}{
+		{"empty", ``, models.Profile{Version: CurrentVersion}},
+		{"null", `null`, models.Profile{Version: CurrentVersion}},
+		{"not an object", `["metric"]`, models.Profile{Version: CurrentVersion}},
+		{"invalid JSON", `{"units":`, models.Profile{Version: CurrentVersion}},
+		{"current", `{"version":2,"units":"imperial","goals":["strength"],"height_cm":180}`, models.Profile{
+			Version:  CurrentVersion,
+			Units:    ptr("imperial"),
+			Goals:    []string{"strength"},
+			HeightCm: ptr(180.0),
+		}},
+		{"current with invalid value", `{"version":2,"units":"furlongs","sex":"female"}`, models.Profile{
+			Version: CurrentVersion,
+			Sex:     ptr("female"),
+		}},
+		{"v1 aliases", `{"dob":"1990-05-01","gender":"Female","height":175.5,"unit":"Imperial","tz":"Europe/Berlin","experience":"Intermediate","goal":"Weight Loss","avatar":"abc_123"}`, models.Profile{
+			Version:         CurrentVersion,
+			BirthDate:       ptr("1990-05-01"),
+			Sex:             ptr("female"),
+			HeightCm:        ptr(175.5),
+			Units:           ptr("imperial"),
+			Timezone:        ptr("Europe/Berlin"),
+			ExperienceLevel: ptr("intermediate"),
+			Goals:           []string{"weight_loss"},
+			AvatarImageID:   ptr("abc_123"),
+		}},
+		{"v1 typed keys win over aliases", `{"units":"metric","unit":"imperial"}`, models.Profile{
+			Version: CurrentVersion,
+			Units:   ptr("metric"),
+		}},
+		{"v1 drops unknown and invalid values", `{"favourite_colour":"blue","height":20,"birthday":"yesterday","goals":["strength","flying"],"tz":"Local"}`, models.Profile{
+			Version: CurrentVersion,
+		}},
+		{"v1 with a version key of its own", `{"version":"1.3","units":"metric"}`, models.Profile{
+			Version: CurrentVersion,
+			Units:   ptr("metric"),
+		}},
+		{"explicit version 1", `{"version":1,"unit":"metric"}`, models.Profile{
+			Version: CurrentVersion,
+			Units:   ptr("metric"),
+		}},
+		{"version 0", `{"version":0,"unit":"metric"}`, models.Profile{
+			Version: CurrentVersion,
+			Units:   ptr("metric"),
+		}},
+		{"negative version", `{"version":-4,"unit":"metric"}`, models.Profile{
+			Version: CurrentVersion,
+			Units:   ptr("metric"),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode([]byte(tt.raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(tt.want)
+				t.Errorf("Decode = %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsNewerVersions(t *testing.T) {
+	for _, raw := range []string{`{"version":3,"units":"metric"}`, `{"version":2.5}`, `{"version":1e9}`} {
+		if _, err := Decode([]byte(raw)); !errors.Is(err, ErrUnknownVersion) {
+			t.Errorf("Decode(%s) = %v, want %v", raw, err, ErrUnknownVersion)
+		}
+	}
+}
+
+func TestMigrationsCoverEveryVersion(t *testing.T) {
+	for v := 1; v < CurrentVersion; v++ {
+		if migrations[v] == nil {
+			t.Errorf("no migration from version %d", v)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	p := models.Profile{Version: CurrentVersion, Units: ptr("metric"), Sex: ptr("male")}
+
+	got, err := Merge(p, json.RawMessage(`{"units":"imperial","sex":null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := models.Profile{Version: CurrentVersion, Units: ptr("imperial")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge = %+v, want %+v", got, want)
+	}
+
+	if got, err := Merge(p, nil); err != nil || !reflect.DeepEqual(got, p) {
+		t.Errorf("Merge without patch = %+v, %v, want %+v", got, err, p)
+	}
+	if _, err := Merge(p, json.RawMessage(`{"favourite_colour":"blue"}`)); err == nil {
+		t.Error("Merge accepted an unknown field")
+	}
+	if got, err := Merge(p, json.RawMessage(`{"version":7}`)); err != nil || got.Version != CurrentVersion {
+		t.Errorf("Merge with a version = %+v, %v, want version %d", got, err, CurrentVersion)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		p     models.Profile
+		valid bool
+	}{
+		{"empty", models.Profile{}, true},
+		{"birth date", models.Profile{BirthDate: ptr("1990-05-01")}, true},
+		{"birth date format", models.Profile{BirthDate: ptr("01.05.1990")}, false},
+		{"birth date too early", models.Profile{BirthDate: ptr("1899-12-31")}, false},
+		{"birth date in future", models.Profile{BirthDate: ptr("2999-01-01")}, false},
+		{"sex", models.Profile{Sex: ptr("robot")}, false},
+		{"height", models.Profile{HeightCm: ptr(300.0)}, true},
+		{"height too small", models.Profile{HeightCm: ptr(49.9)}, false},
+		{"units", models.Profile{Units: ptr("metric")}, true},
+		{"units unknown", models.Profile{Units: ptr("stone")}, false},
+		{"timezone", models.Profile{Timezone: ptr("America/New_York")}, true},
+		{"timezone local", models.Profile{Timezone: ptr("Local")}, false},
+		{"timezone unknown", models.Profile{Timezone: ptr("Mars/Olympus")}, false},
+		{"experience", models.Profile{ExperienceLevel: ptr("expert")}, false},
+		{"goals", models.Profile{Goals: []string{"strength", "mobility"}}, true},
+		{"goals unknown", models.Profile{Goals: []string{"flying"}}, false},
+		{"goals duplicate", models.Profile{Goals: []string{"strength", "strength"}}, false},
+		{"avatar", models.Profile{AvatarImageID: ptr("../etc/passwd")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.p); (err == nil) != tt.valid {
+				t.Errorf("Validate = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package records
+
+import (
+	"math"
+	"slices"
+)
+
+const (
+	MaxWeight      = "max_weight"
+	RepsAtWeight   = "reps_at_weight"
+	EstimatedOneRM = "estimated_1rm"
+	SessionVolume  = "session_volume"
+	FastestTime    = "fastest_time"
+)
+
+// epsilon is the smallest difference that counts as an improvement, so that
+// float noise from unit conversions never produces a record.
+const epsilon = 1e-6
+
+// Epley estimates a one repetition maximum as weight * (1 + reps/30).
+func Epley(weight float64, reps int32) float64 {
+	if reps <= 1 {
+		return weight
+	}
+	return weight * (1 + float64(reps)/30)
+}
+
+// Brzycki estimates a one repetition maximum as weight * 36 / (37 - reps).
+// It is only meaningful for low repetition counts.
+func Brzycki(weight float64, reps int32) float64 {
+	if reps <= 1 {
+		return weight
+	}
+	if reps >= 37 {
+		return math.Inf(1)
+	}
+	return weight * 36 / (37 - float64(reps))
+}
+
+// EstimatedOneRepMax uses Brzycki up to ten repetitions, where it is the more
+// accurate of the two, and Epley above that.
+func EstimatedOneRepMax(weight float64, reps int32) float64 {
+	if reps <= 10 {
+		return Brzycki(weight, reps)
+	}
+	return Epley(weight, reps)
+}
+
+// LowerIsBetter reports whether a smaller value beats the current record.
+func LowerIsBetter(recordType string) bool {
+	return recordType == FastestTime
+}
+
+// Beats reports whether value improves on the current best of a record type.
+func Beats(recordType string, value, best float64) bool {
+	if LowerIsBetter(recordType) {
+		return value < best-epsilon
+	}
+	return value > best+epsilon
+}
+
+// Candidate is a value a single set could set a record for.
+type Candidate struct {
+	Type  string
+	Value float64
+}
+
+// SetCandidates returns the record candidates of a single set. Volume is per
+// session and computed separately.
+func SetCandidates(weight *float64, reps *int32, distance *float64, duration *int32) []Candidate {
+	var candidates []Candidate
+	if weight != nil && *weight > 0 && reps != nil && *reps > 0 {
+		candidates = append(candidates,
+			Candidate{Type: MaxWeight, Value: *weight},
+			Candidate{Type: RepsAtWeight, Value: float64(*reps)},
+			Candidate{Type: EstimatedOneRM, Value: EstimatedOneRepMax(*weight, *reps)},
+		)
+	}
+	if distance != nil && *distance > 0 && duration != nil && *duration > 0 {
+		candidates = append(candidates, Candidate{Type: FastestTime, Value: float64(*duration)})
+	}
+	return candidates
+}
+
+// Set holds the numbers of a logged set that records are set with.
+type Set struct {
+	Weight   *float64
+	Reps     *int32
+	Distance *float64
+	Duration *int32
+}
+
+// Best is the best candidate of a record type among a number of sets,
+// together with the index of the set it comes from.
+type Best struct {
+	Candidate
+	Set int
+}
+
+// BestOf returns the best candidate of every record type among sets. Reps at
+// a weight are kept per weight and fastest times per distance, as records
+// are. An earlier set wins a tie. Volume is per session and not included.
+func BestOf(sets []Set) []Best {
+	var best []Best
+	for i, s := range sets {
+		for _, c := range SetCandidates(s.Weight, s.Reps, s.Distance, s.Duration) {
+			j := slices.IndexFunc(best, func(b Best) bool {
+				return b.Type == c.Type && sameGroup(c.Type, sets[b.Set], s)
+			})
+			switch {
+			case j < 0:
+				best = append(best, Best{Candidate: c, Set: i})
+			case Beats(c.Type, c.Value, best[j].Value):
+				best[j] = Best{Candidate: c, Set: i}
+			}
+		}
+	}
+	return best
+}
+
+// sameGroup reports whether the records of a type that a and b set are
+// compared with each other.
+func sameGroup(recordType string, a, b Set) bool {
+	switch recordType {
+	case RepsAtWeight:
+		return math.Abs(*a.Weight-*b.Weight) < epsilon
+	case FastestTime:
+		return math.Abs(*a.Distance-*b.Distance) < epsilon
+	}
+	return true
+}
@@ -0,0 +1,126 @@
+package records
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestEstimatedOneRepMax(t *testing.T) {
+	tests := []struct {
+		name   string
+		weight float64
+		reps   int32
+		want   float64
+	}{
+		{"single", 100, 1, 100},
+		{"no reps", 100, 0, 100},
+		{"brzycki", 100, 5, 112.5},
+		{"brzycki at ten", 90, 10, 120},
+		{"epley above ten", 90, 12, 126},
+		{"epley at thirty", 50, 30, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EstimatedOneRepMax(tt.weight, tt.reps); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("EstimatedOneRepMax(%v, %d) = %v, want %v", tt.weight, tt.reps, got, tt.want)
+			}
+		})
+	}
+
+	if got := Brzycki(100, 37); !math.IsInf(got, 1) {
+		t.Errorf("Brzycki(100, 37) = %v, want +Inf", got)
+	}
+	// The switch between the formulas must not make more reps worth less.
+	for reps := int32(1); reps < 36; reps++ {
+		if EstimatedOneRepMax(100, reps+1) <= EstimatedOneRepMax(100, reps) {
+			t.Errorf("%d reps are not worth more than %d", reps+1, reps)
+		}
+	}
+}
+
+func TestBeats(t *testing.T) {
+	tests := []struct {
+		name       string
+		recordType string
+		value      float64
+		best       float64
+		want       bool
+	}{
+		{"higher weight", MaxWeight, 101, 100, true},
+		{"same weight", MaxWeight, 100, 100, false},
+		{"float noise", MaxWeight, 100 + 1e-9, 100, false},
+		{"lower weight", MaxWeight, 99, 100, false},
+		{"faster time", FastestTime, 299, 300, true},
+		{"same time", FastestTime, 300, 300, false},
+		{"slower time", FastestTime, 301, 300, false},
+		{"more volume", SessionVolume, 5001, 5000, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Beats(tt.recordType, tt.value, tt.best); got != tt.want {
+				t.Errorf("Beats(%s, %v, %v) = %v, want %v", tt.recordType, tt.value, tt.best, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetCandidates(t *testing.T) {
+	tests := []struct {
+		name string
+		set  Set
+		want []Candidate
+	}{
+		{"empty", Set{}, nil},
+		{"weight without reps", Set{Weight: ptr(100.0)}, nil},
+		{"zero weight", Set{Weight: ptr(0.0), Reps: ptr(int32(10))}, nil},
+		{"weight and reps", Set{Weight: ptr(100.0), Reps: ptr(int32(5))}, []Candidate{
+			{Type: MaxWeight, Value: 100},
+			{Type: RepsAtWeight, Value: 5},
+			{Type: EstimatedOneRM, Value: 112.5},
+		}},
+		{"distance without time", Set{Distance: ptr(5000.0)}, nil},
+		{"distance and time", Set{Distance: ptr(5000.0), Duration: ptr(int32(1500))}, []Candidate{
+			{Type: FastestTime, Value: 1500},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SetCandidates(tt.set.Weight, tt.set.Reps, tt.set.Distance, tt.set.Duration)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SetCandidates = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBestOf(t *testing.T) {
+	sets := []Set{
+		{Weight: ptr(100.0), Reps: ptr(int32(5))},
+		{Weight: ptr(100.0), Reps: ptr(int32(8))},
+		{Weight: ptr(110.0), Reps: ptr(int32(2))},
+		{Weight: ptr(100.0), Reps: ptr(int32(8))},
+		{Distance: ptr(5000.0), Duration: ptr(int32(1500))},
+		{Distance: ptr(5000.0), Duration: ptr(int32(1400))},
+		{Distance: ptr(10000.0), Duration: ptr(int32(3200))},
+		{},
+	}
+	want := []Best{
+		{Candidate{MaxWeight, 110}, 2},
+		{Candidate{RepsAtWeight, 8}, 1},
+		{Candidate{EstimatedOneRM, 100 * 36.0 / 29}, 1},
+		{Candidate{RepsAtWeight, 2}, 2},
+		{Candidate{FastestTime, 1400}, 5},
+		{Candidate{FastestTime, 3200}, 6},
+	}
+	if got := BestOf(sets); !reflect.DeepEqual(got, want) {
+		t.Errorf("BestOf = %+v, want %+v", got, want)
+	}
+	if got := BestOf(nil); got != nil {
+		t.Errorf("BestOf(nil) = %+v, want none", got)
+	}
+}
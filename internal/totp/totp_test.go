@@ -0,0 +1,174 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 seed of the RFC 6238 test vectors,
+// "12345678901234567890", in base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeRFC6238(t *testing.T) {
+	// The RFC lists 8 digit codes; 6 digit codes are their last 6 digits.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestCodeAcceptsLowerCaseSecret(t *testing.T) {
+	got, err := Code(strings.ToLower(rfcSecret), Step(time.Unix(59, 0)))
+	if err != nil || got != "287082" {
+		t.Errorf("Code = %q, %v, want 287082", got, err)
+	}
+}
+
+func TestCodeRejectsInvalidSecret(t *testing.T) {
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("Code accepted an invalid secret")
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := Step(now)
+	for offset := int64(-3); offset <= 3; offset++ {
+		code, err := Code(rfcSecret, step+offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := Validate(rfcSecret, code, now)
+		want := offset >= -Skew && offset <= Skew
+		if ok != want {
+			t.Errorf("offset %d: ok = %v, want %v", offset, ok, want)
+		}
+		if ok && got != step+offset {
+			t.Errorf("offset %d: step = %d, want %d", offset, got, step+offset)
+		}
+	}
+}
+
+func TestValidateReplay(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code, err := Code(rfcSecret, Step(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	last, ok := Validate(rfcSecret, code, now)
+	if !ok {
+		t.Fatal("first use rejected")
+	}
+
+	// The code stays valid within the skew, so callers detect the replay by
+	// the step it matched.
+	for _, later := range []time.Time{now, now.Add(Period)} {
+		step, ok := Validate(rfcSecret, code, later)
+		if !ok {
+			t.Fatalf("code rejected at %v", later)
+		}
+		if step > last {
+			t.Errorf("replay at %v matched step %d after the accepted %d", later, step, last)
+		}
+	}
+
+	next, err := Code(rfcSecret, Step(now)+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if step, ok := Validate(rfcSecret, next, now.Add(Period)); !ok || step <= last {
+		t.Errorf("next code: step = %d, ok = %v, want a step after %d", step, ok, last)
+	}
+}
+
+func TestValidateInput(t *testing.T) {
+	now := time.Unix(59, 0)
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"287082", true},
+		{"287 082", true},
+		{"287083", false},
+		{"28708", false},
+		{"2870820", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if _, ok := Validate(rfcSecret, tt.code, now); ok != tt.want {
+			t.Errorf("Validate(%q) = %v, want %v", tt.code, ok, tt.want)
+		}
+	}
+	if _, ok := Validate("not base32!", "287082", now); ok {
+		t.Error("Validate accepted a code for an invalid secret")
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secret) != 32 {
+		t.Errorf("len(secret) = %d, want 32", len(secret))
+	}
+	if _, err := Code(secret, 1); err != nil {
+		t.Errorf("generated secret is unusable: %v", err)
+	}
+}
+
+func TestURI(t *testing.T) {
+	u, err := url.Parse(URI("Fitness App", "ann@example.com", rfcSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Fitness App:ann@example.com" {
+		t.Errorf("URI = %s", u)
+	}
+	q := u.Query()
+	if q.Get("secret") != rfcSecret || q.Get("issuer") != "Fitness App" || q.Get("digits") != "6" || q.Get("period") != "30" {
+		t.Errorf("query = %v", q)
+	}
+	if strings.Contains(u.RawQuery, "+") {
+		t.Errorf("query %q encodes spaces as +", u.RawQuery)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("code %q is not of the form xxxxx-xxxxx", code)
+		}
+		n := NormalizeRecoveryCode(strings.ToUpper(code))
+		if len(n) != 10 || seen[n] {
+			t.Errorf("code %q normalizes to %q", code, n)
+		}
+		seen[n] = true
+	}
+	if got := NormalizeRecoveryCode(" AbCdE-fGhIj "); got != "abcdefghij" {
+		t.Errorf("NormalizeRecoveryCode = %q, want abcdefghij", got)
+	}
+}
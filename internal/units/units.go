@@ -0,0 +1,109 @@
+package units
+
+import "math"
+
+// Values are stored in SI based units: weights in kilograms, distances in
+// metres and body measurements in centimetres. A Converter translates them to
+// and from the unit system a user works in.
+const (
+	Metric   = "metric"
+	Imperial = "imperial"
+)
+
+const (
+	KilogramsPerPound  = 0.45359237
+	MetresPerMile      = 1609.344
+	CentimetresPerInch = 2.54
+)
+
+// imperialPlateStep is the smallest load that can be added to a barbell in a
+// pound gym, a pair of 2.5 lb plates.
+const imperialPlateStep = 5
+
+// Valid reports whether system is Metric or Imperial.
+func Valid(system string) bool {
+	return system == Metric || system == Imperial
+}
+
+// Converter converts values between SI units and System. The zero value
+// converts to metric.
+type Converter struct {
+	System string
+}
+
+func (c Converter) imperial() bool {
+	return c.System == Imperial
+}
+
+// Name returns the unit system values are presented in.
+func (c Converter) Name() string {
+	if c.imperial() {
+		return Imperial
+	}
+	return Metric
+}
+
+// ToKilograms converts a weight given in kg or lb.
+func (c Converter) ToKilograms(v float64) float64 {
+	if c.imperial() {
+		return v * KilogramsPerPound
+	}
+	return v
+}
+
+// ToMetres converts a distance given in m or mi.
+func (c Converter) ToMetres(v float64) float64 {
+	if c.imperial() {
+		return v * MetresPerMile
+	}
+	return v
+}
+
+// ToCentimetres converts a length given in cm or in.
+func (c Converter) ToCentimetres(v float64) float64 {
+	if c.imperial() {
+		return v * CentimetresPerInch
+	}
+	return v
+}
+
+// Weight presents a measured weight, e.g. a logged set or a weigh-in.
+func (c Converter) Weight(kg float64) float64 {
+	if c.imperial() {
+		return Round(kg/KilogramsPerPound, 0.1)
+	}
+	return kg
+}
+
+// Load presents a weight to be put on the bar, such as a template target.
+// Converted loads are rounded to the nearest plate increment: 60 kg becomes
+// 130 lb rather than 132.3 lb, which nobody can load.
+func (c Converter) Load(kg float64) float64 {
+	if c.imperial() {
+		return Round(kg/KilogramsPerPound, imperialPlateStep)
+	}
+	return kg
+}
+
+// Distance presents a distance in m or mi.
+func (c Converter) Distance(m float64) float64 {
+	if c.imperial() {
+		return Round(m/MetresPerMile, 0.01)
+	}
+	return m
+}
+
+// Length presents a body measurement in cm or in.
+func (c Converter) Length(cm float64) float64 {
+	if c.imperial() {
+		return Round(cm/CentimetresPerInch, 0.1)
+	}
+	return cm
+}
+
+// Round rounds v to the nearest multiple of step.
+func Round(v, step float64) float64 {
+	r := math.Round(v/step) * step
+	// Strip the binary noise multiplying by a fractional step leaves behind.
+	return math.Round(r*1e6) / 1e6
+}
@@ -0,0 +1,81 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestValid(t *testing.T) {
+	for system, want := range map[string]bool{Metric: true, Imperial: true, "": false, "Metric": false, "stone": false} {
+		if got := Valid(system); got != want {
+			t.Errorf("Valid(%q) = %v, want %v", system, got, want)
+		}
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		v, step, want float64
+	}{
+		{132.277, 5, 130},
+		{132.5, 5, 135},
+		{220.462, 0.1, 220.5},
+		{3.10686, 0.01, 3.11},
+		{0.3, 0.1, 0.3},
+		{-2.25, 0.5, -2.5},
+	}
+	for _, tt := range tests {
+		if got := Round(tt.v, tt.step); got != tt.want {
+			t.Errorf("Round(%v, %v) = %v, want %v", tt.v, tt.step, got, tt.want)
+		}
+	}
+}
+
+func TestConverter(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(Converter, float64) float64
+		in   float64
+		// metric and imperial are the results in either system.
+		metric, imperial float64
+	}{
+		{"ToKilograms", Converter.ToKilograms, 100, 100, 45.359237},
+		{"ToMetres", Converter.ToMetres, 3, 3, 4828.032},
+		{"ToCentimetres", Converter.ToCentimetres, 10, 10, 25.4},
+		{"Weight", Converter.Weight, 100, 100, 220.5},
+		{"Load", Converter.Load, 60, 60, 130},
+		{"Load of a plate step", Converter.Load, 2.2679, 2.2679, 5},
+		{"Distance", Converter.Distance, 5000, 5000, 3.11},
+		{"Length", Converter.Length, 100, 100, 39.4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fn(Converter{System: Metric}, tt.in); math.Abs(got-tt.metric) > 1e-9 {
+				t.Errorf("metric %s(%v) = %v, want %v", tt.name, tt.in, got, tt.metric)
+			}
+			if got := tt.fn(Converter{System: Imperial}, tt.in); math.Abs(got-tt.imperial) > 1e-9 {
+				t.Errorf("imperial %s(%v) = %v, want %v", tt.name, tt.in, got, tt.imperial)
+			}
+		})
+	}
+}
+
+func TestConverterDefaultsToMetric(t *testing.T) {
+	var c Converter
+	if c.Name() != Metric || c.Weight(100) != 100 {
+		t.Errorf("zero Converter = %s, %v, want metric", c.Name(), c.Weight(100))
+	}
+	if c := (Converter{System: "stone"}); c.Name() != Metric {
+		t.Errorf("Converter of an unknown system = %s, want metric", c.Name())
+	}
+}
+
+// A weight entered in pounds reads back as the same number of pounds.
+func TestConverterRoundTrip(t *testing.T) {
+	c := Converter{System: Imperial}
+	for _, lb := range []float64{0, 0.5, 45, 135.5, 225, 1000} {
+		if got := c.Weight(c.ToKilograms(lb)); got != lb {
+			t.Errorf("%v lb read back as %v", lb, got)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Package validate checks request models against the rules declared in their
+// validate struct tags, for example
+//
+//	Username string `json:"username" validate:"required,max=32"`
+//
+// Rules are separated by commas and apply to the pointee of pointer fields,
+// which are skipped when nil unless the rule is required, and to the value
+// of fields with an Unwrap method such as models.Optional. Supported rules:
+//
+//	required       the value must not be empty, zero or nil
+//	min=N, max=N   bounds the length of strings and slices, the value of numbers
+//	maxbytes=N     bounds the length of strings in bytes rather than characters,
+//	               for limits such as the 72 bytes bcrypt hashes
+//	email          a bare email address
+//	oneof=a b c    one of the space separated values
+//	date           a date in YYYY-MM-DD format
+//	maxfuture=D    a date or time no further than the duration D from now
+//	dive           validate each element of a slice of structs
+//
+// Violations are reported by their JSON names, with nested fields written as
+// sessions[0].day.
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/errors"
+)
+
+// Struct returns every rule v violates. v must be a struct or a pointer to
+// one.
+func Struct(v any) []errors.FieldError {
+	var fields []errors.FieldError
+	walk(reflect.Indirect(reflect.ValueOf(v)), "", &fields)
+	return fields
+}
+
+func walk(v reflect.Value, prefix string, fields *[]errors.FieldError) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("validate")
+		if tag == "" || !f.IsExported() {
+			continue
+		}
+		name := prefix + jsonName(f)
+		for _, rule := range strings.Split(tag, ",") {
+			msg, ok := check(v.Field(i), rule, name, fields)
+			if !ok {
+				*fields = append(*fields, errors.FieldError{Field: name, Message: msg})
+				break
+			}
+		}
+	}
+}
+
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// wrapper is implemented by field types that hold the value to validate.
+type wrapper interface {
+	Unwrap() any
+}
+
+// check applies one rule to v, reporting a message when it is violated.
+func check(v reflect.Value, rule, name string, fields *[]errors.FieldError) (string, bool) {
+	rule, arg, _ := strings.Cut(rule, "=")
+	if w, ok := v.Interface().(wrapper); ok {
+		v = reflect.ValueOf(w.Unwrap())
+	}
+	if rule == "required" {
+		if v.IsZero() || (v.Kind() == reflect.String && strings.TrimSpace(v.String()) == "") ||
+			(v.Kind() == reflect.Slice && v.Len() == 0) {
+			return "is required", false
+		}
+		return "", true
+	}
+
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", true
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.String && v.Len() == 0 {
+		return "", true
+	}
+
+	switch rule {
+	case "min", "max":
+		return bound(v, rule, arg)
+	case "maxbytes":
+		limit, err := strconv.Atoi(arg)
+		if err != nil {
+			panic("validate: invalid maxbytes bound " + arg)
+		}
+		if v.Kind() != reflect.String {
+			panic("validate: maxbytes does not apply to " + v.Kind().String())
+		}
+		if v.Len() > limit {
+			return "must be at most " + arg + " bytes long", false
+		}
+	case "email":
+		addr, err := mail.ParseAddress(v.String())
+		if err != nil || addr.Address != v.String() {
+			return "must be a valid email address", false
+		}
+	case "oneof":
+		allowed := strings.Fields(arg)
+		if !slices.Contains(allowed, fmt.Sprint(v.Interface())) {
+			return "must be one of " + strings.Join(allowed, ", "), false
+		}
+	case "date":
+		if _, err := time.Parse(time.DateOnly, v.String()); err != nil {
+			return "must be a date in YYYY-MM-DD format", false
+		}
+	case "maxfuture":
+		d, err := time.ParseDuration(arg)
+		if err != nil {
+			panic("validate: invalid maxfuture duration " + arg)
+		}
+		var t time.Time
+		switch x := v.Interface().(type) {
+		case time.Time:
+			t = x
+		case string:
+			if t, err = time.Parse(time.DateOnly, x); err != nil {
+				// Reported by the date rule.
+				return "", true
+			}
+		}
+		if t.After(time.Now().Add(d)) {
+			return "is too far in the future", false
+		}
+	case "dive":
+		for i := 0; i < v.Len(); i++ {
+			walk(reflect.Indirect(v.Index(i)), fmt.Sprintf("%s[%d].", name, i), fields)
+		}
+	default:
+		panic("validate: unknown rule " + rule)
+	}
+	return "", true
+}
+
+func bound(v reflect.Value, rule, arg string) (string, bool) {
+	limit, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		panic("validate: invalid " + rule + " bound " + arg)
+	}
+
+	var n float64
+	var unit string
+	switch v.Kind() {
+	case reflect.String:
+		n, unit = float64(utf8.RuneCountInString(v.String())), " characters"
+	case reflect.Slice:
+		n, unit = float64(v.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(v.Int())
+	case reflect.Float32, reflect.Float64:
+		n = v.Float()
+	default:
+		panic("validate: " + rule + " does not apply to " + v.Kind().String())
+	}
+
+	if rule == "min" && n < limit {
+		if unit != "" {
+			return "must have at least " + arg + unit, false
+		}
+		return "must be at least " + arg, false
+	}
+	if rule == "max" && n > limit {
+		if unit != "" {
+			return "must have at most " + arg + unit, false
+		}
+		return "must be at most " + arg, false
+	}
+	return "", true
+}
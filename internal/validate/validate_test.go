@@ -0,0 +1,158 @@
+package validate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Oyatillohgayratov/fitness-tracking-app/errors"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/models"
+)
+
+type session struct {
+	Day  string `json:"day" validate:"required,oneof=mon tue"`
+	Reps int    `json:"reps" validate:"min=1"`
+}
+
+type request struct {
+	Username  string                     `json:"username" validate:"required,min=3,max=5"`
+	Email     string                     `json:"email,omitempty" validate:"email"`
+	Weight    *float64                   `json:"weight" validate:"min=0,max=500"`
+	Unit      *string                    `json:"unit" validate:"oneof=kg lb"`
+	Level     int                        `json:"level" validate:"oneof=1 2 3"`
+	BirthDate string                     `json:"birth_date" validate:"date,maxfuture=0s"`
+	StartedAt *time.Time                 `json:"started_at" validate:"maxfuture=1h"`
+	Tags      []string                   `json:"tags" validate:"max=2"`
+	Sessions  []session                  `json:"sessions" validate:"dive"`
+	Note      string                     `validate:"max=3"`
+	Secret    string                     `json:"secret" validate:"max=4,maxbytes=4"`
+	EndedAt   models.Optional[time.Time] `json:"ended_at" validate:"maxfuture=1h"`
+	ignored   string                     `validate:"required"`
+}
+
+func valid() request {
+	return request{Username: "ann", Level: 1}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestStruct(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*request)
+		want   []errors.FieldError
+	}{
+		{"valid", func(*request) {}, nil},
+		{"required", func(r *request) { r.Username = "" }, []errors.FieldError{{Field: "username", Message: "is required"}}},
+		{"required blank", func(r *request) { r.Username = "   " }, []errors.FieldError{{Field: "username", Message: "is required"}}},
+		{"min length", func(r *request) { r.Username = "an" }, []errors.FieldError{{Field: "username", Message: "must have at least 3 characters"}}},
+		{"max length counts runes", func(r *request) { r.Username = "ännnn" }, nil},
+		{"max length", func(r *request) { r.Username = "annabel" }, []errors.FieldError{{Field: "username", Message: "must have at most 5 characters"}}},
+		{"email", func(r *request) { r.Email = "ann@example.com" }, nil},
+		{"email with name", func(r *request) { r.Email = "Ann <ann@example.com>" }, []errors.FieldError{{Field: "email", Message: "must be a valid email address"}}},
+		{"email invalid", func(r *request) { r.Email = "ann" }, []errors.FieldError{{Field: "email", Message: "must be a valid email address"}}},
+		{"number bounds", func(r *request) { r.Weight = ptr(500.0) }, nil},
+		{"number above max", func(r *request) { r.Weight = ptr(500.5) }, []errors.FieldError{{Field: "weight", Message: "must be at most 500"}}},
+		{"number below min", func(r *request) { r.Weight = ptr(-1.0) }, []errors.FieldError{{Field: "weight", Message: "must be at least 0"}}},
+		{"oneof pointer", func(r *request) { r.Unit = ptr("st") }, []errors.FieldError{{Field: "unit", Message: "must be one of kg, lb"}}},
+		{"oneof empty pointee", func(r *request) { r.Unit = ptr("") }, nil},
+		{"oneof number", func(r *request) { r.Level = 4 }, []errors.FieldError{{Field: "level", Message: "must be one of 1, 2, 3"}}},
+		{"date", func(r *request) { r.BirthDate = "1990-02-30" }, []errors.FieldError{{Field: "birth_date", Message: "must be a date in YYYY-MM-DD format"}}},
+		{"date in future", func(r *request) { r.BirthDate = time.Now().AddDate(1, 0, 0).Format(time.DateOnly) }, []errors.FieldError{{Field: "birth_date", Message: "is too far in the future"}}},
+		{"time within maxfuture", func(r *request) { r.StartedAt = ptr(time.Now().Add(30 * time.Minute)) }, nil},
+		{"time beyond maxfuture", func(r *request) { r.StartedAt = ptr(time.Now().Add(2 * time.Hour)) }, []errors.FieldError{{Field: "started_at", Message: "is too far in the future"}}},
+		{"slice length", func(r *request) { r.Tags = []string{"a", "b", "c"} }, []errors.FieldError{{Field: "tags", Message: "must have at most 2 items"}}},
+		{"dive", func(r *request) {
+			r.Sessions = []session{{Day: "mon", Reps: 1}, {Day: "sun", Reps: 0}}
+		}, []errors.FieldError{
+			{Field: "sessions[1].day", Message: "must be one of mon, tue"},
+			{Field: "sessions[1].reps", Message: "must be at least 1"},
+		}},
+		{"field without json name", func(r *request) { r.Note = "long" }, []errors.FieldError{{Field: "Note", Message: "must have at most 3 characters"}}},
+		{"maxbytes", func(r *request) { r.Secret = "abcd" }, nil},
+		{"maxbytes counts bytes", func(r *request) { r.Secret = "ééé" }, []errors.FieldError{{Field: "secret", Message: "must be at most 4 bytes long"}}},
+		{"optional left out", func(*request) {}, nil},
+		{"optional null", func(r *request) { r.EndedAt = models.Optional[time.Time]{Set: true} }, nil},
+		{"optional value", func(r *request) {
+			r.EndedAt = models.Optional[time.Time]{Set: true, Value: ptr(time.Now().Add(2 * time.Hour))}
+		}, []errors.FieldError{{Field: "ended_at", Message: "is too far in the future"}}},
+		{"unexported field", func(r *request) { r.ignored = "" }, nil},
+		{"every field reported once", func(r *request) {
+			r.Username = ""
+			r.Level = 0
+		}, []errors.FieldError{
+			{Field: "username", Message: "is required"},
+			{Field: "level", Message: "must be one of 1, 2, 3"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := valid()
+			tt.modify(&r)
+			if got := Struct(&r); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Struct = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructAcceptsValue(t *testing.T) {
+	if got := Struct(request{Level: 1}); len(got) != 1 || got[0].Field != "username" {
+		t.Errorf("Struct = %v, want a username error", got)
+	}
+}
+
+// Bad tags are programming errors and panic on the first request they see.
+func TestStructPanicsOnBadTags(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+	}{
+		{"unknown rule", &struct {
+			A string `validate:"uppercase"`
+		}{A: "a"}},
+		{"invalid bound", &struct {
+			A string `validate:"max=ten"`
+		}{A: "a"}},
+		{"bound on unsupported kind", &struct {
+			A bool `validate:"min=1"`
+		}{A: true}},
+		{"invalid maxbytes bound", &struct {
+			A string `validate:"maxbytes=many"`
+		}{A: "a"}},
+		{"maxbytes on unsupported kind", &struct {
+			A []string `validate:"maxbytes=1"`
+		}{A: []string{"a"}}},
+		{"invalid maxfuture duration", &struct {
+			A string `validate:"maxfuture=tomorrow"`
+		}{A: "2024-01-01"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Struct did not panic")
+				}
+			}()
+			Struct(tt.v)
+		})
+	}
+}
+
+// Passwords that pass validation must be short enough for bcrypt, which
+// refuses more than 72 bytes, however few characters they are.
+func TestPasswordsFitBcrypt(t *testing.T) {
+	for _, password := range []string{strings.Repeat("a", 72), strings.Repeat("é", 36), strings.Repeat("é", 37), strings.Repeat("💪", 19)} {
+		errs := Struct(&models.UserRegisterRequest{Username: "ann", Email: "ann@example.com", Password: password})
+		errs = append(errs, Struct(&models.PasswordResetSubmitRequest{Token: "t", NewPassword: password})...)
+		_, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		if fits := err == nil; fits != (len(errs) == 0) {
+			t.Errorf("%d byte password: validation errors %v, bcrypt error %v", len(password), errs, err)
+		}
+	}
+}
@@ -0,0 +1 @@
+drop TABLE if EXISTS refresh_tokens;
\ No newline at end of file
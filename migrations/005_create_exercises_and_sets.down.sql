@@ -0,0 +1,2 @@
+drop TABLE if EXISTS sets;
+drop TABLE if EXISTS exercises;
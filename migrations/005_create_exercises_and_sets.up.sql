@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS exercises (
+    id serial primary key,
+    workout_id integer not null,
+    name text not null,
+    notes text,
+    position integer not null,
+    create_at timestamptz not null default now(),
+    FOREIGN KEY (workout_id) REFERENCES workouts(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS exercises_workout_id_idx ON exercises (workout_id);
+
+CREATE TABLE IF NOT EXISTS sets (
+    id serial primary key,
+    exercise_id integer not null,
+    position integer not null,
+    repetitions integer,
+    weight double precision,
+    duration_seconds integer,
+    distance_meters double precision,
+    rpe double precision CHECK (rpe BETWEEN 1 AND 10),
+    rest_seconds integer,
+    create_at timestamptz not null default now(),
+    FOREIGN KEY (exercise_id) REFERENCES exercises(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS sets_exercise_id_idx ON sets (exercise_id);
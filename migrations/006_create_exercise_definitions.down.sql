@@ -0,0 +1,2 @@
+ALTER TABLE exercises DROP COLUMN IF EXISTS definition_id;
+drop TABLE if EXISTS exercise_definitions;
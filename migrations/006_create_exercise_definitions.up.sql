@@ -0,0 +1,81 @@
+CREATE TABLE IF NOT EXISTS exercise_definitions (
+    id serial primary key,
+    user_id integer,
+    name text not null,
+    aliases text[] not null default '{}',
+    primary_muscles text[] not null default '{}',
+    secondary_muscles text[] not null default '{}',
+    equipment text not null,
+    movement_pattern text not null,
+    unilateral boolean not null default false,
+    kind text not null CHECK (kind IN ('strength', 'cardio')),
+    create_at timestamptz not null default now(),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+-- Catalog entries (user_id is null) must be unique, custom exercises only per user.
+CREATE UNIQUE INDEX IF NOT EXISTS exercise_definitions_catalog_name_idx
+    ON exercise_definitions (lower(name)) WHERE user_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS exercise_definitions_user_name_idx
+    ON exercise_definitions (user_id, lower(name)) WHERE user_id IS NOT NULL;
+
+INSERT INTO exercise_definitions (name, aliases, primary_muscles, secondary_muscles, equipment, movement_pattern, unilateral, kind) VALUES
+    ('Barbell Bench Press', '{"bench","bench press","bp","flat bench"}', '{"chest"}', '{"triceps","shoulders"}', 'barbell', 'horizontal_push', false, 'strength'),
+    ('Incline Barbell Bench Press', '{"incline bench","incline press"}', '{"chest"}', '{"shoulders","triceps"}', 'barbell', 'horizontal_push', false, 'strength'),
+    ('Dumbbell Bench Press', '{"db bench","dumbbell press"}', '{"chest"}', '{"triceps","shoulders"}', 'dumbbell', 'horizontal_push', false, 'strength'),
+    ('Incline Dumbbell Press', '{"incline db press"}', '{"chest"}', '{"shoulders","triceps"}', 'dumbbell', 'horizontal_push', false, 'strength'),
+    ('Push-Up', '{"pushup","push up","press up"}', '{"chest"}', '{"triceps","shoulders","abs"}', 'bodyweight', 'horizontal_push', false, 'strength'),
+    ('Dip', '{"dips","parallel bar dip"}', '{"chest","triceps"}', '{"shoulders"}', 'bodyweight', 'vertical_push', false, 'strength'),
+    ('Cable Fly', '{"cable crossover","chest fly"}', '{"chest"}', '{"shoulders"}', 'cable', 'isolation', false, 'strength'),
+    ('Overhead Press', '{"ohp","military press","shoulder press","strict press"}', '{"shoulders"}', '{"triceps","traps"}', 'barbell', 'vertical_push', false, 'strength'),
+    ('Dumbbell Shoulder Press', '{"db shoulder press","seated dumbbell press"}', '{"shoulders"}', '{"triceps"}', 'dumbbell', 'vertical_push', false, 'strength'),
+    ('Lateral Raise', '{"side raise","lateral raises"}', '{"shoulders"}', '{}', 'dumbbell', 'isolation', false, 'strength'),
+    ('Face Pull', '{"face pulls"}', '{"shoulders"}', '{"traps","back"}', 'cable', 'horizontal_pull', false, 'strength'),
+    ('Back Squat', '{"squat","squats","high bar squat","low bar squat"}', '{"quadriceps","glutes"}', '{"hamstrings","lower_back","adductors"}', 'barbell', 'squat', false, 'strength'),
+    ('Front Squat', '{"front squats"}', '{"quadriceps"}', '{"glutes","abs"}', 'barbell', 'squat', false, 'strength'),
+    ('Goblet Squat', '{"goblet squats"}', '{"quadriceps","glutes"}', '{"abs"}', 'dumbbell', 'squat', false, 'strength'),
+    ('Leg Press', '{"leg press machine"}', '{"quadriceps","glutes"}', '{"hamstrings"}', 'machine', 'squat', false, 'strength'),
+    ('Bulgarian Split Squat', '{"bss","split squat","rear foot elevated split squat"}', '{"quadriceps","glutes"}', '{"hamstrings","adductors"}', 'dumbbell', 'lunge', true, 'strength'),
+    ('Walking Lunge', '{"lunges","lunge"}', '{"quadriceps","glutes"}', '{"hamstrings"}', 'dumbbell', 'lunge', true, 'strength'),
+    ('Leg Extension', '{"leg extensions","quad extension"}', '{"quadriceps"}', '{}', 'machine', 'isolation', false, 'strength'),
+    ('Deadlift', '{"dl","conventional deadlift","deadlifts"}', '{"hamstrings","glutes","lower_back"}', '{"back","traps","forearms","quadriceps"}', 'barbell', 'hinge', false, 'strength'),
+    ('Sumo Deadlift', '{"sumo","sumo dl"}', '{"glutes","hamstrings","adductors"}', '{"quadriceps","lower_back","traps"}', 'barbell', 'hinge', false, 'strength'),
+    ('Romanian Deadlift', '{"rdl","romanian dl","stiff leg deadlift"}', '{"hamstrings","glutes"}', '{"lower_back"}', 'barbell', 'hinge', false, 'strength'),
+    ('Hip Thrust', '{"barbell hip thrust","glute bridge"}', '{"glutes"}', '{"hamstrings"}', 'barbell', 'hinge', false, 'strength'),
+    ('Kettlebell Swing', '{"kb swing","swings"}', '{"glutes","hamstrings"}', '{"lower_back","shoulders"}', 'kettlebell', 'hinge', false, 'strength'),
+    ('Lying Leg Curl', '{"leg curl","hamstring curl"}', '{"hamstrings"}', '{"calves"}', 'machine', 'isolation', false, 'strength'),
+    ('Standing Calf Raise', '{"calf raise","calf raises"}', '{"calves"}', '{}', 'machine', 'isolation', false, 'strength'),
+    ('Pull-Up', '{"pullup","pull up","chin-up","chin up","chinup"}', '{"lats"}', '{"biceps","back","forearms"}', 'bodyweight', 'vertical_pull', false, 'strength'),
+    ('Lat Pulldown', '{"pulldown","lat pull down"}', '{"lats"}', '{"biceps","back"}', 'cable', 'vertical_pull', false, 'strength'),
+    ('Barbell Row', '{"bent over row","bb row","pendlay row"}', '{"back","lats"}', '{"biceps","lower_back","traps"}', 'barbell', 'horizontal_pull', false, 'strength'),
+    ('Dumbbell Row', '{"one arm row","db row","single arm row"}', '{"back","lats"}', '{"biceps"}', 'dumbbell', 'horizontal_pull', true, 'strength'),
+    ('Seated Cable Row', '{"cable row","seated row"}', '{"back","lats"}', '{"biceps"}', 'cable', 'horizontal_pull', false, 'strength'),
+    ('Barbell Shrug', '{"shrug","shrugs"}', '{"traps"}', '{"forearms"}', 'barbell', 'isolation', false, 'strength'),
+    ('Barbell Curl', '{"bicep curl","biceps curl","bb curl"}', '{"biceps"}', '{"forearms"}', 'barbell', 'isolation', false, 'strength'),
+    ('Dumbbell Curl', '{"db curl","alternating curl"}', '{"biceps"}', '{"forearms"}', 'dumbbell', 'isolation', true, 'strength'),
+    ('Hammer Curl', '{"hammer curls"}', '{"biceps","forearms"}', '{}', 'dumbbell', 'isolation', true, 'strength'),
+    ('Triceps Pushdown', '{"pushdown","tricep pushdown","rope pushdown"}', '{"triceps"}', '{}', 'cable', 'isolation', false, 'strength'),
+    ('Skull Crusher', '{"lying triceps extension","skullcrusher"}', '{"triceps"}', '{}', 'ez_bar', 'isolation', false, 'strength'),
+    ('Plank', '{"front plank"}', '{"abs"}', '{"obliques","shoulders"}', 'bodyweight', 'core', false, 'strength'),
+    ('Hanging Leg Raise', '{"leg raise","hanging knee raise"}', '{"abs"}', '{"obliques","forearms"}', 'bodyweight', 'core', false, 'strength'),
+    ('Cable Crunch', '{"kneeling cable crunch"}', '{"abs"}', '{"obliques"}', 'cable', 'core', false, 'strength'),
+    ('Farmer''s Carry', '{"farmers walk","farmer walk","loaded carry"}', '{"forearms","traps"}', '{"abs","glutes"}', 'dumbbell', 'carry', false, 'strength'),
+    ('Running', '{"run","jog","jogging","treadmill"}', '{"quadriceps","hamstrings","calves"}', '{"glutes"}', 'bodyweight', 'locomotion', false, 'cardio'),
+    ('Cycling', '{"bike","stationary bike","spin"}', '{"quadriceps"}', '{"hamstrings","calves","glutes"}', 'cardio_machine', 'locomotion', false, 'cardio'),
+    ('Rowing Machine', '{"row erg","erg","rower","indoor rowing"}', '{"back","quadriceps"}', '{"hamstrings","biceps","glutes"}', 'cardio_machine', 'locomotion', false, 'cardio'),
+    ('Swimming', '{"swim"}', '{"full_body"}', '{}', 'bodyweight', 'locomotion', false, 'cardio'),
+    ('Jump Rope', '{"skipping","skipping rope"}', '{"calves"}', '{"shoulders"}', 'other', 'locomotion', false, 'cardio'),
+    ('Elliptical', '{"cross trainer"}', '{"quadriceps","glutes"}', '{"hamstrings"}', 'cardio_machine', 'locomotion', false, 'cardio'),
+    ('Stair Climber', '{"stairmaster","stair machine"}', '{"quadriceps","glutes"}', '{"calves"}', 'cardio_machine', 'locomotion', false, 'cardio');
+
+ALTER TABLE exercises ADD COLUMN IF NOT EXISTS definition_id integer REFERENCES exercise_definitions(id);
+
+-- Link already logged exercises to the catalog where the free-text name matches.
+UPDATE exercises e
+SET definition_id = d.id
+FROM exercise_definitions d
+WHERE e.definition_id IS NULL
+  AND d.user_id IS NULL
+  AND (lower(d.name) = lower(trim(e.name))
+       OR lower(trim(e.name)) IN (SELECT lower(a) FROM unnest(d.aliases) a));
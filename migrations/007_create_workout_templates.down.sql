@@ -0,0 +1,8 @@
+ALTER TABLE sets
+    DROP COLUMN IF EXISTS target_reps_min,
+    DROP COLUMN IF EXISTS target_reps_max,
+    DROP COLUMN IF EXISTS target_weight_min,
+    DROP COLUMN IF EXISTS target_weight_max;
+ALTER TABLE workouts DROP COLUMN IF EXISTS template_id;
+drop TABLE if EXISTS template_exercises;
+drop TABLE if EXISTS workout_templates;
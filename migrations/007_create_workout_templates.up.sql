@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS workout_templates (
+    id serial primary key,
+    user_id integer not null,
+    name text not null,
+    description text,
+    create_at timestamptz not null default now(),
+    update_at timestamptz not null default now(),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS workout_templates_user_id_idx ON workout_templates (user_id);
+
+CREATE TABLE IF NOT EXISTS template_exercises (
+    id serial primary key,
+    template_id integer not null,
+    definition_id integer not null,
+    position integer not null,
+    notes text,
+    target_sets integer not null CHECK (target_sets > 0),
+    target_reps_min integer,
+    target_reps_max integer,
+    target_weight_min double precision,
+    target_weight_max double precision,
+    rest_seconds integer,
+    FOREIGN KEY (template_id) REFERENCES workout_templates(id)
+        ON DELETE CASCADE,
+    FOREIGN KEY (definition_id) REFERENCES exercise_definitions(id)
+);
+
+CREATE INDEX IF NOT EXISTS template_exercises_template_id_idx ON template_exercises (template_id);
+
+ALTER TABLE workouts ADD COLUMN IF NOT EXISTS template_id integer REFERENCES workout_templates(id) ON DELETE SET NULL;
+
+ALTER TABLE sets
+    ADD COLUMN IF NOT EXISTS target_reps_min integer,
+    ADD COLUMN IF NOT EXISTS target_reps_max integer,
+    ADD COLUMN IF NOT EXISTS target_weight_min double precision,
+    ADD COLUMN IF NOT EXISTS target_weight_max double precision;
@@ -0,0 +1,3 @@
+drop TABLE if EXISTS program_enrollments;
+drop TABLE if EXISTS program_sessions;
+drop TABLE if EXISTS programs;
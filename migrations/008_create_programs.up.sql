@@ -0,0 +1,45 @@
+CREATE TABLE IF NOT EXISTS programs (
+    id serial primary key,
+    user_id integer not null,
+    name text not null,
+    description text,
+    weeks integer not null CHECK (weeks > 0),
+    weekly_weight_increment double precision not null default 0,
+    deload_every_weeks integer CHECK (deload_every_weeks > 1),
+    deload_factor double precision not null default 0.6 CHECK (deload_factor > 0 AND deload_factor <= 1),
+    create_at timestamptz not null default now(),
+    update_at timestamptz not null default now(),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS programs_user_id_idx ON programs (user_id);
+
+-- A session without a week repeats every week of the program.
+CREATE TABLE IF NOT EXISTS program_sessions (
+    id serial primary key,
+    program_id integer not null,
+    week integer CHECK (week > 0),
+    day integer not null CHECK (day BETWEEN 1 AND 7),
+    template_id integer not null,
+    FOREIGN KEY (program_id) REFERENCES programs(id)
+        ON DELETE CASCADE,
+    FOREIGN KEY (template_id) REFERENCES workout_templates(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS program_sessions_program_id_idx ON program_sessions (program_id);
+
+CREATE TABLE IF NOT EXISTS program_enrollments (
+    id serial primary key,
+    user_id integer not null,
+    program_id integer not null,
+    start_date DATE not null,
+    create_at timestamptz not null default now(),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+        ON DELETE CASCADE,
+    FOREIGN KEY (program_id) REFERENCES programs(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS program_enrollments_user_id_idx ON program_enrollments (user_id);
@@ -0,0 +1 @@
+drop TABLE if EXISTS personal_records;
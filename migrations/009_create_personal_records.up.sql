@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS personal_records (
+    id serial primary key,
+    user_id integer not null,
+    definition_id integer not null,
+    record_type text not null CHECK (record_type IN ('max_weight', 'reps_at_weight', 'estimated_1rm', 'session_volume', 'fastest_time')),
+    value double precision not null,
+    previous_value double precision,
+    weight double precision,
+    repetitions integer,
+    distance_meters double precision,
+    duration_seconds integer,
+    workout_id integer,
+    set_id integer,
+    achieved_at timestamptz not null default now(),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+        ON DELETE CASCADE,
+    FOREIGN KEY (definition_id) REFERENCES exercise_definitions(id)
+        ON DELETE CASCADE,
+    FOREIGN KEY (workout_id) REFERENCES workouts(id)
+        ON DELETE SET NULL,
+    FOREIGN KEY (set_id) REFERENCES sets(id)
+        ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS personal_records_user_definition_idx ON personal_records (user_id, definition_id, achieved_at DESC);
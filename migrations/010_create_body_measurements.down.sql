@@ -0,0 +1 @@
+drop TABLE if EXISTS body_measurements;
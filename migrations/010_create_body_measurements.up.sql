@@ -0,0 +1,23 @@
+-- Body data is stored in kilograms and centimetres; every value is optional so
+-- a row can hold just a weigh-in or a full set of tape measurements.
+CREATE TABLE IF NOT EXISTS body_measurements (
+    id serial primary key,
+    user_id integer not null,
+    measured_on date not null,
+    weight_kg double precision CHECK (weight_kg > 0),
+    body_fat_percent double precision CHECK (body_fat_percent >= 0 AND body_fat_percent < 100),
+    neck_cm double precision CHECK (neck_cm > 0),
+    chest_cm double precision CHECK (chest_cm > 0),
+    waist_cm double precision CHECK (waist_cm > 0),
+    hips_cm double precision CHECK (hips_cm > 0),
+    arm_cm double precision CHECK (arm_cm > 0),
+    thigh_cm double precision CHECK (thigh_cm > 0),
+    calf_cm double precision CHECK (calf_cm > 0),
+    notes text,
+    create_at timestamptz not null default now(),
+    update_at timestamptz not null default now(),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS body_measurements_user_measured_on_idx ON body_measurements (user_id, measured_on);
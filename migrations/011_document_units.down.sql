@@ -0,0 +1,10 @@
+COMMENT ON COLUMN sets.weight IS NULL;
+COMMENT ON COLUMN sets.distance_meters IS NULL;
+COMMENT ON COLUMN sets.target_weight_min IS NULL;
+COMMENT ON COLUMN sets.target_weight_max IS NULL;
+COMMENT ON COLUMN template_exercises.target_weight_min IS NULL;
+COMMENT ON COLUMN template_exercises.target_weight_max IS NULL;
+COMMENT ON COLUMN programs.weekly_weight_increment IS NULL;
+COMMENT ON COLUMN personal_records.weight IS NULL;
+COMMENT ON COLUMN personal_records.distance_meters IS NULL;
+COMMENT ON COLUMN personal_records.value IS NULL;
@@ -0,0 +1,12 @@
+-- Every measurement is stored in SI based units and converted to the user's
+-- unit system at the API boundary.
+COMMENT ON COLUMN sets.weight IS 'kilograms';
+COMMENT ON COLUMN sets.distance_meters IS 'metres';
+COMMENT ON COLUMN sets.target_weight_min IS 'kilograms';
+COMMENT ON COLUMN sets.target_weight_max IS 'kilograms';
+COMMENT ON COLUMN template_exercises.target_weight_min IS 'kilograms';
+COMMENT ON COLUMN template_exercises.target_weight_max IS 'kilograms';
+COMMENT ON COLUMN programs.weekly_weight_increment IS 'kilograms';
+COMMENT ON COLUMN personal_records.weight IS 'kilograms';
+COMMENT ON COLUMN personal_records.distance_meters IS 'metres';
+COMMENT ON COLUMN personal_records.value IS 'kilograms for max_weight, estimated_1rm and session_volume (kg x reps), repetitions for reps_at_weight, seconds for fastest_time';
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS workouts_user_date_idx;
+ALTER TABLE workouts DROP CONSTRAINT IF EXISTS workouts_ended_after_started;
+ALTER TABLE workouts ALTER COLUMN date SET DEFAULT current_date;
+ALTER TABLE workouts DROP COLUMN IF EXISTS timezone;
+ALTER TABLE workouts DROP COLUMN IF EXISTS ended_at;
+ALTER TABLE workouts DROP COLUMN IF EXISTS started_at;
@@ -0,0 +1,23 @@
+-- A workout happens at an instant, but users think of it as belonging to a day
+-- of their own calendar. started_at and ended_at record the instants and
+-- timezone the IANA zone the user trained in; date is kept as the local
+-- calendar date of started_at so per day and per week queries stay simple.
+ALTER TABLE workouts ADD COLUMN IF NOT EXISTS started_at timestamptz;
+ALTER TABLE workouts ADD COLUMN IF NOT EXISTS ended_at timestamptz;
+ALTER TABLE workouts ADD COLUMN IF NOT EXISTS timezone text not null default 'UTC';
+
+-- Existing workouts only have a date; take the creation time when it falls on
+-- that date and midnight UTC otherwise.
+UPDATE workouts
+SET started_at = CASE
+    WHEN (create_at AT TIME ZONE 'UTC')::date = date THEN create_at
+    ELSE date::timestamp AT TIME ZONE 'UTC'
+END
+WHERE started_at IS NULL;
+
+ALTER TABLE workouts ALTER COLUMN started_at SET NOT NULL;
+ALTER TABLE workouts ALTER COLUMN started_at SET DEFAULT now();
+ALTER TABLE workouts ALTER COLUMN date DROP DEFAULT;
+ALTER TABLE workouts ADD CONSTRAINT workouts_ended_after_started CHECK (ended_at >= started_at);
+
+CREATE INDEX IF NOT EXISTS workouts_user_date_idx ON workouts (user_id, date);
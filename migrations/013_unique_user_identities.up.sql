@@ -0,0 +1,57 @@
+-- Usernames and emails identify accounts, so they must be unique regardless
+-- of case. Emails are stored normalized (trimmed and lower cased); usernames
+-- keep the case the user chose.
+UPDATE users SET email = lower(trim(email)) WHERE email <> lower(trim(email));
+
+-- Older versions allowed duplicates. The oldest account keeps the value and
+-- later ones get a suffixed placeholder; every change is recorded in
+-- user_identity_cleanup so the affected users can be contacted.
+CREATE TABLE IF NOT EXISTS user_identity_cleanup (
+    id serial primary key,
+    user_id int not null references users(id) on delete cascade,
+    field text not null check (field in ('username', 'email')),
+    old_value text not null,
+    new_value text not null,
+    cleaned_at timestamptz not null default now()
+);
+
+WITH duplicates AS (
+    SELECT id, username,
+           row_number() OVER (PARTITION BY lower(username) ORDER BY id) AS n
+    FROM users
+), renamed AS (
+    UPDATE users u
+    SET username = d.username || '_' || u.id
+    FROM duplicates d
+    WHERE d.id = u.id AND d.n > 1
+    RETURNING u.id, d.username AS old_value, u.username AS new_value
+)
+INSERT INTO user_identity_cleanup (user_id, field, old_value, new_value)
+SELECT id, 'username', old_value, new_value FROM renamed;
+
+WITH duplicates AS (
+    SELECT id, email,
+           row_number() OVER (PARTITION BY email ORDER BY id) AS n
+    FROM users
+), renamed AS (
+    UPDATE users u
+    SET email = 'duplicate+' || u.id || '@invalid'
+    FROM duplicates d
+    WHERE d.id = u.id AND d.n > 1
+    RETURNING u.id, d.email AS old_value, u.email AS new_value
+)
+INSERT INTO user_identity_cleanup (user_id, field, old_value, new_value)
+SELECT id, 'email', old_value, new_value FROM renamed;
+
+DO $$
+DECLARE
+    cleaned int;
+BEGIN
+    SELECT count(*) INTO cleaned FROM user_identity_cleanup;
+    IF cleaned > 0 THEN
+        RAISE NOTICE 'renamed % duplicate usernames or emails, see user_identity_cleanup', cleaned;
+    END IF;
+END $$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_username_lower_key ON users (lower(username));
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_lower_key ON users (lower(email));
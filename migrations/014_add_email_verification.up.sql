@@ -0,0 +1,19 @@
+-- Accounts start unverified until the owner follows the link sent to their
+-- email. Accounts created before verification existed are trusted.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at timestamptz;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS create_at timestamptz not null default now();
+UPDATE users SET email_verified_at = create_at WHERE email_verified_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS users_unverified_create_at_idx ON users (create_at) WHERE email_verified_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS email_verification_tokens (
+    id serial primary key,
+    user_id integer not null references users(id) on delete cascade,
+    email text not null,
+    token_hash text not null unique,
+    expires_at timestamptz not null,
+    used_at timestamptz,
+    create_at timestamptz not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS email_verification_tokens_user_id_idx ON email_verification_tokens (user_id, create_at);
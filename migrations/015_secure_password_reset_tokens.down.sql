@@ -0,0 +1,9 @@
+DELETE FROM password_reset_tokens;
+
+DROP INDEX IF EXISTS password_reset_tokens_user_id_idx;
+ALTER TABLE password_reset_tokens DROP COLUMN IF EXISTS create_at;
+ALTER TABLE password_reset_tokens DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE password_reset_tokens DROP COLUMN IF EXISTS token_hash;
+ALTER TABLE password_reset_tokens ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE password_reset_tokens ADD COLUMN token TEXT NOT NULL;
+ALTER TABLE password_reset_tokens ADD COLUMN expiration TIMESTAMP NOT NULL;
@@ -0,0 +1,13 @@
+-- Reset tokens used to be stored in plain text without a working expiry.
+-- They are now random tokens of which only the SHA-256 is stored, deleted
+-- once used. Outstanding tokens cannot be converted and are dropped.
+DELETE FROM password_reset_tokens;
+
+ALTER TABLE password_reset_tokens DROP COLUMN IF EXISTS token;
+ALTER TABLE password_reset_tokens DROP COLUMN IF EXISTS expiration;
+ALTER TABLE password_reset_tokens ALTER COLUMN user_id SET NOT NULL;
+ALTER TABLE password_reset_tokens ADD COLUMN token_hash text not null unique;
+ALTER TABLE password_reset_tokens ADD COLUMN expires_at timestamptz not null;
+ALTER TABLE password_reset_tokens ADD COLUMN create_at timestamptz not null default now();
+
+CREATE INDEX IF NOT EXISTS password_reset_tokens_user_id_idx ON password_reset_tokens (user_id);
@@ -0,0 +1,15 @@
+-- TOTP secrets are stored as entered into authenticator apps, since the
+-- server needs them to compute codes. totp_secret without totp_enabled_at is
+-- an enrollment that was not confirmed yet. totp_last_step is the time step
+-- of the last accepted code, which keeps a code from being used twice.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret text;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled_at timestamptz;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step bigint;
+
+CREATE TABLE IF NOT EXISTS totp_recovery_codes (
+    id serial primary key,
+    user_id integer not null references users(id) on delete cascade,
+    code_hash text not null,
+    create_at timestamptz not null default now(),
+    unique (user_id, code_hash)
+);
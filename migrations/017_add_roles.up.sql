@@ -0,0 +1,56 @@
+-- Every user has one role, and roles are granted permissions. Handlers only
+-- check permissions, so what a role may do can be changed here without code
+-- changes. There is no API to create the first admin; promote one with
+--   UPDATE users SET role = 'admin' WHERE email = '...';
+CREATE TABLE IF NOT EXISTS roles (
+    name text primary key,
+    description text not null default ''
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    name text primary key,
+    description text not null default ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role text not null references roles(name) on delete cascade,
+    permission text not null references permissions(name) on delete cascade,
+    primary key (role, permission)
+);
+
+INSERT INTO roles (name, description) VALUES
+    ('user', 'Tracks their own training'),
+    ('coach', 'Looks up the users they coach'),
+    ('admin', 'Manages accounts')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO permissions (name, description) VALUES
+    ('users:read', 'List and search users'),
+    ('users:suspend', 'Suspend and unsuspend users'),
+    ('users:reset_password', 'Force users to reset their password'),
+    ('users:manage_roles', 'Change the role of users')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('coach', 'users:read'),
+    ('admin', 'users:read'),
+    ('admin', 'users:suspend'),
+    ('admin', 'users:reset_password'),
+    ('admin', 'users:manage_roles')
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role text not null default 'user' references roles(name);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_at timestamptz;
+
+-- admin_actions records who did what to which account through the admin
+-- API. Rows outlive the users they mention.
+CREATE TABLE IF NOT EXISTS admin_actions (
+    id serial primary key,
+    actor_id integer references users(id) on delete set null,
+    target_id integer references users(id) on delete set null,
+    action text not null,
+    details jsonb,
+    create_at timestamptz not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS admin_actions_target_id_idx ON admin_actions (target_id, create_at);
@@ -0,0 +1,24 @@
+DELETE FROM role_permissions WHERE permission = 'audit:read';
+DELETE FROM permissions WHERE name = 'audit:read';
+
+CREATE TABLE IF NOT EXISTS admin_actions (
+    id serial primary key,
+    actor_id integer references users(id) on delete set null,
+    target_id integer references users(id) on delete set null,
+    action text not null,
+    details jsonb,
+    create_at timestamptz not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS admin_actions_target_id_idx ON admin_actions (target_id, create_at);
+
+INSERT INTO admin_actions (actor_id, target_id, action, details, create_at)
+SELECT e.actor_id, e.target_id, substr(e.action, length('admin.') + 1), e.details, e.create_at
+FROM audit_events e
+WHERE e.action LIKE 'admin.%'
+  AND (e.actor_id IS NULL OR EXISTS (SELECT 1 FROM users WHERE id = e.actor_id))
+  AND (e.target_id IS NULL OR EXISTS (SELECT 1 FROM users WHERE id = e.target_id))
+ORDER BY e.id;
+
+DROP TABLE IF EXISTS audit_events;
+DROP FUNCTION IF EXISTS audit_events_append_only();
@@ -0,0 +1,48 @@
+-- audit_events is an append-only record of security sensitive and data
+-- changing actions. Actor and target ids are kept without foreign keys so
+-- events survive the deletion of what they describe. Rows are only ever
+-- deleted by the retention job.
+CREATE TABLE IF NOT EXISTS audit_events (
+    id serial primary key,
+    actor_id integer,
+    action text not null,
+    target_type text,
+    target_id integer,
+    ip text,
+    user_agent text,
+    request_id text,
+    details jsonb,
+    create_at timestamptz not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_create_at_idx ON audit_events (create_at, id);
+CREATE INDEX IF NOT EXISTS audit_events_actor_id_idx ON audit_events (actor_id, create_at);
+CREATE INDEX IF NOT EXISTS audit_events_action_idx ON audit_events (action, create_at);
+CREATE INDEX IF NOT EXISTS audit_events_target_idx ON audit_events (target_type, target_id, create_at);
+
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events;
+CREATE TRIGGER audit_events_append_only
+    BEFORE UPDATE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+-- Admin actions are audit events now.
+INSERT INTO audit_events (actor_id, action, target_type, target_id, details, create_at)
+SELECT actor_id, 'admin.' || action, 'user', target_id, details, create_at
+FROM admin_actions
+ORDER BY id;
+
+DROP TABLE IF EXISTS admin_actions;
+
+INSERT INTO permissions (name, description) VALUES
+    ('audit:read', 'Query the audit log')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'audit:read')
+ON CONFLICT DO NOTHING;
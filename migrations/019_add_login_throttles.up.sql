@@ -0,0 +1,12 @@
+-- login_throttles holds the failed login and password reset counters when
+-- brute-force protection is configured with the postgres store, so that all
+-- instances see the same counts. Keys name what is counted, such as
+-- login:account:<email> or login:address:<ip>.
+CREATE TABLE IF NOT EXISTS login_throttles (
+    key text primary key,
+    failures integer not null default 0,
+    last_failure timestamptz not null,
+    locked_until timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS login_throttles_last_failure_idx ON login_throttles (last_failure);
@@ -0,0 +1,4 @@
+-- Profiles used to be upgraded here. The app upgrades them as it reads them
+-- (see internal/profile), which keeps the migrations of each version in one
+-- place, so there is nothing left to do.
+SELECT 1;
@@ -0,0 +1,4 @@
+INSERT INTO role_permissions (role, permission) VALUES ('coach', 'users:read')
+ON CONFLICT DO NOTHING;
+
+UPDATE roles SET description = 'Looks up the users they coach' WHERE name = 'coach';
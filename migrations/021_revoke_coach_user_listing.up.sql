@@ -0,0 +1,6 @@
+-- Coaches could list and search every account. They get no permissions
+-- until there is a relationship between coaches and the users they coach
+-- to scope them by.
+DELETE FROM role_permissions WHERE role = 'coach' AND permission = 'users:read';
+
+UPDATE roles SET description = 'Coaches other users' WHERE name = 'coach';
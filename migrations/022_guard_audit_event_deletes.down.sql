@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS audit_events_no_truncate ON audit_events;
+DROP TRIGGER IF EXISTS audit_events_retention_only ON audit_events;
+DROP FUNCTION IF EXISTS audit_events_retention_only();
+DROP TABLE IF EXISTS audit_settings;
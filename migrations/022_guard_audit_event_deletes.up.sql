@@ -0,0 +1,29 @@
+-- audit_events rejected updates but not deletes, so the log was only
+-- append-only by convention. Deletes are now limited to events older than
+-- the retention period, which the app stores here from its configuration
+-- when it starts, and the table cannot be truncated.
+CREATE TABLE IF NOT EXISTS audit_settings (
+    id boolean primary key default true check (id),
+    retention interval not null default interval '365 days' check (retention > interval '0')
+);
+
+INSERT INTO audit_settings DEFAULT VALUES ON CONFLICT DO NOTHING;
+
+CREATE OR REPLACE FUNCTION audit_events_retention_only() RETURNS trigger AS $$
+BEGIN
+    IF OLD.create_at >= now() - (SELECT retention FROM audit_settings) THEN
+        RAISE EXCEPTION 'audit event % is within the retention period', OLD.id;
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_events_retention_only ON audit_events;
+CREATE TRIGGER audit_events_retention_only
+    BEFORE DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_retention_only();
+
+DROP TRIGGER IF EXISTS audit_events_no_truncate ON audit_events;
+CREATE TRIGGER audit_events_no_truncate
+    BEFORE TRUNCATE ON audit_events
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
@@ -0,0 +1,3 @@
+-- The shortened names are recorded in user_identity_cleanup; they are not
+-- lengthened again.
+SELECT 1;
@@ -0,0 +1,37 @@
+-- Migration 013 renamed duplicate usernames to <name>_<id>, which can be
+-- longer than the 32 characters usernames may have. Shorten those names,
+-- keeping the id suffix and adding a counter when the result is taken.
+-- Renamed names that collided with existing ones made 013 fail at its unique
+-- index, so they cannot exist. Every rename is recorded in
+-- user_identity_cleanup like the ones 013 made.
+DO $$
+DECLARE
+    renamed record;
+    suffix text;
+    candidate text;
+    attempt int;
+BEGIN
+    FOR renamed IN
+        SELECT u.id, u.username, c.old_value
+        FROM users u
+        JOIN user_identity_cleanup c
+          ON c.user_id = u.id AND c.field = 'username' AND c.new_value = u.username
+        WHERE length(u.username) > 32
+        ORDER BY u.id
+    LOOP
+        attempt := 1;
+        LOOP
+            suffix := '_' || renamed.id;
+            IF attempt > 1 THEN
+                suffix := suffix || '_' || attempt;
+            END IF;
+            candidate := left(renamed.old_value, 32 - length(suffix)) || suffix;
+            EXIT WHEN NOT EXISTS (SELECT 1 FROM users WHERE lower(username) = lower(candidate));
+            attempt := attempt + 1;
+        END LOOP;
+
+        UPDATE users SET username = candidate WHERE id = renamed.id;
+        INSERT INTO user_identity_cleanup (user_id, field, old_value, new_value)
+        VALUES (renamed.id, 'username', renamed.username, candidate);
+    END LOOP;
+END $$;
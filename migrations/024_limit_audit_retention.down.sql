@@ -0,0 +1,10 @@
+CREATE OR REPLACE FUNCTION audit_events_retention_only() RETURNS trigger AS $$
+BEGIN
+    IF OLD.create_at >= now() - (SELECT retention FROM audit_settings) THEN
+        RAISE EXCEPTION 'audit event % is within the retention period', OLD.id;
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE audit_settings DROP CONSTRAINT IF EXISTS audit_settings_retention_minimum;
@@ -0,0 +1,18 @@
+-- The retention only had to be positive, so a misconfigured app could let
+-- itself delete audit events a second after writing them. It now has to be
+-- at least 90 days, the same minimum the app checks its configuration
+-- against, and the trigger applies the minimum should the check be dropped.
+UPDATE audit_settings SET retention = interval '90 days' WHERE retention < interval '90 days';
+
+ALTER TABLE audit_settings DROP CONSTRAINT IF EXISTS audit_settings_retention_minimum;
+ALTER TABLE audit_settings ADD CONSTRAINT audit_settings_retention_minimum
+    CHECK (retention >= interval '90 days');
+
+CREATE OR REPLACE FUNCTION audit_events_retention_only() RETURNS trigger AS $$
+BEGIN
+    IF OLD.create_at >= now() - greatest((SELECT retention FROM audit_settings), interval '90 days') THEN
+        RAISE EXCEPTION 'audit event % is within the retention period', OLD.id;
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
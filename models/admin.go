@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AdminUserResponse describes an account to administrators.
+type AdminUserResponse struct {
+	ID            int32      `json:"id"`
+	Username      string     `json:"username"`
+	Email         string     `json:"email"`
+	Role          string     `json:"role"`
+	EmailVerified bool       `json:"email_verified"`
+	SuspendedAt   *time.Time `json:"suspended_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type SuspendUserRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+type UpdateRoleRequest struct {
+	Role string `json:"role" validate:"required,max=32"`
+}
+
+type AuditEventResponse struct {
+	ID         int32           `json:"id"`
+	ActorID    *int32          `json:"actor_id,omitempty"`
+	Action     string          `json:"action"`
+	TargetType *string         `json:"target_type,omitempty"`
+	TargetID   *int32          `json:"target_id,omitempty"`
+	IP         *string         `json:"ip,omitempty"`
+	UserAgent  *string         `json:"user_agent,omitempty"`
+	RequestID  *string         `json:"request_id,omitempty"`
+	Details    json.RawMessage `json:"details,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
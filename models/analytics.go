@@ -0,0 +1,66 @@
+package models
+
+// Trend is a least squares line through a series; Slope is the change per
+// bucket.
+type Trend struct {
+	Slope     float64 `json:"slope"`
+	Intercept float64 `json:"intercept"`
+}
+
+type VolumePoint struct {
+	BucketStart string  `json:"bucket_start"`
+	Tonnage     float64 `json:"tonnage"`
+	Sets        int32   `json:"sets"`
+}
+
+type VolumeSeries struct {
+	Key    string        `json:"key"`
+	Name   string        `json:"name"`
+	Points []VolumePoint `json:"points"`
+	Trend  Trend         `json:"trend"`
+}
+
+type VolumeResponse struct {
+	From    string         `json:"from"`
+	To      string         `json:"to"`
+	Bucket  string         `json:"bucket"`
+	GroupBy string         `json:"group_by"`
+	Unit    string         `json:"unit"`
+	Series  []VolumeSeries `json:"series"`
+}
+
+type FrequencyPoint struct {
+	BucketStart        string  `json:"bucket_start"`
+	Sessions           int32   `json:"sessions"`
+	AvgDurationSeconds float64 `json:"avg_duration_seconds"`
+}
+
+type FrequencyResponse struct {
+	From               string  `json:"from"`
+	To                 string  `json:"to"`
+	Bucket             string  `json:"bucket"`
+	TotalSessions      int32   `json:"total_sessions"`
+	SessionsPerWeek    float64 `json:"sessions_per_week"`
+	AvgDurationSeconds float64 `json:"avg_duration_seconds"`
+	// CurrentStreak and LongestStreak count consecutive buckets with at
+	// least one session.
+	CurrentStreak int              `json:"current_streak"`
+	LongestStreak int              `json:"longest_streak"`
+	Points        []FrequencyPoint `json:"points"`
+	Trend         Trend            `json:"trend"`
+}
+
+type MuscleBalance struct {
+	Muscle         string  `json:"muscle"`
+	Sets           float64 `json:"sets"`
+	WeeklySets     float64 `json:"weekly_sets"`
+	RecommendedMin float64 `json:"recommended_min"`
+	RecommendedMax float64 `json:"recommended_max"`
+	Status         string  `json:"status"`
+}
+
+type MuscleBalanceResponse struct {
+	From    string          `json:"from"`
+	To      string          `json:"to"`
+	Muscles []MuscleBalance `json:"muscles"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// BodyMeasurementRequest records measurements taken on one day. Weight is in
+// kg or lb and circumferences in cm or in, depending on Unit, which defaults
+// to the caller's preference.
+type BodyMeasurementRequest struct {
+	Unit           *string  `json:"unit,omitempty" validate:"oneof=metric imperial"`
+	MeasuredOn     string   `json:"measured_on" validate:"required,date,maxfuture=24h"`
+	Weight         *float64 `json:"weight,omitempty"`
+	BodyFatPercent *float64 `json:"body_fat_percent,omitempty"`
+	Neck           *float64 `json:"neck,omitempty"`
+	Chest          *float64 `json:"chest,omitempty"`
+	Waist          *float64 `json:"waist,omitempty"`
+	Hips           *float64 `json:"hips,omitempty"`
+	Arm            *float64 `json:"arm,omitempty"`
+	Thigh          *float64 `json:"thigh,omitempty"`
+	Calf           *float64 `json:"calf,omitempty"`
+	Notes          *string  `json:"notes,omitempty" validate:"max=1000"`
+}
+
+type BodyMeasurementResponse struct {
+	ID             int32    `json:"id"`
+	MeasuredOn     string   `json:"measured_on"`
+	Weight         *float64 `json:"weight,omitempty"`
+	BodyFatPercent *float64 `json:"body_fat_percent,omitempty"`
+	Neck           *float64 `json:"neck,omitempty"`
+	Chest          *float64 `json:"chest,omitempty"`
+	Waist          *float64 `json:"waist,omitempty"`
+	Hips           *float64 `json:"hips,omitempty"`
+	Arm            *float64 `json:"arm,omitempty"`
+	Thigh          *float64 `json:"thigh,omitempty"`
+	Calf           *float64 `json:"calf,omitempty"`
+	Notes          *string  `json:"notes,omitempty"`
+	Unit           string   `json:"unit"`
+	// WeightAverage is the trailing moving average of the weight, only set
+	// when listing measurements.
+	WeightAverage  *float64  `json:"weight_average,omitempty"`
+	BMI            *float64  `json:"bmi,omitempty"`
+	FFMI           *float64  `json:"ffmi,omitempty"`
+	NormalizedFFMI *float64  `json:"normalized_ffmi,omitempty"`
+	CreateAt       time.Time `json:"created_at"`
+	UpdateAt       time.Time `json:"updated_at"`
+}
+
+type BodyMeasurementListResponse struct {
+	From          string                    `json:"from"`
+	To            string                    `json:"to"`
+	AverageWindow int                       `json:"average_window"`
+	Unit          string                    `json:"unit"`
+	Height        *float64                  `json:"height,omitempty"`
+	Measurements  []BodyMeasurementResponse `json:"measurements"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+var Muscles = []string{
+	"chest", "back", "lats", "traps", "shoulders", "biceps", "triceps", "forearms",
+	"abs", "obliques", "lower_back", "glutes", "quadriceps", "hamstrings", "calves",
+	"adductors", "abductors", "full_body",
+}
+
+var Equipment = []string{
+	"barbell", "dumbbell", "kettlebell", "machine", "cable", "bodyweight", "band",
+	"smith_machine", "ez_bar", "cardio_machine", "other",
+}
+
+var MovementPatterns = []string{
+	"horizontal_push", "horizontal_pull", "vertical_push", "vertical_pull", "squat",
+	"hinge", "lunge", "carry", "rotation", "isolation", "core", "locomotion", "other",
+}
+
+var ExerciseKinds = []string{"strength", "cardio"}
+
+type ExerciseDefinitionCreateRequest struct {
+	Name             string   `json:"name" validate:"required,max=100"`
+	Aliases          []string `json:"aliases" validate:"max=20"`
+	PrimaryMuscles   []string `json:"primary_muscles" validate:"required,max=10"`
+	SecondaryMuscles []string `json:"secondary_muscles" validate:"max=10"`
+	Equipment        string   `json:"equipment" validate:"required"`
+	MovementPattern  string   `json:"movement_pattern" validate:"required"`
+	Unilateral       bool     `json:"unilateral"`
+	Kind             string   `json:"kind" validate:"required"`
+}
+
+type ExerciseDefinitionResponse struct {
+	ID               int32     `json:"id"`
+	Name             string    `json:"name"`
+	Aliases          []string  `json:"aliases"`
+	PrimaryMuscles   []string  `json:"primary_muscles"`
+	SecondaryMuscles []string  `json:"secondary_muscles"`
+	Equipment        string    `json:"equipment"`
+	MovementPattern  string    `json:"movement_pattern"`
+	Unilateral       bool      `json:"unilateral"`
+	Kind             string    `json:"kind"`
+	Custom           bool      `json:"custom"`
+	CreateAt         time.Time `json:"created_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "encoding/json"
+
+// Optional is a field of a partial update that tells a field left out of the
+// request, which keeps its value, from an explicit null, which clears it.
+type Optional[T any] struct {
+	// Set is true when the field was in the request.
+	Set   bool
+	Value *T
+}
+
+// UnmarshalJSON is only called for fields in the request, null included.
+func (o *Optional[T]) UnmarshalJSON(b []byte) error {
+	o.Set = true
+	o.Value = nil
+	return json.Unmarshal(b, &o.Value)
+}
+
+// Unwrap returns the value for validation.
+func (o Optional[T]) Unwrap() any {
+	return o.Value
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestOptional(t *testing.T) {
+	tests := []struct {
+		raw   string
+		set   bool
+		value *string
+	}{
+		{`{}`, false, nil},
+		{`{"description":null}`, true, nil},
+		{`{"description":""}`, true, ptr("")},
+		{`{"description":"legs"}`, true, ptr("legs")},
+	}
+	for _, tt := range tests {
+		var req struct {
+			Description Optional[string] `json:"description"`
+		}
+		if err := json.Unmarshal([]byte(tt.raw), &req); err != nil {
+			t.Fatalf("%s: %v", tt.raw, err)
+		}
+		got := req.Description
+		if got.Set != tt.set || (got.Value == nil) != (tt.value == nil) || (got.Value != nil && *got.Value != *tt.value) {
+			t.Errorf("%s: Optional = %+v, want set %v and value %v", tt.raw, got, tt.set, tt.value)
+		}
+	}
+
+	var req struct {
+		EndedAt Optional[int] `json:"ended_at"`
+	}
+	if err := json.Unmarshal([]byte(`{"ended_at":"soon"}`), &req); err == nil {
+		t.Error("Optional accepted a value of the wrong type")
+	}
+}
@@ -0,0 +1,8 @@
+package models
+
+// Page is one page of a cursor paginated listing. NextCursor is omitted on
+// the last page.
+type Page[T any] struct {
+	Items      []T     `json:"items"`
+	NextCursor *string `json:"next_cursor,omitempty"`
+}
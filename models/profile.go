@@ -0,0 +1,35 @@
+package models
+
+var Sexes = []string{"male", "female", "other"}
+
+var UnitSystems = []string{"metric", "imperial"}
+
+var ExperienceLevels = []string{"beginner", "intermediate", "advanced"}
+
+var Goals = []string{
+	"strength", "hypertrophy", "endurance", "weight_loss", "general_fitness", "mobility",
+}
+
+// Profile is the document stored in users.profile. Version identifies its
+// schema so older documents can be migrated when they are read.
+type Profile struct {
+	Version         int      `json:"version"`
+	BirthDate       *string  `json:"birth_date,omitempty"`
+	Sex             *string  `json:"sex,omitempty"`
+	HeightCm        *float64 `json:"height_cm,omitempty"`
+	Units           *string  `json:"units,omitempty"`
+	Timezone        *string  `json:"timezone,omitempty"`
+	ExperienceLevel *string  `json:"experience_level,omitempty"`
+	Goals           []string `json:"goals,omitempty"`
+	AvatarImageID   *string  `json:"avatar_image_id,omitempty"`
+}
+
+type UserResponse struct {
+	ID            int32   `json:"id"`
+	Username      string  `json:"username"`
+	Email         string  `json:"email"`
+	Role          string  `json:"role"`
+	EmailVerified bool    `json:"email_verified"`
+	TwoFactor     bool    `json:"two_factor_enabled"`
+	Profile       Profile `json:"profile"`
+}
@@ -0,0 +1,84 @@
+package models
+
+import "time"
+
+type ProgramSessionRequest struct {
+	Week       *int32 `json:"week,omitempty" validate:"min=1"`
+	Day        int32  `json:"day" validate:"required,min=1,max=7"`
+	TemplateID int32  `json:"template_id" validate:"required"`
+}
+
+type ProgramRequest struct {
+	Unit                  *string                 `json:"unit,omitempty" validate:"oneof=metric imperial"`
+	Name                  string                  `json:"name" validate:"required,max=100"`
+	Description           *string                 `json:"description,omitempty" validate:"max=1000"`
+	Weeks                 int32                   `json:"weeks" validate:"required,min=1,max=104"`
+	WeeklyWeightIncrement float64                 `json:"weekly_weight_increment"`
+	DeloadEveryWeeks      *int32                  `json:"deload_every_weeks,omitempty" validate:"min=2"`
+	DeloadFactor          *float64                `json:"deload_factor,omitempty"`
+	Sessions              []ProgramSessionRequest `json:"sessions" validate:"required,max=1000,dive"`
+}
+
+type ProgramSessionResponse struct {
+	ID         int32  `json:"id"`
+	Week       *int32 `json:"week,omitempty"`
+	Day        int32  `json:"day"`
+	TemplateID int32  `json:"template_id"`
+}
+
+type ProgramResponse struct {
+	ID                    int32                    `json:"id"`
+	Name                  string                   `json:"name"`
+	Description           *string                  `json:"description,omitempty"`
+	Weeks                 int32                    `json:"weeks"`
+	WeeklyWeightIncrement float64                  `json:"weekly_weight_increment"`
+	DeloadEveryWeeks      *int32                   `json:"deload_every_weeks,omitempty"`
+	DeloadFactor          float64                  `json:"deload_factor"`
+	Unit                  string                   `json:"unit"`
+	CreateAt              time.Time                `json:"created_at"`
+	UpdateAt              time.Time                `json:"updated_at"`
+	Sessions              []ProgramSessionResponse `json:"sessions,omitempty"`
+}
+
+type EnrollmentRequest struct {
+	StartDate string `json:"start_date" validate:"required,date,maxfuture=8760h"`
+}
+
+type EnrollmentResponse struct {
+	ID        int32     `json:"id"`
+	ProgramID int32     `json:"program_id"`
+	StartDate string    `json:"start_date"`
+	CreateAt  time.Time `json:"created_at"`
+}
+
+type ScheduledExercise struct {
+	ExerciseDefinitionID int32    `json:"exercise_definition_id"`
+	TargetSets           int32    `json:"target_sets"`
+	TargetRepsMin        *int32   `json:"target_reps_min,omitempty"`
+	TargetRepsMax        *int32   `json:"target_reps_max,omitempty"`
+	TargetWeightMin      *float64 `json:"target_weight_min,omitempty"`
+	TargetWeightMax      *float64 `json:"target_weight_max,omitempty"`
+}
+
+type ScheduledSession struct {
+	Date         string              `json:"date"`
+	EnrollmentID int32               `json:"enrollment_id"`
+	ProgramID    int32               `json:"program_id"`
+	ProgramName  string              `json:"program_name"`
+	Week         int32               `json:"week"`
+	Day          int32               `json:"day"`
+	Deload       bool                `json:"deload"`
+	TemplateID   int32               `json:"template_id"`
+	TemplateName string              `json:"template_name"`
+	Status       string              `json:"status"`
+	WorkoutID    *int32              `json:"workout_id,omitempty"`
+	Exercises    []ScheduledExercise `json:"exercises"`
+}
+
+type ScheduleResponse struct {
+	From     string                  `json:"from"`
+	To       string                  `json:"to"`
+	Unit     string                  `json:"unit"`
+	Sessions []ScheduledSession      `json:"sessions"`
+	Workouts []WorkoutCreateResponse `json:"workouts"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+type PersonalRecordResponse struct {
+	ID                   int32     `json:"id"`
+	ExerciseDefinitionID int32     `json:"exercise_definition_id"`
+	RecordType           string    `json:"record_type"`
+	Value                float64   `json:"value"`
+	PreviousValue        *float64  `json:"previous_value,omitempty"`
+	Weight               *float64  `json:"weight,omitempty"`
+	Repetitions          *int32    `json:"repetitions,omitempty"`
+	Distance             *float64  `json:"distance,omitempty"`
+	DurationSeconds      *int32    `json:"duration_seconds,omitempty"`
+	WorkoutID            *int32    `json:"workout_id,omitempty"`
+	SetID                *int32    `json:"set_id,omitempty"`
+	Unit                 string    `json:"unit"`
+	AchievedAt           time.Time `json:"achieved_at"`
+}
+
+type PersonalRecordHistoryResponse struct {
+	ExerciseDefinitionID int32                    `json:"exercise_definition_id"`
+	Current              []PersonalRecordResponse `json:"current"`
+	History              []PersonalRecordResponse `json:"history"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+type TemplateExerciseRequest struct {
+	ExerciseDefinitionID int32    `json:"exercise_definition_id" validate:"required"`
+	Notes                *string  `json:"notes,omitempty" validate:"max=1000"`
+	TargetSets           int32    `json:"target_sets" validate:"required,min=1,max=50"`
+	TargetRepsMin        *int32   `json:"target_reps_min,omitempty" validate:"min=1,max=1000"`
+	TargetRepsMax        *int32   `json:"target_reps_max,omitempty" validate:"min=1,max=1000"`
+	TargetWeightMin      *float64 `json:"target_weight_min,omitempty" validate:"min=0,max=2000"`
+	TargetWeightMax      *float64 `json:"target_weight_max,omitempty" validate:"min=0,max=2000"`
+	RestSeconds          *int32   `json:"rest_seconds,omitempty" validate:"min=0,max=3600"`
+}
+
+type TemplateRequest struct {
+	Unit        *string                   `json:"unit,omitempty" validate:"oneof=metric imperial"`
+	Name        string                    `json:"name" validate:"required,max=100"`
+	Description *string                   `json:"description,omitempty" validate:"max=1000"`
+	Exercises   []TemplateExerciseRequest `json:"exercises" validate:"max=50,dive"`
+}
+
+type TemplateExerciseResponse struct {
+	ID                   int32    `json:"id"`
+	ExerciseDefinitionID int32    `json:"exercise_definition_id"`
+	Position             int32    `json:"position"`
+	Notes                *string  `json:"notes,omitempty"`
+	TargetSets           int32    `json:"target_sets"`
+	TargetRepsMin        *int32   `json:"target_reps_min,omitempty"`
+	TargetRepsMax        *int32   `json:"target_reps_max,omitempty"`
+	TargetWeightMin      *float64 `json:"target_weight_min,omitempty"`
+	TargetWeightMax      *float64 `json:"target_weight_max,omitempty"`
+	RestSeconds          *int32   `json:"rest_seconds,omitempty"`
+}
+
+type TemplateResponse struct {
+	ID          int32                      `json:"id"`
+	Name        string                     `json:"name"`
+	Description *string                    `json:"description,omitempty"`
+	Unit        string                     `json:"unit"`
+	CreateAt    time.Time                  `json:"created_at"`
+	UpdateAt    time.Time                  `json:"updated_at"`
+	Exercises   []TemplateExerciseResponse `json:"exercises,omitempty"`
+}
+
+// WorkoutFromTemplateRequest starts a workout from a template at StartedAt,
+// or at the beginning of Date in the caller's timezone, or now.
+type WorkoutFromTemplateRequest struct {
+	Name        *string    `json:"name,omitempty" validate:"max=100"`
+	Description *string    `json:"description,omitempty" validate:"max=1000"`
+	Date        string     `json:"date" validate:"date,maxfuture=24h"`
+	StartedAt   *time.Time `json:"started_at,omitempty" validate:"maxfuture=24h"`
+	Timezone    *string    `json:"timezone,omitempty" validate:"max=64"`
+}
@@ -1,30 +1,100 @@
 package models
 
+import "encoding/json"
+
 type UserRegisterRequest struct {
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Username string `json:"username" validate:"required,min=3,max=32"`
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Password string `json:"password" validate:"required,min=8,maxbytes=72"`
 }
 
 type UserRegisterResponse struct {
-	ID       int            `json:"id"`
-	Username string         `json:"username"`
-	Email    string         `json:"email"`
-	Profile  map[string]any `json:"profile,omitempty"`
+	ID            int      `json:"id"`
+	Username      string   `json:"username"`
+	Email         string   `json:"email"`
+	EmailVerified bool     `json:"email_verified"`
+	Profile       *Profile `json:"profile,omitempty"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
 }
 
 type PasswordResetRequest struct {
-	Email string `json:"email"`
+	Email string `json:"email" validate:"required,email,max=254"`
 }
 
 type PasswordResetSubmitRequest struct {
-	Token       string `json:"token"`
-	NewPassword string `json:"new_password"`
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=8,maxbytes=72"`
 }
 
+// UpdateUserRequest updates the caller. Empty fields keep their current
+// value; Profile is a partial Profile document where null clears a field.
 type UpdateUserRequest struct {
-	ID       int            `json:"id"`
-	Username string         `json:"username"`
-	Email    string         `json:"email"`
-	Profile  map[string]any `json:"profile,omitempty"`
+	ID       int             `json:"id"`
+	Username string          `json:"username" validate:"min=3,max=32"`
+	Email    string          `json:"email" validate:"email,max=254"`
+	Profile  json.RawMessage `json:"profile,omitempty"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,max=254"`
+	Password string `json:"password" validate:"required,maxbytes=72"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type UsernameAvailabilityRequest struct {
+	Username string `json:"username" validate:"required,min=3,max=32"`
+}
+
+type UsernameAvailabilityResponse struct {
+	Username  string `json:"username"`
+	Available bool   `json:"available"`
+}
+
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// MFAChallengeResponse is returned by login instead of a token pair when the
+// account has two-factor authentication enabled.
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// MFALoginRequest completes a login with a TOTP code or a recovery code.
+type MFALoginRequest struct {
+	MFAToken     string `json:"mfa_token" validate:"required"`
+	Code         string `json:"code" validate:"max=16"`
+	RecoveryCode string `json:"recovery_code" validate:"max=32"`
+}
+
+type TOTPEnrollResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type TOTPConfirmRequest struct {
+	Code string `json:"code" validate:"required,max=16"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TOTPDisableRequest re-authenticates the user with their password and a
+// TOTP code or a recovery code.
+type TOTPDisableRequest struct {
+	Password     string `json:"password" validate:"required,maxbytes=72"`
+	Code         string `json:"code" validate:"max=16"`
+	RecoveryCode string `json:"recovery_code" validate:"max=32"`
 }
@@ -1,28 +1,97 @@
 package models
 
-import (
-	"database/sql"
-	"time"
-)
+import "time"
 
+// WorkoutCreateRequest starts a workout. StartedAt defaults to now and
+// Timezone, an IANA name, to the caller's profile timezone; together they
+// decide which calendar day the workout belongs to.
 type WorkoutCreateRequest struct {
-	UserID      int32          `json:"user_id"`
-	Name        string         `json:"name"`
-	Description sql.NullString `json:"description"`
+	UserID      int32      `json:"user_id"`
+	Name        string     `json:"name" validate:"required,max=100"`
+	Description *string    `json:"description,omitempty" validate:"max=1000"`
+	StartedAt   *time.Time `json:"started_at,omitempty" validate:"maxfuture=24h"`
+	EndedAt     *time.Time `json:"ended_at,omitempty" validate:"maxfuture=24h"`
+	Timezone    *string    `json:"timezone,omitempty" validate:"max=64"`
 }
 
 type WorkoutCreateResponse struct {
-	ID          int32          `json:"id"`
-	UserID      int32          `json:"user_id"`
-	Name        string         `json:"name"`
-	Description sql.NullString `json:"description"`
-	Date        time.Time      `json:"date"`
-	CreateAt    time.Time      `json:"created_at"`
-	UpdateAt    time.Time      `json:"updated_at"`
+	ID          int32      `json:"id"`
+	UserID      int32      `json:"user_id"`
+	Name        string     `json:"name"`
+	Description *string    `json:"description,omitempty"`
+	Date        string     `json:"date"`
+	StartedAt   time.Time  `json:"started_at"`
+	EndedAt     *time.Time `json:"ended_at,omitempty"`
+	Timezone    string     `json:"timezone"`
+	TemplateID  *int32     `json:"template_id,omitempty"`
+	CreateAt    time.Time  `json:"created_at"`
+	UpdateAt    time.Time  `json:"updated_at"`
 }
 
+// WorkoutUpdateRequest is a partial update; fields left out keep their
+// current values. Description and EndedAt are cleared by setting them to
+// null.
 type WorkoutUpdateRequest struct {
-	Name        string         `json:"name"`
-	Description sql.NullString `json:"description"`
-	Date        time.Time      `json:"date"`
+	Name        *string             `json:"name,omitempty" validate:"max=100"`
+	Description Optional[string]    `json:"description" validate:"max=1000"`
+	StartedAt   *time.Time          `json:"started_at,omitempty" validate:"maxfuture=24h"`
+	EndedAt     Optional[time.Time] `json:"ended_at" validate:"maxfuture=24h"`
+	Timezone    *string             `json:"timezone,omitempty" validate:"max=64"`
+}
+
+type ExerciseCreateRequest struct {
+	ExerciseDefinitionID int32   `json:"exercise_definition_id" validate:"required"`
+	Notes                *string `json:"notes,omitempty" validate:"max=1000"`
+}
+
+type ExerciseResponse struct {
+	ID                   int32         `json:"id"`
+	WorkoutID            int32         `json:"workout_id"`
+	ExerciseDefinitionID *int32        `json:"exercise_definition_id,omitempty"`
+	Name                 string        `json:"name"`
+	Notes                *string       `json:"notes,omitempty"`
+	Position             int32         `json:"position"`
+	CreateAt             time.Time     `json:"created_at"`
+	Sets                 []SetResponse `json:"sets"`
+}
+
+// SetCreateRequest logs a set. Weight and Distance are in kg and m, or lb and
+// mi, depending on Unit, which defaults to the caller's preference.
+type SetCreateRequest struct {
+	Unit            *string  `json:"unit,omitempty" validate:"oneof=metric imperial"`
+	Repetitions     *int32   `json:"repetitions,omitempty" validate:"min=0,max=1000"`
+	Weight          *float64 `json:"weight,omitempty" validate:"min=0,max=2000"`
+	DurationSeconds *int32   `json:"duration_seconds,omitempty" validate:"min=0,max=86400"`
+	Distance        *float64 `json:"distance,omitempty" validate:"min=0,max=1000000"`
+	Rpe             *float64 `json:"rpe,omitempty" validate:"min=1,max=10"`
+	RestSeconds     *int32   `json:"rest_seconds,omitempty" validate:"min=0,max=3600"`
+}
+
+type SetResponse struct {
+	ID              int32     `json:"id"`
+	ExerciseID      int32     `json:"exercise_id"`
+	Position        int32     `json:"position"`
+	Repetitions     *int32    `json:"repetitions,omitempty"`
+	Weight          *float64  `json:"weight,omitempty"`
+	DurationSeconds *int32    `json:"duration_seconds,omitempty"`
+	Distance        *float64  `json:"distance,omitempty"`
+	Rpe             *float64  `json:"rpe,omitempty"`
+	RestSeconds     *int32    `json:"rest_seconds,omitempty"`
+	TargetRepsMin   *int32    `json:"target_reps_min,omitempty"`
+	TargetRepsMax   *int32    `json:"target_reps_max,omitempty"`
+	TargetWeightMin *float64  `json:"target_weight_min,omitempty"`
+	TargetWeightMax *float64  `json:"target_weight_max,omitempty"`
+	CreateAt        time.Time `json:"created_at"`
+}
+
+type WorkoutDetailResponse struct {
+	WorkoutCreateResponse
+	Unit      string             `json:"unit"`
+	Exercises []ExerciseResponse `json:"exercises"`
+}
+
+type SetSaveResponse struct {
+	SetResponse
+	Unit            string                   `json:"unit"`
+	PersonalRecords []PersonalRecordResponse `json:"personal_records"`
 }
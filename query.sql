@@ -3,9 +3,22 @@ select * from users
 where id = $1 limit 1;
 
 -- name: ListUser :many
-select id, username, email, profile
+select id, username, email, role, email_verified_at, suspended_at, create_at
 from users
-order by username;
+where (sqlc.narg(query)::text is null
+       or strpos(lower(username), lower(sqlc.narg(query)::text)) > 0
+       or strpos(lower(email), lower(sqlc.narg(query)::text)) > 0)
+  and (sqlc.narg(role)::text is null or role = sqlc.narg(role)::text)
+  and (sqlc.narg(suspended)::bool is null or (suspended_at is not null) = sqlc.narg(suspended)::bool)
+  and (sqlc.narg(after_username)::text is null
+       or (sqlc.arg(sort_desc)::bool and (username, id) < (sqlc.narg(after_username)::text, sqlc.arg(after_id)::int))
+       or (not sqlc.arg(sort_desc)::bool and (username, id) > (sqlc.narg(after_username)::text, sqlc.arg(after_id)::int)))
+order by
+  case when not sqlc.arg(sort_desc)::bool then username end,
+  case when not sqlc.arg(sort_desc)::bool then id end,
+  case when sqlc.arg(sort_desc)::bool then username end desc,
+  case when sqlc.arg(sort_desc)::bool then id end desc
+limit sqlc.arg(page_limit);
 
 -- name: CreateUser :one
 insert into users (username, password_hash, email, profile)
@@ -14,25 +27,165 @@ returning *;
 
 -- name: UpdateUser :exec
 update users
-set username = $2, email = $3, profile = $4
+set username = $2, email = $3, profile = $4,
+    email_verified_at = case when email = $3 then email_verified_at end
 where id = $1;
 
+-- name: VerifyUserEmail :execrows
+update users
+set email_verified_at = now()
+where id = $1 and email = $2 and email_verified_at is null;
+
+-- name: ChangeUserEmail :exec
+update users
+set email = $2, email_verified_at = now()
+where id = $1;
+
+-- name: PurgeUnverifiedUsers :execrows
+delete from users
+where email_verified_at is null and create_at < $1;
+
+-- name: CreateEmailVerificationToken :one
+insert into email_verification_tokens (user_id, email, token_hash, expires_at)
+values ($1, $2, $3, $4)
+returning *;
+
+-- name: UseEmailVerificationToken :one
+update email_verification_tokens
+set used_at = now()
+where token_hash = $1 and used_at is null and expires_at > now()
+returning *;
+
+-- name: GetLastEmailVerificationSentAt :one
+select max(create_at)::timestamptz as sent_at
+from email_verification_tokens
+where user_id = $1;
+
 -- name: DeleteUser :exec
 delete from users
 where id = $1;
 
 -- name: GetUserByEmail :one
 select * from users
-where email = $1 limit 1;
+where lower(email) = lower($1) limit 1;
+
+-- name: UsernameTaken :one
+select exists (
+    select 1 from users
+    where lower(username) = lower($1) and id <> sqlc.arg(except_id)::int
+);
 
--- name: SavePasswordResetToken :exec
-insert into password_reset_tokens (user_id, token, expiration)
+-- name: EmailTaken :one
+select exists (
+    select 1 from users
+    where lower(email) = lower($1) and id <> sqlc.arg(except_id)::int
+);
+
+-- name: CreatePasswordResetToken :exec
+insert into password_reset_tokens (user_id, token_hash, expires_at)
 values ($1, $2, $3);
 
--- name: GetPasswordResetToken :one
-select * from password_reset_tokens
-where token = $1 limit 1;
+-- name: DeletePasswordResetTokens :exec
+delete from password_reset_tokens
+where user_id = $1;
+
+-- name: UsePasswordResetToken :one
+delete from password_reset_tokens
+where token_hash = $1 and expires_at > now()
+returning *;
+
+-- name: SetTOTPSecret :execrows
+update users
+set totp_secret = $2, totp_last_step = null
+where id = $1 and totp_enabled_at is null;
+
+-- name: EnableTOTP :execrows
+update users
+set totp_enabled_at = now(), totp_last_step = $2
+where id = $1 and totp_secret is not null and totp_enabled_at is null;
+
+-- name: DisableTOTP :exec
+update users
+set totp_secret = null, totp_enabled_at = null, totp_last_step = null
+where id = $1;
 
+-- name: UseTOTPStep :execrows
+update users
+set totp_last_step = $2
+where id = $1 and totp_enabled_at is not null
+  and (totp_last_step is null or totp_last_step < $2);
+
+-- name: CreateRecoveryCode :exec
+insert into totp_recovery_codes (user_id, code_hash)
+values ($1, $2);
+
+-- name: DeleteRecoveryCodes :exec
+delete from totp_recovery_codes
+where user_id = $1;
+
+-- name: UseRecoveryCode :execrows
+delete from totp_recovery_codes
+where user_id = $1 and code_hash = $2;
+
+-- name: ClearUserPassword :exec
+update users
+set password_hash = ''
+where id = $1;
+
+-- name: SuspendUser :execrows
+update users
+set suspended_at = now()
+where id = $1 and suspended_at is null;
+
+-- name: UnsuspendUser :execrows
+update users
+set suspended_at = null
+where id = $1 and suspended_at is not null;
+
+-- name: UpdateUserRole :exec
+update users
+set role = $2
+where id = $1;
+
+-- name: GetRole :one
+select * from roles
+where name = $1;
+
+-- name: HasPermission :one
+select exists (
+    select 1 from role_permissions
+    where role = $1 and permission = $2
+);
+
+-- name: CreateAuditEvent :exec
+insert into audit_events (actor_id, action, target_type, target_id, ip, user_agent, request_id, details)
+values ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: ListAuditEvents :many
+select * from audit_events
+where (sqlc.narg(actor_id)::int is null or actor_id = sqlc.narg(actor_id)::int)
+  and (sqlc.narg(action)::text is null or action = sqlc.narg(action)::text)
+  and (sqlc.narg(target_type)::text is null or target_type = sqlc.narg(target_type)::text)
+  and (sqlc.narg(target_id)::int is null or target_id = sqlc.narg(target_id)::int)
+  and (sqlc.narg(from_time)::timestamptz is null or create_at >= sqlc.narg(from_time)::timestamptz)
+  and (sqlc.narg(to_time)::timestamptz is null or create_at < sqlc.narg(to_time)::timestamptz)
+  and (sqlc.narg(after_key)::timestamptz is null
+       or (sqlc.arg(sort_desc)::bool and (create_at, id) < (sqlc.narg(after_key)::timestamptz, sqlc.arg(after_id)::int))
+       or (not sqlc.arg(sort_desc)::bool and (create_at, id) > (sqlc.narg(after_key)::timestamptz, sqlc.arg(after_id)::int)))
+order by
+  case when not sqlc.arg(sort_desc)::bool then create_at end,
+  case when not sqlc.arg(sort_desc)::bool then id end,
+  case when sqlc.arg(sort_desc)::bool then create_at end desc,
+  case when sqlc.arg(sort_desc)::bool then id end desc
+limit sqlc.arg(page_limit);
+
+-- name: SetAuditRetention :exec
+update audit_settings
+set retention = sqlc.arg(seconds)::bigint * interval '1 second';
+
+-- name: PurgeAuditEvents :execrows
+delete from audit_events
+where create_at < now() - (select retention from audit_settings);
 -- name: UpdatePassword :exec
 update users
 set password_hash = $2
@@ -40,25 +193,429 @@ where id = $1;
 
 
 -- name: CreateWorkout :one
-insert into workouts (user_id, name, description,date)
-values ($1, $2, $3, $4)
-returning id, user_id, name , description, date, create_at, update_at;
+insert into workouts (user_id, name, description, date, template_id, started_at, ended_at, timezone)
+values ($1, $2, $3, $4, $5, $6, $7, $8)
+returning id, user_id, name, description, date, create_at, update_at, template_id, started_at, ended_at, timezone;
 
--- name: GetWorkoutsByUserID :many
-select id, user_id, name, description, date, create_at, update_at
-from workouts
-where user_id = $1;
+-- name: ListWorkouts :many
+select id, user_id, name, description, date, create_at, update_at, template_id, started_at, ended_at, timezone
+from workouts w
+where w.user_id = $1
+  and (sqlc.narg(from_date)::date is null or w.date >= sqlc.narg(from_date)::date)
+  and (sqlc.narg(to_date)::date is null or w.date <= sqlc.narg(to_date)::date)
+  and (sqlc.narg(name)::text is null or strpos(lower(w.name), lower(sqlc.narg(name)::text)) > 0)
+  and (sqlc.narg(template_id)::int is null or w.template_id = sqlc.narg(template_id)::int)
+  and (sqlc.narg(definition_id)::int is null or exists (
+      select 1 from exercises e
+      where e.workout_id = w.id and e.definition_id = sqlc.narg(definition_id)::int
+  ))
+  and (sqlc.narg(after_key)::timestamptz is null
+       or (sqlc.arg(sort_desc)::bool
+           and (case when sqlc.arg(sort_by)::text = 'updated' then w.update_at else w.started_at end, w.id) < (sqlc.narg(after_key)::timestamptz, sqlc.arg(after_id)::int))
+       or (not sqlc.arg(sort_desc)::bool
+           and (case when sqlc.arg(sort_by)::text = 'updated' then w.update_at else w.started_at end, w.id) > (sqlc.narg(after_key)::timestamptz, sqlc.arg(after_id)::int)))
+order by
+  case when not sqlc.arg(sort_desc)::bool then (case when sqlc.arg(sort_by)::text = 'updated' then w.update_at else w.started_at end) end,
+  case when not sqlc.arg(sort_desc)::bool then w.id end,
+  case when sqlc.arg(sort_desc)::bool then (case when sqlc.arg(sort_by)::text = 'updated' then w.update_at else w.started_at end) end desc,
+  case when sqlc.arg(sort_desc)::bool then w.id end desc
+limit sqlc.arg(page_limit);
 
 -- name: GetWorkoutByUserID :one
-select id, user_id, name, description, date, create_at, update_at
+select id, user_id, name, description, date, create_at, update_at, template_id, started_at, ended_at, timezone
 from workouts
 where id = $1 and user_id = $2;
 
--- name: UpdateWorkout :exec
+-- name: UpdateWorkout :execrows
 update workouts
-set name = $3, description = $4, date = $5, update_at = now()
+set name = $3, description = $4, date = $5, started_at = $6, ended_at = $7, timezone = $8, update_at = now()
 where id = $1 and user_id = $2;
 
--- name: DeleteWorkout :exec
+-- name: DeleteWorkout :one
 delete from workouts
+where id = $1 and user_id = $2
+returning id, user_id, name, description, date, create_at, update_at, template_id, started_at, ended_at, timezone;
+
+-- name: CreateRefreshToken :one
+insert into refresh_tokens (user_id, family_id, token_hash, expires_at)
+values ($1, $2, $3, $4)
+returning *;
+
+-- name: GetRefreshTokenByHash :one
+select * from refresh_tokens
+where token_hash = $1 limit 1;
+
+-- name: RevokeRefreshToken :execrows
+update refresh_tokens
+set revoked_at = now()
+where id = $1 and revoked_at is null;
+
+-- name: RevokeRefreshTokenFamily :exec
+update refresh_tokens
+set revoked_at = now()
+where family_id = $1 and revoked_at is null;
+
+-- name: RevokeUserRefreshTokens :exec
+update refresh_tokens
+set revoked_at = now()
+where user_id = $1 and revoked_at is null;
+
+-- name: CreateExercise :one
+insert into exercises (workout_id, name, notes, position, definition_id)
+values ($1, $2, $3, (select coalesce(max(position), 0) + 1 from exercises where workout_id = $1), $4)
+returning *;
+
+-- name: GetExerciseByUserID :one
+select e.id, e.workout_id, e.name, e.notes, e.position, e.create_at, e.definition_id
+from exercises e
+join workouts w on w.id = e.workout_id
+where e.id = $1 and w.user_id = $2;
+
+-- name: GetExercisesByWorkoutID :many
+select * from exercises
+where workout_id = $1
+order by position, id;
+
+-- name: CreateSet :one
+insert into sets (exercise_id, position, repetitions, weight, duration_seconds, distance_meters, rpe, rest_seconds)
+values ($1, (select coalesce(max(position), 0) + 1 from sets where exercise_id = $1), $2, $3, $4, $5, $6, $7)
+returning *;
+
+-- name: GetSetsByWorkoutID :many
+select s.id, s.exercise_id, s.position, s.repetitions, s.weight, s.duration_seconds, s.distance_meters, s.rpe, s.rest_seconds, s.create_at,
+       s.target_reps_min, s.target_reps_max, s.target_weight_min, s.target_weight_max
+from sets s
+join exercises e on e.id = s.exercise_id
+where e.workout_id = $1
+order by s.exercise_id, s.position, s.id;
+
+-- name: CreateExerciseDefinition :one
+insert into exercise_definitions (user_id, name, aliases, primary_muscles, secondary_muscles, equipment, movement_pattern, unilateral, kind)
+values ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+returning *;
+
+-- name: GetExerciseDefinition :one
+select * from exercise_definitions
+where id = $1 and (user_id is null or user_id = $2);
+
+-- name: SearchExerciseDefinitions :many
+select * from exercise_definitions
+where (user_id is null or user_id = sqlc.arg(user_id)::int)
+  and (sqlc.arg(query)::text = ''
+       or name ilike '%' || sqlc.arg(query)::text || '%'
+       or exists (select 1 from unnest(aliases) a where a ilike '%' || sqlc.arg(query)::text || '%'))
+  and (sqlc.arg(muscle)::text = ''
+       or sqlc.arg(muscle)::text = any(primary_muscles)
+       or sqlc.arg(muscle)::text = any(secondary_muscles))
+  and (sqlc.arg(equipment)::text = '' or equipment = sqlc.arg(equipment)::text)
+order by user_id nulls first, name;
+
+-- name: UpdateSet :one
+update sets
+set repetitions = $3, weight = $4, duration_seconds = $5, distance_meters = $6, rpe = $7, rest_seconds = $8
+where id = $1 and exercise_id = $2
+returning *;
+
+-- name: CreateWorkoutTemplate :one
+insert into workout_templates (user_id, name, description)
+values ($1, $2, $3)
+returning *;
+
+-- name: GetWorkoutTemplate :one
+select * from workout_templates
+where id = $1 and user_id = $2;
+
+-- name: ListWorkoutTemplates :many
+select * from workout_templates
+where user_id = $1
+order by name, id;
+
+-- name: UpdateWorkoutTemplate :execrows
+update workout_templates
+set name = $3, description = $4, update_at = now()
+where id = $1 and user_id = $2;
+
+-- name: DeleteWorkoutTemplate :execrows
+delete from workout_templates
+where id = $1 and user_id = $2;
+
+-- name: CreateTemplateExercise :one
+insert into template_exercises (template_id, definition_id, position, notes, target_sets, target_reps_min, target_reps_max, target_weight_min, target_weight_max, rest_seconds)
+values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+returning *;
+
+-- name: GetTemplateExercises :many
+select * from template_exercises
+where template_id = $1
+order by position, id;
+
+-- name: DeleteTemplateExercises :exec
+delete from template_exercises
+where template_id = $1;
+
+-- name: CreateExercisesFromTemplate :exec
+insert into exercises (workout_id, name, notes, position, definition_id)
+select $1, d.name, te.notes, te.position, te.definition_id
+from template_exercises te
+join exercise_definitions d on d.id = te.definition_id
+where te.template_id = $2;
+
+-- name: CreatePlannedSetsFromTemplate :exec
+insert into sets (exercise_id, position, rest_seconds, target_reps_min, target_reps_max, target_weight_min, target_weight_max)
+select e.id, g.n, te.rest_seconds, te.target_reps_min, te.target_reps_max, te.target_weight_min, te.target_weight_max
+from exercises e
+join template_exercises te on te.template_id = $2 and te.position = e.position
+cross join lateral generate_series(1, te.target_sets) as g(n)
+where e.workout_id = $1;
+
+-- name: GetWorkoutsByUserIDBetween :many
+select id, user_id, name, description, date, create_at, update_at, template_id, started_at, ended_at, timezone
+from workouts
+where user_id = $1 and date between sqlc.arg(from_date)::date and sqlc.arg(to_date)::date
+order by date, started_at, id;
+
+-- name: CreateProgram :one
+insert into programs (user_id, name, description, weeks, weekly_weight_increment, deload_every_weeks, deload_factor)
+values ($1, $2, $3, $4, $5, $6, $7)
+returning *;
+
+-- name: GetProgram :one
+select * from programs
+where id = $1 and user_id = $2;
+
+-- name: ListPrograms :many
+select * from programs
+where user_id = $1
+order by name, id;
+
+-- name: DeleteProgram :execrows
+delete from programs
+where id = $1 and user_id = $2;
+
+-- name: CreateProgramSession :one
+insert into program_sessions (program_id, week, day, template_id)
+values ($1, $2, $3, $4)
+returning *;
+
+-- name: GetProgramSessions :many
+select * from program_sessions
+where program_id = $1
+order by week nulls first, day, id;
+
+-- name: CreateProgramEnrollment :one
+insert into program_enrollments (user_id, program_id, start_date)
+values ($1, $2, $3)
+returning *;
+
+-- name: ListProgramEnrollments :many
+select * from program_enrollments
+where user_id = $1
+order by start_date, id;
+
+-- name: DeleteProgramEnrollment :execrows
+delete from program_enrollments
 where id = $1 and user_id = $2;
+
+-- name: GetScheduledSessions :many
+select pe.id as enrollment_id, pe.start_date, p.id as program_id, p.name as program_name, p.weeks,
+       p.weekly_weight_increment, p.deload_every_weeks, p.deload_factor,
+       ps.week, ps.day, ps.template_id, t.name as template_name
+from program_enrollments pe
+join programs p on p.id = pe.program_id
+join program_sessions ps on ps.program_id = p.id
+join workout_templates t on t.id = ps.template_id
+where pe.user_id = $1
+  and pe.start_date <= sqlc.arg(to_date)::date
+  and pe.start_date + p.weeks * 7 > sqlc.arg(from_date)::date
+order by pe.id, ps.week nulls first, ps.day;
+
+-- name: CreatePersonalRecord :one
+insert into personal_records (user_id, definition_id, record_type, value, previous_value, weight, repetitions, distance_meters, duration_seconds, workout_id, set_id, achieved_at)
+values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, coalesce((select started_at from workouts where id = $10), now()))
+returning *;
+
+-- name: UpdatePersonalRecord :one
+update personal_records
+set value = $2, weight = $3, repetitions = $4, set_id = $5
+where id = $1
+returning *;
+
+-- name: GetCurrentPersonalRecords :many
+select distinct on (record_type,
+                    case record_type when 'reps_at_weight' then weight when 'fastest_time' then distance_meters end)
+       id, user_id, definition_id, record_type, value, previous_value, weight, repetitions, distance_meters, duration_seconds, workout_id, set_id, achieved_at
+from personal_records
+where user_id = $1 and definition_id = $2
+order by record_type,
+         case record_type when 'reps_at_weight' then weight when 'fastest_time' then distance_meters end,
+         id desc;
+
+-- name: ListCurrentPersonalRecords :many
+select distinct on (definition_id, record_type,
+                    case record_type when 'reps_at_weight' then weight when 'fastest_time' then distance_meters end)
+       id, user_id, definition_id, record_type, value, previous_value, weight, repetitions, distance_meters, duration_seconds, workout_id, set_id, achieved_at
+from personal_records
+where user_id = $1
+order by definition_id, record_type,
+         case record_type when 'reps_at_weight' then weight when 'fastest_time' then distance_meters end,
+         id desc;
+
+-- name: GetPersonalRecordHistory :many
+select * from personal_records
+where user_id = $1 and definition_id = $2
+order by achieved_at desc, id desc;
+
+-- name: DeleteSetPersonalRecords :exec
+delete from personal_records
+where set_id = $1 or (record_type = 'session_volume' and workout_id = $2 and definition_id = $3);
+
+-- name: DeleteWorkoutPersonalRecords :exec
+delete from personal_records
+where workout_id = $1 and user_id = $2;
+
+-- name: ListRecordCandidateSets :many
+select * from (
+    select distinct on (s.weight) s.id, s.weight, s.repetitions, s.distance_meters, s.duration_seconds, e.workout_id
+    from sets s
+    join exercises e on e.id = s.exercise_id
+    join workouts w on w.id = e.workout_id
+    where w.user_id = $1 and e.definition_id = $2 and s.weight > 0 and s.repetitions > 0
+    order by s.weight, s.repetitions desc, w.started_at, s.id
+) by_weight
+union all
+select * from (
+    select distinct on (s.distance_meters) s.id, s.weight, s.repetitions, s.distance_meters, s.duration_seconds, e.workout_id
+    from sets s
+    join exercises e on e.id = s.exercise_id
+    join workouts w on w.id = e.workout_id
+    where w.user_id = $1 and e.definition_id = $2 and s.distance_meters > 0 and s.duration_seconds > 0
+    order by s.distance_meters, s.duration_seconds, w.started_at, s.id
+) by_distance;
+
+-- name: GetBestSessionVolume :one
+select e.workout_id, sum(s.weight * s.repetitions)::double precision as volume
+from sets s
+join exercises e on e.id = s.exercise_id
+join workouts w on w.id = e.workout_id
+where w.user_id = $1 and e.definition_id = $2 and s.weight > 0 and s.repetitions > 0
+group by e.workout_id, w.started_at
+order by volume desc, w.started_at, e.workout_id
+limit 1;
+
+-- name: GetWorkoutExerciseVolume :one
+select coalesce(sum(s.weight * s.repetitions), 0)::double precision as volume
+from sets s
+join exercises e on e.id = s.exercise_id
+where e.workout_id = $1 and e.definition_id = $2;
+
+-- name: GetVolumeByExercise :many
+select date_trunc(sqlc.arg(bucket)::text, w.date)::date as bucket_start,
+       e.definition_id, d.name,
+       sum(s.weight * s.repetitions)::double precision as tonnage,
+       count(*)::int as sets
+from workouts w
+join exercises e on e.workout_id = w.id
+join exercise_definitions d on d.id = e.definition_id
+join sets s on s.exercise_id = e.id
+where w.user_id = $1
+  and w.date between sqlc.arg(from_date)::date and sqlc.arg(to_date)::date
+  and s.weight is not null and s.repetitions is not null
+group by 1, e.definition_id, d.name
+order by 1, d.name;
+
+-- name: GetVolumeByMuscle :many
+select date_trunc(sqlc.arg(bucket)::text, w.date)::date as bucket_start,
+       m.muscle::text as muscle,
+       sum(s.weight * s.repetitions)::double precision as tonnage,
+       count(*)::int as sets
+from workouts w
+join exercises e on e.workout_id = w.id
+join exercise_definitions d on d.id = e.definition_id
+join sets s on s.exercise_id = e.id
+cross join lateral unnest(d.primary_muscles) as m(muscle)
+where w.user_id = $1
+  and w.date between sqlc.arg(from_date)::date and sqlc.arg(to_date)::date
+  and s.weight is not null and s.repetitions is not null
+group by 1, 2
+order by 1, 2;
+
+-- name: GetSessionStats :many
+select date_trunc(sqlc.arg(bucket)::text, w.date)::date as bucket_start,
+       count(*)::int as sessions,
+       coalesce(avg(coalesce(extract(epoch from w.ended_at - w.started_at), ws.duration_seconds)), 0)::double precision as avg_duration_seconds
+from workouts w
+left join lateral (
+    select extract(epoch from max(s.create_at) - min(s.create_at)) as duration_seconds
+    from exercises e
+    join sets s on s.exercise_id = e.id
+    where e.workout_id = w.id
+) ws on true
+where w.user_id = $1
+  and w.date between sqlc.arg(from_date)::date and sqlc.arg(to_date)::date
+group by 1
+order by 1;
+
+-- name: GetSetsPerMuscle :many
+select m.muscle::text as muscle, sum(m.share)::double precision as sets
+from workouts w
+join exercises e on e.workout_id = w.id
+join exercise_definitions d on d.id = e.definition_id
+join sets s on s.exercise_id = e.id
+cross join lateral (
+    select unnest(d.primary_muscles), 1.0
+    union all
+    select unnest(d.secondary_muscles), 0.5
+) as m(muscle, share)
+where w.user_id = $1
+  and w.date between sqlc.arg(from_date)::date and sqlc.arg(to_date)::date
+  and (s.repetitions is not null or s.duration_seconds is not null)
+group by 1
+order by 1;
+
+-- name: CreateBodyMeasurement :one
+insert into body_measurements (user_id, measured_on, weight_kg, body_fat_percent, neck_cm, chest_cm, waist_cm, hips_cm, arm_cm, thigh_cm, calf_cm, notes)
+values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+returning *;
+
+-- name: GetBodyMeasurement :one
+select * from body_measurements
+where id = $1 and user_id = $2;
+
+-- name: ListBodyMeasurements :many
+select * from body_measurements
+where user_id = $1 and measured_on between sqlc.arg(from_date)::date and sqlc.arg(to_date)::date
+order by measured_on, id;
+
+-- name: UpdateBodyMeasurement :one
+update body_measurements
+set measured_on = $3, weight_kg = $4, body_fat_percent = $5, neck_cm = $6, chest_cm = $7, waist_cm = $8,
+    hips_cm = $9, arm_cm = $10, thigh_cm = $11, calf_cm = $12, notes = $13, update_at = now()
+where id = $1 and user_id = $2
+returning *;
+
+-- name: DeleteBodyMeasurement :execrows
+delete from body_measurements
+where id = $1 and user_id = $2;
+
+-- name: CreateLoginThrottle :exec
+insert into login_throttles (key, failures, last_failure)
+values ($1, 0, $2)
+on conflict (key) do nothing;
+
+-- name: GetLoginThrottleForUpdate :one
+select * from login_throttles
+where key = $1
+for update;
+
+-- name: UpdateLoginThrottle :exec
+update login_throttles
+set failures = $2, last_failure = $3, locked_until = $4
+where key = $1;
+
+-- name: DeleteLoginThrottle :exec
+delete from login_throttles
+where key = $1;
+
+-- name: PurgeLoginThrottles :execrows
+delete from login_throttles
+where last_failure < $1 and (locked_until is null or locked_until < $1);
@@ -1,28 +1,122 @@
 package router
 
 import (
+	"database/sql"
 	"log/slog"
 	"net/http"
+	"net/netip"
 
+	"github.com/Oyatillohgayratov/fitness-tracking-app/errors"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/bruteforce"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/clientip"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/config"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/email"
 	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/handlers"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/jwt"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/middleware"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/problem"
+	"github.com/Oyatillohgayratov/fitness-tracking-app/internal/requestid"
 	"github.com/Oyatillohgayratov/fitness-tracking-app/storage"
 )
 
-func NewMux(logger *slog.Logger, storage *storage.Queries) *http.ServeMux {
+func NewMux(logger *slog.Logger, db *sql.DB, storage *storage.Queries, authConfig config.Auth, mail *email.Queue, tokens *jwt.Manager, limiter *bruteforce.Limiter, trustedProxies []netip.Prefix) http.Handler {
 	mux := http.NewServeMux()
 
-	u := handlers.NewHandler(logger, storage)
+	u := handlers.NewHandler(logger, db, storage, authConfig, mail, tokens, limiter)
+	auth := middleware.Auth(logger, tokens)
+	verified := func(next http.Handler) http.Handler {
+		return auth(u.RequireVerified(next))
+	}
+
+	mux.HandleFunc("GET /.well-known/jwks.json", u.JWKS)
 
 	mux.HandleFunc("POST /api/users/register", u.Register)
-	mux.HandleFunc("GET /api/users/get", u.GetUser)
-	mux.HandleFunc("PUT /api/users/update", u.UpdateUser)
-	mux.HandleFunc("DELETE /api/users/delete", u.DeleteUser)
+	mux.HandleFunc("GET /api/users/availability", u.CheckUsername)
+	mux.HandleFunc("POST /api/users/login", u.Login)
+	mux.HandleFunc("POST /api/users/login/2fa", u.LoginMFA)
+	mux.HandleFunc("POST /api/users/refresh", u.Refresh)
+	mux.HandleFunc("POST /api/users/logout", u.Logout)
 	mux.HandleFunc("POST /api/users/request_password_reset", u.RequestPasswordReset)
 	mux.HandleFunc("PUT /api/users/reset_password", u.ResetPassword)
+	mux.HandleFunc("POST /api/users/verify", u.VerifyEmail)
+	mux.Handle("POST /api/users/verify/resend", auth(http.HandlerFunc(u.ResendVerification)))
+
+	mux.Handle("GET /api/me", auth(http.HandlerFunc(u.GetUser)))
+	mux.Handle("PUT /api/me", auth(http.HandlerFunc(u.UpdateUser)))
+	mux.Handle("DELETE /api/me", auth(http.HandlerFunc(u.DeleteUser)))
+	mux.Handle("POST /api/me/2fa", auth(http.HandlerFunc(u.EnrollTOTP)))
+	mux.Handle("POST /api/me/2fa/confirm", auth(http.HandlerFunc(u.ConfirmTOTP)))
+	mux.Handle("DELETE /api/me/2fa", auth(http.HandlerFunc(u.DisableTOTP)))
+
+	mux.Handle("GET /api/users/get", auth(http.HandlerFunc(u.GetUser)))
+	mux.Handle("PUT /api/users/update", auth(http.HandlerFunc(u.UpdateUser)))
+	mux.Handle("DELETE /api/users/delete", auth(http.HandlerFunc(u.DeleteUser)))
+
+	mux.Handle("GET /api/admin/users", auth(http.HandlerFunc(u.AdminListUsers)))
+	mux.Handle("POST /api/admin/users/{id}/suspend", auth(http.HandlerFunc(u.AdminSuspendUser)))
+	mux.Handle("POST /api/admin/users/{id}/unsuspend", auth(http.HandlerFunc(u.AdminUnsuspendUser)))
+	mux.Handle("POST /api/admin/users/{id}/password-reset", auth(http.HandlerFunc(u.AdminResetPassword)))
+	mux.Handle("PUT /api/admin/users/{id}/role", auth(http.HandlerFunc(u.AdminUpdateRole)))
+	mux.Handle("GET /api/admin/audit-events", auth(http.HandlerFunc(u.AdminListAuditEvents)))
+
+	mux.Handle("POST /api/workouts", verified(http.HandlerFunc(u.CreateWorkout)))
+	mux.Handle("GET /api/workouts", verified(http.HandlerFunc(u.GetWorkoutsByUserID)))
+	mux.Handle("GET /api/workout", verified(http.HandlerFunc(u.GetWorkoutByUserID)))
+	mux.Handle("GET /api/workouts/{id}", verified(http.HandlerFunc(u.GetWorkout)))
+	mux.Handle("PATCH /api/workouts/{id}", verified(http.HandlerFunc(u.UpdateWorkout)))
+	mux.Handle("DELETE /api/workouts/{id}", verified(http.HandlerFunc(u.DeleteWorkout)))
+	mux.Handle("POST /api/workouts/{id}/{action}", verified(workoutAction(u)))
+	mux.Handle("POST /api/workouts/{id}/exercises/{exercise_id}/sets", verified(http.HandlerFunc(u.AddSet)))
+	mux.Handle("PUT /api/workouts/{id}/exercises/{exercise_id}/sets/{set_id}", verified(http.HandlerFunc(u.UpdateSet)))
+
+	mux.Handle("GET /api/exercise-definitions", verified(http.HandlerFunc(u.ListExerciseDefinitions)))
+	mux.Handle("POST /api/exercise-definitions", verified(http.HandlerFunc(u.CreateExerciseDefinition)))
+	mux.Handle("GET /api/exercise-definitions/{id}", verified(http.HandlerFunc(u.GetExerciseDefinition)))
 
-	mux.HandleFunc("POST /api/workouts", u.CreateWorkout)
-	mux.HandleFunc("GET /api/workouts", u.GetWorkoutsByUserID)
-	mux.HandleFunc("GET /api/workout", u.GetWorkoutByUserID)
+	mux.Handle("GET /api/templates", verified(http.HandlerFunc(u.ListTemplates)))
+	mux.Handle("POST /api/templates", verified(http.HandlerFunc(u.CreateTemplate)))
+	mux.Handle("GET /api/templates/{id}", verified(http.HandlerFunc(u.GetTemplate)))
+	mux.Handle("PUT /api/templates/{id}", verified(http.HandlerFunc(u.UpdateTemplate)))
+	mux.Handle("DELETE /api/templates/{id}", verified(http.HandlerFunc(u.DeleteTemplate)))
+
+	mux.Handle("GET /api/programs", verified(http.HandlerFunc(u.ListPrograms)))
+	mux.Handle("POST /api/programs", verified(http.HandlerFunc(u.CreateProgram)))
+	mux.Handle("GET /api/programs/{id}", verified(http.HandlerFunc(u.GetProgram)))
+	mux.Handle("DELETE /api/programs/{id}", verified(http.HandlerFunc(u.DeleteProgram)))
+	mux.Handle("POST /api/programs/{id}/enroll", verified(http.HandlerFunc(u.EnrollInProgram)))
+	mux.Handle("GET /api/enrollments", verified(http.HandlerFunc(u.ListEnrollments)))
+	mux.Handle("DELETE /api/enrollments/{id}", verified(http.HandlerFunc(u.DeleteEnrollment)))
+	mux.Handle("GET /api/schedule", verified(http.HandlerFunc(u.GetSchedule)))
+
+	mux.Handle("GET /api/records", verified(http.HandlerFunc(u.ListRecords)))
+	mux.Handle("GET /api/records/{exercise}", verified(http.HandlerFunc(u.GetExerciseRecords)))
+
+	mux.Handle("GET /api/measurements", verified(http.HandlerFunc(u.ListBodyMeasurements)))
+	mux.Handle("POST /api/measurements", verified(http.HandlerFunc(u.CreateBodyMeasurement)))
+	mux.Handle("GET /api/measurements/{id}", verified(http.HandlerFunc(u.GetBodyMeasurement)))
+	mux.Handle("PUT /api/measurements/{id}", verified(http.HandlerFunc(u.UpdateBodyMeasurement)))
+	mux.Handle("DELETE /api/measurements/{id}", verified(http.HandlerFunc(u.DeleteBodyMeasurement)))
+
+	mux.Handle("GET /api/analytics/volume", verified(http.HandlerFunc(u.GetVolumeAnalytics)))
+	mux.Handle("GET /api/analytics/frequency", verified(http.HandlerFunc(u.GetFrequencyAnalytics)))
+	mux.Handle("GET /api/analytics/muscle-balance", verified(http.HandlerFunc(u.GetMuscleBalanceAnalytics)))
+
+	return requestid.Middleware(clientip.Middleware(trustedProxies)(mux))
+}
 
-	return mux
+// workoutAction serves "POST /api/workouts/{id}/exercises" and
+// "POST /api/workouts/from-template/{id}". ServeMux considers the two patterns
+// ambiguous, so they share one registration.
+func workoutAction(u handlers.UserHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.PathValue("id") == "from-template":
+			r.SetPathValue("id", r.PathValue("action"))
+			u.CreateWorkoutFromTemplate(w, r)
+		case r.PathValue("action") == "exercises":
+			u.AddExercise(w, r)
+		default:
+			problem.Write(w, r, errors.NotFound("not found"))
+		}
+	}
 }
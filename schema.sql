@@ -29,7 +29,7 @@ CREATE TABLE "sets" (
   "id" serial PRIMARY KEY,
   "exercise_id" int,
   "repetitions" int,
-  "weight" float
+  "weight" float -- kilograms
 );
 
 CREATE TABLE "images" (
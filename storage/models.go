@@ -11,19 +11,221 @@ import (
 	"github.com/sqlc-dev/pqtype"
 )
 
+type AuditEvent struct {
+	ID         int32
+	ActorID    sql.NullInt32
+	Action     string
+	TargetType sql.NullString
+	TargetID   sql.NullInt32
+	Ip         sql.NullString
+	UserAgent  sql.NullString
+	RequestID  sql.NullString
+	Details    pqtype.NullRawMessage
+	CreateAt   time.Time
+}
+
+type AuditSetting struct {
+	ID        bool
+	Retention int64
+}
+
+type BodyMeasurement struct {
+	ID             int32
+	UserID         int32
+	MeasuredOn     time.Time
+	WeightKg       sql.NullFloat64
+	BodyFatPercent sql.NullFloat64
+	NeckCm         sql.NullFloat64
+	ChestCm        sql.NullFloat64
+	WaistCm        sql.NullFloat64
+	HipsCm         sql.NullFloat64
+	ArmCm          sql.NullFloat64
+	ThighCm        sql.NullFloat64
+	CalfCm         sql.NullFloat64
+	Notes          sql.NullString
+	CreateAt       time.Time
+	UpdateAt       time.Time
+}
+
+type EmailVerificationToken struct {
+	ID        int32
+	UserID    int32
+	Email     string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+	CreateAt  time.Time
+}
+
+type Exercise struct {
+	ID           int32
+	WorkoutID    int32
+	Name         string
+	Notes        sql.NullString
+	Position     int32
+	CreateAt     time.Time
+	DefinitionID sql.NullInt32
+}
+
+type ExerciseDefinition struct {
+	ID               int32
+	UserID           sql.NullInt32
+	Name             string
+	Aliases          []string
+	PrimaryMuscles   []string
+	SecondaryMuscles []string
+	Equipment        string
+	MovementPattern  string
+	Unilateral       bool
+	Kind             string
+	CreateAt         time.Time
+}
+
+type LoginThrottle struct {
+	Key         string
+	Failures    int32
+	LastFailure time.Time
+	LockedUntil sql.NullTime
+}
+
 type PasswordResetToken struct {
+	ID        int32
+	UserID    int32
+	TokenHash string
+	ExpiresAt time.Time
+	CreateAt  time.Time
+}
+
+type Permission struct {
+	Name        string
+	Description string
+}
+
+type PersonalRecord struct {
+	ID              int32
+	UserID          int32
+	DefinitionID    int32
+	RecordType      string
+	Value           float64
+	PreviousValue   sql.NullFloat64
+	Weight          sql.NullFloat64
+	Repetitions     sql.NullInt32
+	DistanceMeters  sql.NullFloat64
+	DurationSeconds sql.NullInt32
+	WorkoutID       sql.NullInt32
+	SetID           sql.NullInt32
+	AchievedAt      time.Time
+}
+
+type Program struct {
+	ID                    int32
+	UserID                int32
+	Name                  string
+	Description           sql.NullString
+	Weeks                 int32
+	WeeklyWeightIncrement float64
+	DeloadEveryWeeks      sql.NullInt32
+	DeloadFactor          float64
+	CreateAt              time.Time
+	UpdateAt              time.Time
+}
+
+type ProgramEnrollment struct {
+	ID        int32
+	UserID    int32
+	ProgramID int32
+	StartDate time.Time
+	CreateAt  time.Time
+}
+
+type ProgramSession struct {
 	ID         int32
-	UserID     sql.NullInt32
-	Token      string
-	Expiration time.Time
+	ProgramID  int32
+	Week       sql.NullInt32
+	Day        int32
+	TemplateID int32
+}
+
+type RefreshToken struct {
+	ID        int32
+	UserID    int32
+	FamilyID  string
+	TokenHash string
+	ExpiresAt time.Time
+	RevokedAt sql.NullTime
+	CreateAt  time.Time
+}
+
+type Role struct {
+	Name        string
+	Description string
+}
+
+type RolePermission struct {
+	Role       string
+	Permission string
+}
+
+type Set struct {
+	ID              int32
+	ExerciseID      int32
+	Position        int32
+	Repetitions     sql.NullInt32
+	Weight          sql.NullFloat64
+	DurationSeconds sql.NullInt32
+	DistanceMeters  sql.NullFloat64
+	Rpe             sql.NullFloat64
+	RestSeconds     sql.NullInt32
+	CreateAt        time.Time
+	TargetRepsMin   sql.NullInt32
+	TargetRepsMax   sql.NullInt32
+	TargetWeightMin sql.NullFloat64
+	TargetWeightMax sql.NullFloat64
+}
+
+type TemplateExercise struct {
+	ID              int32
+	TemplateID      int32
+	DefinitionID    int32
+	Position        int32
+	Notes           sql.NullString
+	TargetSets      int32
+	TargetRepsMin   sql.NullInt32
+	TargetRepsMax   sql.NullInt32
+	TargetWeightMin sql.NullFloat64
+	TargetWeightMax sql.NullFloat64
+	RestSeconds     sql.NullInt32
+}
+
+type TotpRecoveryCode struct {
+	ID       int32
+	UserID   int32
+	CodeHash string
+	CreateAt time.Time
 }
 
 type User struct {
-	ID           int32
-	Username     string
-	Email        string
-	PasswordHash string
-	Profile      pqtype.NullRawMessage
+	ID              int32
+	Username        string
+	Email           string
+	PasswordHash    string
+	Profile         pqtype.NullRawMessage
+	EmailVerifiedAt sql.NullTime
+	CreateAt        time.Time
+	TotpSecret      sql.NullString
+	TotpEnabledAt   sql.NullTime
+	TotpLastStep    sql.NullInt64
+	Role            string
+	SuspendedAt     sql.NullTime
+}
+
+type UserIdentityCleanup struct {
+	ID        int32
+	UserID    int32
+	Field     string
+	OldValue  string
+	NewValue  string
+	CleanedAt time.Time
 }
 
 type Workout struct {
@@ -34,4 +236,17 @@ type Workout struct {
 	Date        time.Time
 	CreateAt    time.Time
 	UpdateAt    time.Time
+	TemplateID  sql.NullInt32
+	StartedAt   time.Time
+	EndedAt     sql.NullTime
+	Timezone    string
+}
+
+type WorkoutTemplate struct {
+	ID          int32
+	UserID      int32
+	Name        string
+	Description sql.NullString
+	CreateAt    time.Time
+	UpdateAt    time.Time
 }
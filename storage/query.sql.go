@@ -10,13 +10,604 @@ import (
 	"database/sql"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/sqlc-dev/pqtype"
 )
 
+const changeUserEmail = `-- name: ChangeUserEmail :exec
+update users
+set email = $2, email_verified_at = now()
+where id = $1
+`
+
+type ChangeUserEmailParams struct {
+	ID    int32
+	Email string
+}
+
+func (q *Queries) ChangeUserEmail(ctx context.Context, arg ChangeUserEmailParams) error {
+	_, err := q.db.ExecContext(ctx, changeUserEmail, arg.ID, arg.Email)
+	return err
+}
+
+const clearUserPassword = `-- name: ClearUserPassword :exec
+update users
+set password_hash = ''
+where id = $1
+`
+
+func (q *Queries) ClearUserPassword(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, clearUserPassword, id)
+	return err
+}
+
+const createAuditEvent = `-- name: CreateAuditEvent :exec
+insert into audit_events (actor_id, action, target_type, target_id, ip, user_agent, request_id, details)
+values ($1, $2, $3, $4, $5, $6, $7, $8)
+`
+
+type CreateAuditEventParams struct {
+	ActorID    sql.NullInt32
+	Action     string
+	TargetType sql.NullString
+	TargetID   sql.NullInt32
+	Ip         sql.NullString
+	UserAgent  sql.NullString
+	RequestID  sql.NullString
+	Details    pqtype.NullRawMessage
+}
+
+func (q *Queries) CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) error {
+	_, err := q.db.ExecContext(ctx, createAuditEvent,
+		arg.ActorID,
+		arg.Action,
+		arg.TargetType,
+		arg.TargetID,
+		arg.Ip,
+		arg.UserAgent,
+		arg.RequestID,
+		arg.Details,
+	)
+	return err
+}
+
+const createBodyMeasurement = `-- name: CreateBodyMeasurement :one
+insert into body_measurements (user_id, measured_on, weight_kg, body_fat_percent, neck_cm, chest_cm, waist_cm, hips_cm, arm_cm, thigh_cm, calf_cm, notes)
+values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, coalesce((select started_at from workouts where id = $10), now()))
+returning id, user_id, measured_on, weight_kg, body_fat_percent, neck_cm, chest_cm, waist_cm, hips_cm, arm_cm, thigh_cm, calf_cm, notes, create_at, update_at
+`
+
+type CreateBodyMeasurementParams struct {
+	UserID         int32
+	MeasuredOn     time.Time
+	WeightKg       sql.NullFloat64
+	BodyFatPercent sql.NullFloat64
+	NeckCm         sql.NullFloat64
+	ChestCm        sql.NullFloat64
+	WaistCm        sql.NullFloat64
+	HipsCm         sql.NullFloat64
+	ArmCm          sql.NullFloat64
+	ThighCm        sql.NullFloat64
+	CalfCm         sql.NullFloat64
+	Notes          sql.NullString
+}
+
+func (q *Queries) CreateBodyMeasurement(ctx context.Context, arg CreateBodyMeasurementParams) (BodyMeasurement, error) {
+	row := q.db.QueryRowContext(ctx, createBodyMeasurement,
+		arg.UserID,
+		arg.MeasuredOn,
+		arg.WeightKg,
+		arg.BodyFatPercent,
+		arg.NeckCm,
+		arg.ChestCm,
+		arg.WaistCm,
+		arg.HipsCm,
+		arg.ArmCm,
+		arg.ThighCm,
+		arg.CalfCm,
+		arg.Notes,
+	)
+	var i BodyMeasurement
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.MeasuredOn,
+		&i.WeightKg,
+		&i.BodyFatPercent,
+		&i.NeckCm,
+		&i.ChestCm,
+		&i.WaistCm,
+		&i.HipsCm,
+		&i.ArmCm,
+		&i.ThighCm,
+		&i.CalfCm,
+		&i.Notes,
+		&i.CreateAt,
+		&i.UpdateAt,
+	)
+	return i, err
+}
+
+const createEmailVerificationToken = `-- name: CreateEmailVerificationToken :one
+insert into email_verification_tokens (user_id, email, token_hash, expires_at)
+values ($1, $2, $3, $4)
+returning id, user_id, email, token_hash, expires_at, used_at, create_at
+`
+
+type CreateEmailVerificationTokenParams struct {
+	UserID    int32
+	Email     string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreateEmailVerificationToken(ctx context.Context, arg CreateEmailVerificationTokenParams) (EmailVerificationToken, error) {
+	row := q.db.QueryRowContext(ctx, createEmailVerificationToken,
+		arg.UserID,
+		arg.Email,
+		arg.TokenHash,
+		arg.ExpiresAt,
+	)
+	var i EmailVerificationToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Email,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreateAt,
+	)
+	return i, err
+}
+
+const createExercise = `-- name: CreateExercise :one
+insert into exercises (workout_id, name, notes, position, definition_id)
+values ($1, $2, $3, (select coalesce(max(position), 0) + 1 from exercises where workout_id = $1), $4)
+returning id, workout_id, name, notes, position, create_at, definition_id
+`
+
+type CreateExerciseParams struct {
+	WorkoutID    int32
+	Name         string
+	Notes        sql.NullString
+	DefinitionID sql.NullInt32
+}
+
+func (q *Queries) CreateExercise(ctx context.Context, arg CreateExerciseParams) (Exercise, error) {
+	row := q.db.QueryRowContext(ctx, createExercise,
+		arg.WorkoutID,
+		arg.Name,
+		arg.Notes,
+		arg.DefinitionID,
+	)
+	var i Exercise
+	err := row.Scan(
+		&i.ID,
+		&i.WorkoutID,
+		&i.Name,
+		&i.Notes,
+		&i.Position,
+		&i.CreateAt,
+		&i.DefinitionID,
+	)
+	return i, err
+}
+
+const createExerciseDefinition = `-- name: CreateExerciseDefinition :one
+insert into exercise_definitions (user_id, name, aliases, primary_muscles, secondary_muscles, equipment, movement_pattern, unilateral, kind)
+values ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+returning id, user_id, name, aliases, primary_muscles, secondary_muscles, equipment, movement_pattern, unilateral, kind, create_at
+`
+
+type CreateExerciseDefinitionParams struct {
+	UserID           sql.NullInt32
+	Name             string
+	Aliases          []string
+	PrimaryMuscles   []string
+	SecondaryMuscles []string
+	Equipment        string
+	MovementPattern  string
+	Unilateral       bool
+	Kind             string
+}
+
+func (q *Queries) CreateExerciseDefinition(ctx context.Context, arg CreateExerciseDefinitionParams) (ExerciseDefinition, error) {
+	row := q.db.QueryRowContext(ctx, createExerciseDefinition,
+		arg.UserID,
+		arg.Name,
+		pq.Array(arg.Aliases),
+		pq.Array(arg.PrimaryMuscles),
+		pq.Array(arg.SecondaryMuscles),
+		arg.Equipment,
+		arg.MovementPattern,
+		arg.Unilateral,
+		arg.Kind,
+	)
+	var i ExerciseDefinition
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		pq.Array(&i.Aliases),
+		pq.Array(&i.PrimaryMuscles),
+		pq.Array(&i.SecondaryMuscles),
+		&i.Equipment,
+		&i.MovementPattern,
+		&i.Unilateral,
+		&i.Kind,
+		&i.CreateAt,
+	)
+	return i, err
+}
+
+const createExercisesFromTemplate = `-- name: CreateExercisesFromTemplate :exec
+insert into exercises (workout_id, name, notes, position, definition_id)
+select $1, d.name, te.notes, te.position, te.definition_id
+from template_exercises te
+join exercise_definitions d on d.id = te.definition_id
+where te.template_id = $2
+`
+
+type CreateExercisesFromTemplateParams struct {
+	WorkoutID  int32
+	TemplateID int32
+}
+
+func (q *Queries) CreateExercisesFromTemplate(ctx context.Context, arg CreateExercisesFromTemplateParams) error {
+	_, err := q.db.ExecContext(ctx, createExercisesFromTemplate, arg.WorkoutID, arg.TemplateID)
+	return err
+}
+
+const createLoginThrottle = `-- name: CreateLoginThrottle :exec
+insert into login_throttles (key, failures, last_failure)
+values ($1, 0, $2)
+on conflict (key) do nothing
+`
+
+type CreateLoginThrottleParams struct {
+	Key         string
+	LastFailure time.Time
+}
+
+func (q *Queries) CreateLoginThrottle(ctx context.Context, arg CreateLoginThrottleParams) error {
+	_, err := q.db.ExecContext(ctx, createLoginThrottle, arg.Key, arg.LastFailure)
+	return err
+}
+
+const createPasswordResetToken = `-- name: CreatePasswordResetToken :exec
+insert into password_reset_tokens (user_id, token_hash, expires_at)
+values ($1, $2, $3)
+`
+
+type CreatePasswordResetTokenParams struct {
+	UserID    int32
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) error {
+	_, err := q.db.ExecContext(ctx, createPasswordResetToken, arg.UserID, arg.TokenHash, arg.ExpiresAt)
+	return err
+}
+
+const createPersonalRecord = `-- name: CreatePersonalRecord :one
+insert into personal_records (user_id, definition_id, record_type, value, previous_value, weight, repetitions, distance_meters, duration_seconds, workout_id, set_id, achieved_at)
+values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+returning id, user_id, definition_id, record_type, value, previous_value, weight, repetitions, distance_meters, duration_seconds, workout_id, set_id, achieved_at
+`
+
+type CreatePersonalRecordParams struct {
+	UserID          int32
+	DefinitionID    int32
+	RecordType      string
+	Value           float64
+	PreviousValue   sql.NullFloat64
+	Weight          sql.NullFloat64
+	Repetitions     sql.NullInt32
+	DistanceMeters  sql.NullFloat64
+	DurationSeconds sql.NullInt32
+	WorkoutID       sql.NullInt32
+	SetID           sql.NullInt32
+}
+
+func (q *Queries) CreatePersonalRecord(ctx context.Context, arg CreatePersonalRecordParams) (PersonalRecord, error) {
+	row := q.db.QueryRowContext(ctx, createPersonalRecord,
+		arg.UserID,
+		arg.DefinitionID,
+		arg.RecordType,
+		arg.Value,
+		arg.PreviousValue,
+		arg.Weight,
+		arg.Repetitions,
+		arg.DistanceMeters,
+		arg.DurationSeconds,
+		arg.WorkoutID,
+		arg.SetID,
+	)
+	var i PersonalRecord
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.DefinitionID,
+		&i.RecordType,
+		&i.Value,
+		&i.PreviousValue,
+		&i.Weight,
+		&i.Repetitions,
+		&i.DistanceMeters,
+		&i.DurationSeconds,
+		&i.WorkoutID,
+		&i.SetID,
+		&i.AchievedAt,
+	)
+	return i, err
+}
+
+const createPlannedSetsFromTemplate = `-- name: CreatePlannedSetsFromTemplate :exec
+insert into sets (exercise_id, position, rest_seconds, target_reps_min, target_reps_max, target_weight_min, target_weight_max)
+select e.id, g.n, te.rest_seconds, te.target_reps_min, te.target_reps_max, te.target_weight_min, te.target_weight_max
+from exercises e
+join template_exercises te on te.template_id = $2 and te.position = e.position
+cross join lateral generate_series(1, te.target_sets) as g(n)
+where e.workout_id = $1
+`
+
+type CreatePlannedSetsFromTemplateParams struct {
+	WorkoutID  int32
+	TemplateID int32
+}
+
+func (q *Queries) CreatePlannedSetsFromTemplate(ctx context.Context, arg CreatePlannedSetsFromTemplateParams) error {
+	_, err := q.db.ExecContext(ctx, createPlannedSetsFromTemplate, arg.WorkoutID, arg.TemplateID)
+	return err
+}
+
+const createProgram = `-- name: CreateProgram :one
+insert into programs (user_id, name, description, weeks, weekly_weight_increment, deload_every_weeks, deload_factor)
+values ($1, $2, $3, $4, $5, $6, $7)
+returning id, user_id, name, description, weeks, weekly_weight_increment, deload_every_weeks, deload_factor, create_at, update_at
+`
+
+type CreateProgramParams struct {
+	UserID                int32
+	Name                  string
+	Description           sql.NullString
+	Weeks                 int32
+	WeeklyWeightIncrement float64
+	DeloadEveryWeeks      sql.NullInt32
+	DeloadFactor          float64
+}
+
+func (q *Queries) CreateProgram(ctx context.Context, arg CreateProgramParams) (Program, error) {
+	row := q.db.QueryRowContext(ctx, createProgram,
+		arg.UserID,
+		arg.Name,
+		arg.Description,
+		arg.Weeks,
+		arg.WeeklyWeightIncrement,
+		arg.DeloadEveryWeeks,
+		arg.DeloadFactor,
+	)
+	var i Program
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Description,
+		&i.Weeks,
+		&i.WeeklyWeightIncrement,
+		&i.DeloadEveryWeeks,
+		&i.DeloadFactor,
+		&i.CreateAt,
+		&i.UpdateAt,
+	)
+	return i, err
+}
+
+const createProgramEnrollment = `-- name: CreateProgramEnrollment :one
+insert into program_enrollments (user_id, program_id, start_date)
+values ($1, $2, $3)
+returning id, user_id, program_id, start_date, create_at
+`
+
+type CreateProgramEnrollmentParams struct {
+	UserID    int32
+	ProgramID int32
+	StartDate time.Time
+}
+
+func (q *Queries) CreateProgramEnrollment(ctx context.Context, arg CreateProgramEnrollmentParams) (ProgramEnrollment, error) {
+	row := q.db.QueryRowContext(ctx, createProgramEnrollment, arg.UserID, arg.ProgramID, arg.StartDate)
+	var i ProgramEnrollment
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ProgramID,
+		&i.StartDate,
+		&i.CreateAt,
+	)
+	return i, err
+}
+
+const createProgramSession = `-- name: CreateProgramSession :one
+insert into program_sessions (program_id, week, day, template_id)
+values ($1, $2, $3, $4)
+returning id, program_id, week, day, template_id
+`
+
+type CreateProgramSessionParams struct {
+	ProgramID  int32
+	Week       sql.NullInt32
+	Day        int32
+	TemplateID int32
+}
+
+func (q *Queries) CreateProgramSession(ctx context.Context, arg CreateProgramSessionParams) (ProgramSession, error) {
+	row := q.db.QueryRowContext(ctx, createProgramSession,
+		arg.ProgramID,
+		arg.Week,
+		arg.Day,
+		arg.TemplateID,
+	)
+	var i ProgramSession
+	err := row.Scan(
+		&i.ID,
+		&i.ProgramID,
+		&i.Week,
+		&i.Day,
+		&i.TemplateID,
+	)
+	return i, err
+}
+
+const createRecoveryCode = `-- name: CreateRecoveryCode :exec
+insert into totp_recovery_codes (user_id, code_hash)
+values ($1, $2)
+`
+
+type CreateRecoveryCodeParams struct {
+	UserID   int32
+	CodeHash string
+}
+
+func (q *Queries) CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error {
+	_, err := q.db.ExecContext(ctx, createRecoveryCode, arg.UserID, arg.CodeHash)
+	return err
+}
+
+const createRefreshToken = `-- name: CreateRefreshToken :one
+insert into refresh_tokens (user_id, family_id, token_hash, expires_at)
+values ($1, $2, $3, $4)
+returning id, user_id, family_id, token_hash, expires_at, revoked_at, create_at
+`
+
+type CreateRefreshTokenParams struct {
+	UserID    int32
+	FamilyID  string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, createRefreshToken,
+		arg.UserID,
+		arg.FamilyID,
+		arg.TokenHash,
+		arg.ExpiresAt,
+	)
+	var i RefreshToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.FamilyID,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreateAt,
+	)
+	return i, err
+}
+
+const createSet = `-- name: CreateSet :one
+insert into sets (exercise_id, position, repetitions, weight, duration_seconds, distance_meters, rpe, rest_seconds)
+values ($1, (select coalesce(max(position), 0) + 1 from sets where exercise_id = $1), $2, $3, $4, $5, $6, $7)
+returning id, exercise_id, position, repetitions, weight, duration_seconds, distance_meters, rpe, rest_seconds, create_at, target_reps_min, target_reps_max, target_weight_min, target_weight_max
+`
+
+type CreateSetParams struct {
+	ExerciseID      int32
+	Repetitions     sql.NullInt32
+	Weight          sql.NullFloat64
+	DurationSeconds sql.NullInt32
+	DistanceMeters  sql.NullFloat64
+	Rpe             sql.NullFloat64
+	RestSeconds     sql.NullInt32
+}
+
+func (q *Queries) CreateSet(ctx context.Context, arg CreateSetParams) (Set, error) {
+	row := q.db.QueryRowContext(ctx, createSet,
+		arg.ExerciseID,
+		arg.Repetitions,
+		arg.Weight,
+		arg.DurationSeconds,
+		arg.DistanceMeters,
+		arg.Rpe,
+		arg.RestSeconds,
+	)
+	var i Set
+	err := row.Scan(
+		&i.ID,
+		&i.ExerciseID,
+		&i.Position,
+		&i.Repetitions,
+		&i.Weight,
+		&i.DurationSeconds,
+		&i.DistanceMeters,
+		&i.Rpe,
+		&i.RestSeconds,
+		&i.CreateAt,
+		&i.TargetRepsMin,
+		&i.TargetRepsMax,
+		&i.TargetWeightMin,
+		&i.TargetWeightMax,
+	)
+	return i, err
+}
+
+const createTemplateExercise = `-- name: CreateTemplateExercise :one
+insert into template_exercises (template_id, definition_id, position, notes, target_sets, target_reps_min, target_reps_max, target_weight_min, target_weight_max, rest_seconds)
+values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+returning id, template_id, definition_id, position, notes, target_sets, target_reps_min, target_reps_max, target_weight_min, target_weight_max, rest_seconds
+`
+
+type CreateTemplateExerciseParams struct {
+	TemplateID      int32
+	DefinitionID    int32
+	Position        int32
+	Notes           sql.NullString
+	TargetSets      int32
+	TargetRepsMin   sql.NullInt32
+	TargetRepsMax   sql.NullInt32
+	TargetWeightMin sql.NullFloat64
+	TargetWeightMax sql.NullFloat64
+	RestSeconds     sql.NullInt32
+}
+
+func (q *Queries) CreateTemplateExercise(ctx context.Context, arg CreateTemplateExerciseParams) (TemplateExercise, error) {
+	row := q.db.QueryRowContext(ctx, createTemplateExercise,
+		arg.TemplateID,
+		arg.DefinitionID,
+		arg.Position,
+		arg.Notes,
+		arg.TargetSets,
+		arg.TargetRepsMin,
+		arg.TargetRepsMax,
+		arg.TargetWeightMin,
+		arg.TargetWeightMax,
+		arg.RestSeconds,
+	)
+	var i TemplateExercise
+	err := row.Scan(
+		&i.ID,
+		&i.TemplateID,
+		&i.DefinitionID,
+		&i.Position,
+		&i.Notes,
+		&i.TargetSets,
+		&i.TargetRepsMin,
+		&i.TargetRepsMax,
+		&i.TargetWeightMin,
+		&i.TargetWeightMax,
+		&i.RestSeconds,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
 insert into users (username, password_hash, email, profile)
 values ($1, $2, $3, $4)
-returning id, username, email, password_hash, profile
+returning id, username, email, password_hash, profile, email_verified_at, create_at, totp_secret, totp_enabled_at, totp_last_step, role, suspended_at
 `
 
 type CreateUserParams struct {
@@ -40,14 +631,21 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.PasswordHash,
 		&i.Profile,
+		&i.EmailVerifiedAt,
+		&i.CreateAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.Role,
+		&i.SuspendedAt,
 	)
 	return i, err
 }
 
 const createWorkout = `-- name: CreateWorkout :one
-insert into workouts (user_id, name, description,date)
-values ($1, $2, $3, $4)
-returning id, user_id, name , description, date, create_at, update_at
+insert into workouts (user_id, name, description, date, template_id, started_at, ended_at, timezone)
+values ($1, $2, $3, $4, $5, $6, $7, $8)
+returning id, user_id, name, description, date, create_at, update_at, template_id, started_at, ended_at, timezone
 `
 
 type CreateWorkoutParams struct {
@@ -55,6 +653,10 @@ type CreateWorkoutParams struct {
 	Name        string
 	Description sql.NullString
 	Date        time.Time
+	TemplateID  sql.NullInt32
+	StartedAt   time.Time
+	EndedAt     sql.NullTime
+	Timezone    string
 }
 
 func (q *Queries) CreateWorkout(ctx context.Context, arg CreateWorkoutParams) (Workout, error) {
@@ -63,6 +665,10 @@ func (q *Queries) CreateWorkout(ctx context.Context, arg CreateWorkoutParams) (W
 		arg.Name,
 		arg.Description,
 		arg.Date,
+		arg.TemplateID,
+		arg.StartedAt,
+		arg.EndedAt,
+		arg.Timezone,
 	)
 	var i Workout
 	err := row.Scan(
@@ -73,122 +679,1764 @@ func (q *Queries) CreateWorkout(ctx context.Context, arg CreateWorkoutParams) (W
 		&i.Date,
 		&i.CreateAt,
 		&i.UpdateAt,
+		&i.TemplateID,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.Timezone,
 	)
 	return i, err
 }
 
-const deleteUser = `-- name: DeleteUser :exec
-delete from users
-where id = $1
+const createWorkoutTemplate = `-- name: CreateWorkoutTemplate :one
+insert into workout_templates (user_id, name, description)
+values ($1, $2, $3)
+returning id, user_id, name, description, create_at, update_at
 `
 
-func (q *Queries) DeleteUser(ctx context.Context, id int32) error {
-	_, err := q.db.ExecContext(ctx, deleteUser, id)
-	return err
+type CreateWorkoutTemplateParams struct {
+	UserID      int32
+	Name        string
+	Description sql.NullString
 }
 
-const deleteWorkout = `-- name: DeleteWorkout :exec
-delete from workouts
+func (q *Queries) CreateWorkoutTemplate(ctx context.Context, arg CreateWorkoutTemplateParams) (WorkoutTemplate, error) {
+	row := q.db.QueryRowContext(ctx, createWorkoutTemplate, arg.UserID, arg.Name, arg.Description)
+	var i WorkoutTemplate
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Description,
+		&i.CreateAt,
+		&i.UpdateAt,
+	)
+	return i, err
+}
+
+const deleteBodyMeasurement = `-- name: DeleteBodyMeasurement :execrows
+delete from body_measurements
 where id = $1 and user_id = $2
 `
 
-type DeleteWorkoutParams struct {
+type DeleteBodyMeasurementParams struct {
 	ID     int32
 	UserID int32
 }
 
-func (q *Queries) DeleteWorkout(ctx context.Context, arg DeleteWorkoutParams) error {
-	_, err := q.db.ExecContext(ctx, deleteWorkout, arg.ID, arg.UserID)
-	return err
+func (q *Queries) DeleteBodyMeasurement(ctx context.Context, arg DeleteBodyMeasurementParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteBodyMeasurement, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-const getPasswordResetToken = `-- name: GetPasswordResetToken :one
-select id, user_id, token, expiration from password_reset_tokens
-where token = $1 limit 1
+const deleteLoginThrottle = `-- name: DeleteLoginThrottle :exec
+delete from login_throttles
+where key = $1
 `
 
-func (q *Queries) GetPasswordResetToken(ctx context.Context, token string) (PasswordResetToken, error) {
-	row := q.db.QueryRowContext(ctx, getPasswordResetToken, token)
-	var i PasswordResetToken
-	err := row.Scan(
-		&i.ID,
-		&i.UserID,
-		&i.Token,
-		&i.Expiration,
-	)
-	return i, err
+func (q *Queries) DeleteLoginThrottle(ctx context.Context, key string) error {
+	_, err := q.db.ExecContext(ctx, deleteLoginThrottle, key)
+	return err
 }
 
-const getUser = `-- name: GetUser :one
-select id, username, email, password_hash, profile from users
-where id = $1 limit 1
+const deletePasswordResetTokens = `-- name: DeletePasswordResetTokens :exec
+delete from password_reset_tokens
+where user_id = $1
 `
 
-func (q *Queries) GetUser(ctx context.Context, id int32) (User, error) {
-	row := q.db.QueryRowContext(ctx, getUser, id)
-	var i User
+func (q *Queries) DeletePasswordResetTokens(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, deletePasswordResetTokens, userID)
+	return err
+}
+
+const deleteProgram = `-- name: DeleteProgram :execrows
+delete from programs
+where id = $1 and user_id = $2
+`
+
+type DeleteProgramParams struct {
+	ID     int32
+	UserID int32
+}
+
+func (q *Queries) DeleteProgram(ctx context.Context, arg DeleteProgramParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteProgram, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteProgramEnrollment = `-- name: DeleteProgramEnrollment :execrows
+delete from program_enrollments
+where id = $1 and user_id = $2
+`
+
+type DeleteProgramEnrollmentParams struct {
+	ID     int32
+	UserID int32
+}
+
+func (q *Queries) DeleteProgramEnrollment(ctx context.Context, arg DeleteProgramEnrollmentParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteProgramEnrollment, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteRecoveryCodes = `-- name: DeleteRecoveryCodes :exec
+delete from totp_recovery_codes
+where user_id = $1
+`
+
+func (q *Queries) DeleteRecoveryCodes(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteRecoveryCodes, userID)
+	return err
+}
+
+const deleteSetPersonalRecords = `-- name: DeleteSetPersonalRecords :exec
+delete from personal_records
+where set_id = $1 or (record_type = 'session_volume' and workout_id = $2 and definition_id = $3)
+`
+
+type DeleteSetPersonalRecordsParams struct {
+	SetID        sql.NullInt32
+	WorkoutID    sql.NullInt32
+	DefinitionID int32
+}
+
+func (q *Queries) DeleteSetPersonalRecords(ctx context.Context, arg DeleteSetPersonalRecordsParams) error {
+	_, err := q.db.ExecContext(ctx, deleteSetPersonalRecords, arg.SetID, arg.WorkoutID, arg.DefinitionID)
+	return err
+}
+
+const deleteTemplateExercises = `-- name: DeleteTemplateExercises :exec
+delete from template_exercises
+where template_id = $1
+`
+
+func (q *Queries) DeleteTemplateExercises(ctx context.Context, templateID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteTemplateExercises, templateID)
+	return err
+}
+
+const deleteUser = `-- name: DeleteUser :exec
+delete from users
+where id = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, deleteUser, id)
+	return err
+}
+
+const deleteWorkout = `-- name: DeleteWorkout :one
+delete from workouts
+where id = $1 and user_id = $2
+returning id, user_id, name, description, date, create_at, update_at, template_id, started_at, ended_at, timezone
+`
+
+type DeleteWorkoutParams struct {
+	ID     int32
+	UserID int32
+}
+
+func (q *Queries) DeleteWorkout(ctx context.Context, arg DeleteWorkoutParams) (Workout, error) {
+	row := q.db.QueryRowContext(ctx, deleteWorkout, arg.ID, arg.UserID)
+	var i Workout
 	err := row.Scan(
 		&i.ID,
-		&i.Username,
-		&i.Email,
-		&i.PasswordHash,
-		&i.Profile,
+		&i.UserID,
+		&i.Name,
+		&i.Description,
+		&i.Date,
+		&i.CreateAt,
+		&i.UpdateAt,
+		&i.TemplateID,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.Timezone,
 	)
 	return i, err
 }
 
-const getUserByEmail = `-- name: GetUserByEmail :one
-select id, username, email, password_hash, profile from users
-where email = $1 limit 1
+const deleteWorkoutPersonalRecords = `-- name: DeleteWorkoutPersonalRecords :exec
+delete from personal_records
+where workout_id = $1 and user_id = $2
 `
 
-func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
-	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
-	var i User
+type DeleteWorkoutPersonalRecordsParams struct {
+	WorkoutID sql.NullInt32
+	UserID    int32
+}
+
+func (q *Queries) DeleteWorkoutPersonalRecords(ctx context.Context, arg DeleteWorkoutPersonalRecordsParams) error {
+	_, err := q.db.ExecContext(ctx, deleteWorkoutPersonalRecords, arg.WorkoutID, arg.UserID)
+	return err
+}
+
+const deleteWorkoutTemplate = `-- name: DeleteWorkoutTemplate :execrows
+delete from workout_templates
+where id = $1 and user_id = $2
+`
+
+type DeleteWorkoutTemplateParams struct {
+	ID     int32
+	UserID int32
+}
+
+func (q *Queries) DeleteWorkoutTemplate(ctx context.Context, arg DeleteWorkoutTemplateParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteWorkoutTemplate, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const disableTOTP = `-- name: DisableTOTP :exec
+update users
+set totp_secret = null, totp_enabled_at = null, totp_last_step = null
+where id = $1
+`
+
+func (q *Queries) DisableTOTP(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, disableTOTP, id)
+	return err
+}
+
+const emailTaken = `-- name: EmailTaken :one
+select exists (
+    select 1 from users
+    where lower(email) = lower($1) and id <> $2::int
+)
+`
+
+type EmailTakenParams struct {
+	Lower    string
+	ExceptID int32
+}
+
+func (q *Queries) EmailTaken(ctx context.Context, arg EmailTakenParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, emailTaken, arg.Lower, arg.ExceptID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const enableTOTP = `-- name: EnableTOTP :execrows
+update users
+set totp_enabled_at = now(), totp_last_step = $2
+where id = $1 and totp_secret is not null and totp_enabled_at is null
+`
+
+type EnableTOTPParams struct {
+	ID           int32
+	TotpLastStep sql.NullInt64
+}
+
+func (q *Queries) EnableTOTP(ctx context.Context, arg EnableTOTPParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, enableTOTP, arg.ID, arg.TotpLastStep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getBestSessionVolume = `-- name: GetBestSessionVolume :one
+select e.workout_id, sum(s.weight * s.repetitions)::double precision as volume
+from sets s
+join exercises e on e.id = s.exercise_id
+join workouts w on w.id = e.workout_id
+where w.user_id = $1 and e.definition_id = $2 and s.weight > 0 and s.repetitions > 0
+group by e.workout_id, w.started_at
+order by volume desc, w.started_at, e.workout_id
+limit 1
+`
+
+type GetBestSessionVolumeParams struct {
+	UserID       int32
+	DefinitionID sql.NullInt32
+}
+
+type GetBestSessionVolumeRow struct {
+	WorkoutID int32
+	Volume    float64
+}
+
+func (q *Queries) GetBestSessionVolume(ctx context.Context, arg GetBestSessionVolumeParams) (GetBestSessionVolumeRow, error) {
+	row := q.db.QueryRowContext(ctx, getBestSessionVolume, arg.UserID, arg.DefinitionID)
+	var i GetBestSessionVolumeRow
+	err := row.Scan(
+		&i.WorkoutID,
+		&i.Volume,
+	)
+	return i, err
+}
+
+const getBodyMeasurement = `-- name: GetBodyMeasurement :one
+select id, user_id, measured_on, weight_kg, body_fat_percent, neck_cm, chest_cm, waist_cm, hips_cm, arm_cm, thigh_cm, calf_cm, notes, create_at, update_at from body_measurements
+where id = $1 and user_id = $2
+`
+
+type GetBodyMeasurementParams struct {
+	ID     int32
+	UserID int32
+}
+
+func (q *Queries) GetBodyMeasurement(ctx context.Context, arg GetBodyMeasurementParams) (BodyMeasurement, error) {
+	row := q.db.QueryRowContext(ctx, getBodyMeasurement, arg.ID, arg.UserID)
+	var i BodyMeasurement
 	err := row.Scan(
 		&i.ID,
-		&i.Username,
-		&i.Email,
-		&i.PasswordHash,
-		&i.Profile,
+		&i.UserID,
+		&i.MeasuredOn,
+		&i.WeightKg,
+		&i.BodyFatPercent,
+		&i.NeckCm,
+		&i.ChestCm,
+		&i.WaistCm,
+		&i.HipsCm,
+		&i.ArmCm,
+		&i.ThighCm,
+		&i.CalfCm,
+		&i.Notes,
+		&i.CreateAt,
+		&i.UpdateAt,
+	)
+	return i, err
+}
+
+const getCurrentPersonalRecords = `-- name: GetCurrentPersonalRecords :many
+select distinct on (record_type,
+                    case record_type when 'reps_at_weight' then weight when 'fastest_time' then distance_meters end)
+       id, user_id, definition_id, record_type, value, previous_value, weight, repetitions, distance_meters, duration_seconds, workout_id, set_id, achieved_at
+from personal_records
+where user_id = $1 and definition_id = $2
+order by record_type,
+         case record_type when 'reps_at_weight' then weight when 'fastest_time' then distance_meters end,
+         id desc
+`
+
+type GetCurrentPersonalRecordsParams struct {
+	UserID       int32
+	DefinitionID int32
+}
+
+func (q *Queries) GetCurrentPersonalRecords(ctx context.Context, arg GetCurrentPersonalRecordsParams) ([]PersonalRecord, error) {
+	rows, err := q.db.QueryContext(ctx, getCurrentPersonalRecords, arg.UserID, arg.DefinitionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PersonalRecord
+	for rows.Next() {
+		var i PersonalRecord
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.DefinitionID,
+			&i.RecordType,
+			&i.Value,
+			&i.PreviousValue,
+			&i.Weight,
+			&i.Repetitions,
+			&i.DistanceMeters,
+			&i.DurationSeconds,
+			&i.WorkoutID,
+			&i.SetID,
+			&i.AchievedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getExerciseByUserID = `-- name: GetExerciseByUserID :one
+select e.id, e.workout_id, e.name, e.notes, e.position, e.create_at, e.definition_id
+from exercises e
+join workouts w on w.id = e.workout_id
+where e.id = $1 and w.user_id = $2
+`
+
+type GetExerciseByUserIDParams struct {
+	ID     int32
+	UserID int32
+}
+
+func (q *Queries) GetExerciseByUserID(ctx context.Context, arg GetExerciseByUserIDParams) (Exercise, error) {
+	row := q.db.QueryRowContext(ctx, getExerciseByUserID, arg.ID, arg.UserID)
+	var i Exercise
+	err := row.Scan(
+		&i.ID,
+		&i.WorkoutID,
+		&i.Name,
+		&i.Notes,
+		&i.Position,
+		&i.CreateAt,
+		&i.DefinitionID,
+	)
+	return i, err
+}
+
+const getExerciseDefinition = `-- name: GetExerciseDefinition :one
+select id, user_id, name, aliases, primary_muscles, secondary_muscles, equipment, movement_pattern, unilateral, kind, create_at from exercise_definitions
+where id = $1 and (user_id is null or user_id = $2)
+`
+
+type GetExerciseDefinitionParams struct {
+	ID     int32
+	UserID sql.NullInt32
+}
+
+func (q *Queries) GetExerciseDefinition(ctx context.Context, arg GetExerciseDefinitionParams) (ExerciseDefinition, error) {
+	row := q.db.QueryRowContext(ctx, getExerciseDefinition, arg.ID, arg.UserID)
+	var i ExerciseDefinition
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		pq.Array(&i.Aliases),
+		pq.Array(&i.PrimaryMuscles),
+		pq.Array(&i.SecondaryMuscles),
+		&i.Equipment,
+		&i.MovementPattern,
+		&i.Unilateral,
+		&i.Kind,
+		&i.CreateAt,
+	)
+	return i, err
+}
+
+const getExercisesByWorkoutID = `-- name: GetExercisesByWorkoutID :many
+select id, workout_id, name, notes, position, create_at, definition_id from exercises
+where workout_id = $1
+order by position, id
+`
+
+func (q *Queries) GetExercisesByWorkoutID(ctx context.Context, workoutID int32) ([]Exercise, error) {
+	rows, err := q.db.QueryContext(ctx, getExercisesByWorkoutID, workoutID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Exercise
+	for rows.Next() {
+		var i Exercise
+		if err := rows.Scan(
+			&i.ID,
+			&i.WorkoutID,
+			&i.Name,
+			&i.Notes,
+			&i.Position,
+			&i.CreateAt,
+			&i.DefinitionID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLastEmailVerificationSentAt = `-- name: GetLastEmailVerificationSentAt :one
+select max(create_at)::timestamptz as sent_at
+from email_verification_tokens
+where user_id = $1
+`
+
+func (q *Queries) GetLastEmailVerificationSentAt(ctx context.Context, userID int32) (sql.NullTime, error) {
+	row := q.db.QueryRowContext(ctx, getLastEmailVerificationSentAt, userID)
+	var sent_at sql.NullTime
+	err := row.Scan(&sent_at)
+	return sent_at, err
+}
+
+const getLoginThrottleForUpdate = `-- name: GetLoginThrottleForUpdate :one
+select key, failures, last_failure, locked_until from login_throttles
+where key = $1
+for update
+`
+
+func (q *Queries) GetLoginThrottleForUpdate(ctx context.Context, key string) (LoginThrottle, error) {
+	row := q.db.QueryRowContext(ctx, getLoginThrottleForUpdate, key)
+	var i LoginThrottle
+	err := row.Scan(
+		&i.Key,
+		&i.Failures,
+		&i.LastFailure,
+		&i.LockedUntil,
 	)
 	return i, err
 }
 
-const getWorkoutByUserID = `-- name: GetWorkoutByUserID :one
-select id, user_id, name, description, date, create_at, update_at
-from workouts
-where id = $1 and user_id = $2
+const getPersonalRecordHistory = `-- name: GetPersonalRecordHistory :many
+select id, user_id, definition_id, record_type, value, previous_value, weight, repetitions, distance_meters, duration_seconds, workout_id, set_id, achieved_at from personal_records
+where user_id = $1 and definition_id = $2
+order by achieved_at desc, id desc
+`
+
+type GetPersonalRecordHistoryParams struct {
+	UserID       int32
+	DefinitionID int32
+}
+
+func (q *Queries) GetPersonalRecordHistory(ctx context.Context, arg GetPersonalRecordHistoryParams) ([]PersonalRecord, error) {
+	rows, err := q.db.QueryContext(ctx, getPersonalRecordHistory, arg.UserID, arg.DefinitionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PersonalRecord
+	for rows.Next() {
+		var i PersonalRecord
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.DefinitionID,
+			&i.RecordType,
+			&i.Value,
+			&i.PreviousValue,
+			&i.Weight,
+			&i.Repetitions,
+			&i.DistanceMeters,
+			&i.DurationSeconds,
+			&i.WorkoutID,
+			&i.SetID,
+			&i.AchievedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getProgram = `-- name: GetProgram :one
+select id, user_id, name, description, weeks, weekly_weight_increment, deload_every_weeks, deload_factor, create_at, update_at from programs
+where id = $1 and user_id = $2
+`
+
+type GetProgramParams struct {
+	ID     int32
+	UserID int32
+}
+
+func (q *Queries) GetProgram(ctx context.Context, arg GetProgramParams) (Program, error) {
+	row := q.db.QueryRowContext(ctx, getProgram, arg.ID, arg.UserID)
+	var i Program
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Description,
+		&i.Weeks,
+		&i.WeeklyWeightIncrement,
+		&i.DeloadEveryWeeks,
+		&i.DeloadFactor,
+		&i.CreateAt,
+		&i.UpdateAt,
+	)
+	return i, err
+}
+
+const getProgramSessions = `-- name: GetProgramSessions :many
+select id, program_id, week, day, template_id from program_sessions
+where program_id = $1
+order by week nulls first, day, id
+`
+
+func (q *Queries) GetProgramSessions(ctx context.Context, programID int32) ([]ProgramSession, error) {
+	rows, err := q.db.QueryContext(ctx, getProgramSessions, programID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProgramSession
+	for rows.Next() {
+		var i ProgramSession
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProgramID,
+			&i.Week,
+			&i.Day,
+			&i.TemplateID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRefreshTokenByHash = `-- name: GetRefreshTokenByHash :one
+select id, user_id, family_id, token_hash, expires_at, revoked_at, create_at from refresh_tokens
+where token_hash = $1 limit 1
+`
+
+func (q *Queries) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, getRefreshTokenByHash, tokenHash)
+	var i RefreshToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.FamilyID,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreateAt,
+	)
+	return i, err
+}
+
+const getRole = `-- name: GetRole :one
+select name, description from roles
+where name = $1
+`
+
+func (q *Queries) GetRole(ctx context.Context, name string) (Role, error) {
+	row := q.db.QueryRowContext(ctx, getRole, name)
+	var i Role
+	err := row.Scan(
+		&i.Name,
+		&i.Description,
+	)
+	return i, err
+}
+
+const getScheduledSessions = `-- name: GetScheduledSessions :many
+select pe.id as enrollment_id, pe.start_date, p.id as program_id, p.name as program_name, p.weeks,
+       p.weekly_weight_increment, p.deload_every_weeks, p.deload_factor,
+       ps.week, ps.day, ps.template_id, t.name as template_name
+from program_enrollments pe
+join programs p on p.id = pe.program_id
+join program_sessions ps on ps.program_id = p.id
+join workout_templates t on t.id = ps.template_id
+where pe.user_id = $1
+  and pe.start_date <= $2::date
+  and pe.start_date + p.weeks * 7 > $3::date
+order by pe.id, ps.week nulls first, ps.day
+`
+
+type GetScheduledSessionsParams struct {
+	UserID   int32
+	ToDate   time.Time
+	FromDate time.Time
+}
+
+type GetScheduledSessionsRow struct {
+	EnrollmentID          int32
+	StartDate             time.Time
+	ProgramID             int32
+	ProgramName           string
+	Weeks                 int32
+	WeeklyWeightIncrement float64
+	DeloadEveryWeeks      sql.NullInt32
+	DeloadFactor          float64
+	Week                  sql.NullInt32
+	Day                   int32
+	TemplateID            int32
+	TemplateName          string
+}
+
+func (q *Queries) GetScheduledSessions(ctx context.Context, arg GetScheduledSessionsParams) ([]GetScheduledSessionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getScheduledSessions, arg.UserID, arg.ToDate, arg.FromDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetScheduledSessionsRow
+	for rows.Next() {
+		var i GetScheduledSessionsRow
+		if err := rows.Scan(
+			&i.EnrollmentID,
+			&i.StartDate,
+			&i.ProgramID,
+			&i.ProgramName,
+			&i.Weeks,
+			&i.WeeklyWeightIncrement,
+			&i.DeloadEveryWeeks,
+			&i.DeloadFactor,
+			&i.Week,
+			&i.Day,
+			&i.TemplateID,
+			&i.TemplateName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSessionStats = `-- name: GetSessionStats :many
+select date_trunc($2::text, w.date)::date as bucket_start,
+       count(*)::int as sessions,
+       coalesce(avg(coalesce(extract(epoch from w.ended_at - w.started_at), ws.duration_seconds)), 0)::double precision as avg_duration_seconds
+from workouts w
+left join lateral (
+    select extract(epoch from max(s.create_at) - min(s.create_at)) as duration_seconds
+    from exercises e
+    join sets s on s.exercise_id = e.id
+    where e.workout_id = w.id
+) ws on true
+where w.user_id = $1
+  and w.date between $3::date and $4::date
+group by 1
+order by 1
+`
+
+type GetSessionStatsParams struct {
+	UserID   int32
+	Bucket   string
+	FromDate time.Time
+	ToDate   time.Time
+}
+
+type GetSessionStatsRow struct {
+	BucketStart        time.Time
+	Sessions           int32
+	AvgDurationSeconds float64
+}
+
+func (q *Queries) GetSessionStats(ctx context.Context, arg GetSessionStatsParams) ([]GetSessionStatsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSessionStats,
+		arg.UserID,
+		arg.Bucket,
+		arg.FromDate,
+		arg.ToDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSessionStatsRow
+	for rows.Next() {
+		var i GetSessionStatsRow
+		if err := rows.Scan(
+			&i.BucketStart,
+			&i.Sessions,
+			&i.AvgDurationSeconds,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSetsByWorkoutID = `-- name: GetSetsByWorkoutID :many
+select s.id, s.exercise_id, s.position, s.repetitions, s.weight, s.duration_seconds, s.distance_meters, s.rpe, s.rest_seconds, s.create_at,
+       s.target_reps_min, s.target_reps_max, s.target_weight_min, s.target_weight_max
+from sets s
+join exercises e on e.id = s.exercise_id
+where e.workout_id = $1
+order by s.exercise_id, s.position, s.id
+`
+
+func (q *Queries) GetSetsByWorkoutID(ctx context.Context, workoutID int32) ([]Set, error) {
+	rows, err := q.db.QueryContext(ctx, getSetsByWorkoutID, workoutID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Set
+	for rows.Next() {
+		var i Set
+		if err := rows.Scan(
+			&i.ID,
+			&i.ExerciseID,
+			&i.Position,
+			&i.Repetitions,
+			&i.Weight,
+			&i.DurationSeconds,
+			&i.DistanceMeters,
+			&i.Rpe,
+			&i.RestSeconds,
+			&i.CreateAt,
+			&i.TargetRepsMin,
+			&i.TargetRepsMax,
+			&i.TargetWeightMin,
+			&i.TargetWeightMax,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSetsPerMuscle = `-- name: GetSetsPerMuscle :many
+select m.muscle::text as muscle, sum(m.share)::double precision as sets
+from workouts w
+join exercises e on e.workout_id = w.id
+join exercise_definitions d on d.id = e.definition_id
+join sets s on s.exercise_id = e.id
+cross join lateral (
+    select unnest(d.primary_muscles), 1.0
+    union all
+    select unnest(d.secondary_muscles), 0.5
+) as m(muscle, share)
+where w.user_id = $1
+  and w.date between $2::date and $3::date
+  and (s.repetitions is not null or s.duration_seconds is not null)
+group by 1
+order by 1
+`
+
+type GetSetsPerMuscleParams struct {
+	UserID   int32
+	FromDate time.Time
+	ToDate   time.Time
+}
+
+type GetSetsPerMuscleRow struct {
+	Muscle string
+	Sets   float64
+}
+
+func (q *Queries) GetSetsPerMuscle(ctx context.Context, arg GetSetsPerMuscleParams) ([]GetSetsPerMuscleRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSetsPerMuscle, arg.UserID, arg.FromDate, arg.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSetsPerMuscleRow
+	for rows.Next() {
+		var i GetSetsPerMuscleRow
+		if err := rows.Scan(
+			&i.Muscle,
+			&i.Sets,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTemplateExercises = `-- name: GetTemplateExercises :many
+select id, template_id, definition_id, position, notes, target_sets, target_reps_min, target_reps_max, target_weight_min, target_weight_max, rest_seconds from template_exercises
+where template_id = $1
+order by position, id
+`
+
+func (q *Queries) GetTemplateExercises(ctx context.Context, templateID int32) ([]TemplateExercise, error) {
+	rows, err := q.db.QueryContext(ctx, getTemplateExercises, templateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TemplateExercise
+	for rows.Next() {
+		var i TemplateExercise
+		if err := rows.Scan(
+			&i.ID,
+			&i.TemplateID,
+			&i.DefinitionID,
+			&i.Position,
+			&i.Notes,
+			&i.TargetSets,
+			&i.TargetRepsMin,
+			&i.TargetRepsMax,
+			&i.TargetWeightMin,
+			&i.TargetWeightMax,
+			&i.RestSeconds,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUser = `-- name: GetUser :one
+select id, username, email, password_hash, profile, email_verified_at, create_at, totp_secret, totp_enabled_at, totp_last_step, role, suspended_at from users
+where id = $1 limit 1
+`
+
+func (q *Queries) GetUser(ctx context.Context, id int32) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Profile,
+		&i.EmailVerifiedAt,
+		&i.CreateAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.Role,
+		&i.SuspendedAt,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+select id, username, email, password_hash, profile, email_verified_at, create_at, totp_secret, totp_enabled_at, totp_last_step, role, suspended_at from users
+where lower(email) = lower($1) limit 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Profile,
+		&i.EmailVerifiedAt,
+		&i.CreateAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.Role,
+		&i.SuspendedAt,
+	)
+	return i, err
+}
+
+const getVolumeByExercise = `-- name: GetVolumeByExercise :many
+select date_trunc($2::text, w.date)::date as bucket_start,
+       e.definition_id, d.name,
+       sum(s.weight * s.repetitions)::double precision as tonnage,
+       count(*)::int as sets
+from workouts w
+join exercises e on e.workout_id = w.id
+join exercise_definitions d on d.id = e.definition_id
+join sets s on s.exercise_id = e.id
+where w.user_id = $1
+  and w.date between $3::date and $4::date
+  and s.weight is not null and s.repetitions is not null
+group by 1, e.definition_id, d.name
+order by 1, d.name
+`
+
+type GetVolumeByExerciseParams struct {
+	UserID   int32
+	Bucket   string
+	FromDate time.Time
+	ToDate   time.Time
+}
+
+type GetVolumeByExerciseRow struct {
+	BucketStart  time.Time
+	DefinitionID sql.NullInt32
+	Name         string
+	Tonnage      float64
+	Sets         int32
+}
+
+func (q *Queries) GetVolumeByExercise(ctx context.Context, arg GetVolumeByExerciseParams) ([]GetVolumeByExerciseRow, error) {
+	rows, err := q.db.QueryContext(ctx, getVolumeByExercise,
+		arg.UserID,
+		arg.Bucket,
+		arg.FromDate,
+		arg.ToDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetVolumeByExerciseRow
+	for rows.Next() {
+		var i GetVolumeByExerciseRow
+		if err := rows.Scan(
+			&i.BucketStart,
+			&i.DefinitionID,
+			&i.Name,
+			&i.Tonnage,
+			&i.Sets,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getVolumeByMuscle = `-- name: GetVolumeByMuscle :many
+select date_trunc($2::text, w.date)::date as bucket_start,
+       m.muscle::text as muscle,
+       sum(s.weight * s.repetitions)::double precision as tonnage,
+       count(*)::int as sets
+from workouts w
+join exercises e on e.workout_id = w.id
+join exercise_definitions d on d.id = e.definition_id
+join sets s on s.exercise_id = e.id
+cross join lateral unnest(d.primary_muscles) as m(muscle)
+where w.user_id = $1
+  and w.date between $3::date and $4::date
+  and s.weight is not null and s.repetitions is not null
+group by 1, 2
+order by 1, 2
+`
+
+type GetVolumeByMuscleParams struct {
+	UserID   int32
+	Bucket   string
+	FromDate time.Time
+	ToDate   time.Time
+}
+
+type GetVolumeByMuscleRow struct {
+	BucketStart time.Time
+	Muscle      string
+	Tonnage     float64
+	Sets        int32
+}
+
+func (q *Queries) GetVolumeByMuscle(ctx context.Context, arg GetVolumeByMuscleParams) ([]GetVolumeByMuscleRow, error) {
+	rows, err := q.db.QueryContext(ctx, getVolumeByMuscle,
+		arg.UserID,
+		arg.Bucket,
+		arg.FromDate,
+		arg.ToDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetVolumeByMuscleRow
+	for rows.Next() {
+		var i GetVolumeByMuscleRow
+		if err := rows.Scan(
+			&i.BucketStart,
+			&i.Muscle,
+			&i.Tonnage,
+			&i.Sets,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWorkoutByUserID = `-- name: GetWorkoutByUserID :one
+select id, user_id, name, description, date, create_at, update_at, template_id, started_at, ended_at, timezone
+from workouts
+where id = $1 and user_id = $2
+`
+
+type GetWorkoutByUserIDParams struct {
+	ID     int32
+	UserID int32
+}
+
+func (q *Queries) GetWorkoutByUserID(ctx context.Context, arg GetWorkoutByUserIDParams) (Workout, error) {
+	row := q.db.QueryRowContext(ctx, getWorkoutByUserID, arg.ID, arg.UserID)
+	var i Workout
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Description,
+		&i.Date,
+		&i.CreateAt,
+		&i.UpdateAt,
+		&i.TemplateID,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.Timezone,
+	)
+	return i, err
+}
+
+const getWorkoutExerciseVolume = `-- name: GetWorkoutExerciseVolume :one
+select coalesce(sum(s.weight * s.repetitions), 0)::double precision as volume
+from sets s
+join exercises e on e.id = s.exercise_id
+where e.workout_id = $1 and e.definition_id = $2
+`
+
+type GetWorkoutExerciseVolumeParams struct {
+	WorkoutID    int32
+	DefinitionID sql.NullInt32
+}
+
+func (q *Queries) GetWorkoutExerciseVolume(ctx context.Context, arg GetWorkoutExerciseVolumeParams) (float64, error) {
+	row := q.db.QueryRowContext(ctx, getWorkoutExerciseVolume, arg.WorkoutID, arg.DefinitionID)
+	var volume float64
+	err := row.Scan(&volume)
+	return volume, err
+}
+
+const getWorkoutTemplate = `-- name: GetWorkoutTemplate :one
+select id, user_id, name, description, create_at, update_at from workout_templates
+where id = $1 and user_id = $2
+`
+
+type GetWorkoutTemplateParams struct {
+	ID     int32
+	UserID int32
+}
+
+func (q *Queries) GetWorkoutTemplate(ctx context.Context, arg GetWorkoutTemplateParams) (WorkoutTemplate, error) {
+	row := q.db.QueryRowContext(ctx, getWorkoutTemplate, arg.ID, arg.UserID)
+	var i WorkoutTemplate
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Description,
+		&i.CreateAt,
+		&i.UpdateAt,
+	)
+	return i, err
+}
+
+const getWorkoutsByUserIDBetween = `-- name: GetWorkoutsByUserIDBetween :many
+select id, user_id, name, description, date, create_at, update_at, template_id, started_at, ended_at, timezone
+from workouts
+where user_id = $1 and date between $2::date and $3::date
+order by date, started_at, id
+`
+
+type GetWorkoutsByUserIDBetweenParams struct {
+	UserID   int32
+	FromDate time.Time
+	ToDate   time.Time
+}
+
+func (q *Queries) GetWorkoutsByUserIDBetween(ctx context.Context, arg GetWorkoutsByUserIDBetweenParams) ([]Workout, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkoutsByUserIDBetween, arg.UserID, arg.FromDate, arg.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Workout
+	for rows.Next() {
+		var i Workout
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Description,
+			&i.Date,
+			&i.CreateAt,
+			&i.UpdateAt,
+			&i.TemplateID,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.Timezone,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const hasPermission = `-- name: HasPermission :one
+select exists (
+    select 1 from role_permissions
+    where role = $1 and permission = $2
+)
+`
+
+type HasPermissionParams struct {
+	Role       string
+	Permission string
+}
+
+func (q *Queries) HasPermission(ctx context.Context, arg HasPermissionParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, hasPermission, arg.Role, arg.Permission)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listAuditEvents = `-- name: ListAuditEvents :many
+select id, actor_id, action, target_type, target_id, ip, user_agent, request_id, details, create_at from audit_events
+where ($1::int is null or actor_id = $1::int)
+  and ($2::text is null or action = $2::text)
+  and ($3::text is null or target_type = $3::text)
+  and ($4::int is null or target_id = $4::int)
+  and ($5::timestamptz is null or create_at >= $5::timestamptz)
+  and ($6::timestamptz is null or create_at < $6::timestamptz)
+  and ($7::timestamptz is null
+       or ($8::bool and (create_at, id) < ($7::timestamptz, $9::int))
+       or (not $8::bool and (create_at, id) > ($7::timestamptz, $9::int)))
+order by
+  case when not $8::bool then create_at end,
+  case when not $8::bool then id end,
+  case when $8::bool then create_at end desc,
+  case when $8::bool then id end desc
+limit $10
+`
+
+type ListAuditEventsParams struct {
+	ActorID    sql.NullInt32
+	Action     sql.NullString
+	TargetType sql.NullString
+	TargetID   sql.NullInt32
+	FromTime   sql.NullTime
+	ToTime     sql.NullTime
+	AfterKey   sql.NullTime
+	SortDesc   bool
+	AfterID    int32
+	PageLimit  int32
+}
+
+func (q *Queries) ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditEvents,
+		arg.ActorID,
+		arg.Action,
+		arg.TargetType,
+		arg.TargetID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.AfterKey,
+		arg.SortDesc,
+		arg.AfterID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditEvent
+	for rows.Next() {
+		var i AuditEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.ActorID,
+			&i.Action,
+			&i.TargetType,
+			&i.TargetID,
+			&i.Ip,
+			&i.UserAgent,
+			&i.RequestID,
+			&i.Details,
+			&i.CreateAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBodyMeasurements = `-- name: ListBodyMeasurements :many
+select id, user_id, measured_on, weight_kg, body_fat_percent, neck_cm, chest_cm, waist_cm, hips_cm, arm_cm, thigh_cm, calf_cm, notes, create_at, update_at from body_measurements
+where user_id = $1 and measured_on between $2::date and $3::date
+order by measured_on, id
+`
+
+type ListBodyMeasurementsParams struct {
+	UserID   int32
+	FromDate time.Time
+	ToDate   time.Time
+}
+
+func (q *Queries) ListBodyMeasurements(ctx context.Context, arg ListBodyMeasurementsParams) ([]BodyMeasurement, error) {
+	rows, err := q.db.QueryContext(ctx, listBodyMeasurements, arg.UserID, arg.FromDate, arg.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BodyMeasurement
+	for rows.Next() {
+		var i BodyMeasurement
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.MeasuredOn,
+			&i.WeightKg,
+			&i.BodyFatPercent,
+			&i.NeckCm,
+			&i.ChestCm,
+			&i.WaistCm,
+			&i.HipsCm,
+			&i.ArmCm,
+			&i.ThighCm,
+			&i.CalfCm,
+			&i.Notes,
+			&i.CreateAt,
+			&i.UpdateAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCurrentPersonalRecords = `-- name: ListCurrentPersonalRecords :many
+select distinct on (definition_id, record_type,
+                    case record_type when 'reps_at_weight' then weight when 'fastest_time' then distance_meters end)
+       id, user_id, definition_id, record_type, value, previous_value, weight, repetitions, distance_meters, duration_seconds, workout_id, set_id, achieved_at
+from personal_records
+where user_id = $1
+order by definition_id, record_type,
+         case record_type when 'reps_at_weight' then weight when 'fastest_time' then distance_meters end,
+         id desc
+`
+
+func (q *Queries) ListCurrentPersonalRecords(ctx context.Context, userID int32) ([]PersonalRecord, error) {
+	rows, err := q.db.QueryContext(ctx, listCurrentPersonalRecords, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PersonalRecord
+	for rows.Next() {
+		var i PersonalRecord
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.DefinitionID,
+			&i.RecordType,
+			&i.Value,
+			&i.PreviousValue,
+			&i.Weight,
+			&i.Repetitions,
+			&i.DistanceMeters,
+			&i.DurationSeconds,
+			&i.WorkoutID,
+			&i.SetID,
+			&i.AchievedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProgramEnrollments = `-- name: ListProgramEnrollments :many
+select id, user_id, program_id, start_date, create_at from program_enrollments
+where user_id = $1
+order by start_date, id
+`
+
+func (q *Queries) ListProgramEnrollments(ctx context.Context, userID int32) ([]ProgramEnrollment, error) {
+	rows, err := q.db.QueryContext(ctx, listProgramEnrollments, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProgramEnrollment
+	for rows.Next() {
+		var i ProgramEnrollment
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ProgramID,
+			&i.StartDate,
+			&i.CreateAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPrograms = `-- name: ListPrograms :many
+select id, user_id, name, description, weeks, weekly_weight_increment, deload_every_weeks, deload_factor, create_at, update_at from programs
+where user_id = $1
+order by name, id
+`
+
+func (q *Queries) ListPrograms(ctx context.Context, userID int32) ([]Program, error) {
+	rows, err := q.db.QueryContext(ctx, listPrograms, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Program
+	for rows.Next() {
+		var i Program
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Description,
+			&i.Weeks,
+			&i.WeeklyWeightIncrement,
+			&i.DeloadEveryWeeks,
+			&i.DeloadFactor,
+			&i.CreateAt,
+			&i.UpdateAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecordCandidateSets = `-- name: ListRecordCandidateSets :many
+select * from (
+    select distinct on (s.weight) s.id, s.weight, s.repetitions, s.distance_meters, s.duration_seconds, e.workout_id
+    from sets s
+    join exercises e on e.id = s.exercise_id
+    join workouts w on w.id = e.workout_id
+    where w.user_id = $1 and e.definition_id = $2 and s.weight > 0 and s.repetitions > 0
+    order by s.weight, s.repetitions desc, w.started_at, s.id
+) by_weight
+union all
+select * from (
+    select distinct on (s.distance_meters) s.id, s.weight, s.repetitions, s.distance_meters, s.duration_seconds, e.workout_id
+    from sets s
+    join exercises e on e.id = s.exercise_id
+    join workouts w on w.id = e.workout_id
+    where w.user_id = $1 and e.definition_id = $2 and s.distance_meters > 0 and s.duration_seconds > 0
+    order by s.distance_meters, s.duration_seconds, w.started_at, s.id
+) by_distance
+`
+
+type ListRecordCandidateSetsParams struct {
+	UserID       int32
+	DefinitionID sql.NullInt32
+}
+
+type ListRecordCandidateSetsRow struct {
+	ID              int32
+	Weight          sql.NullFloat64
+	Repetitions     sql.NullInt32
+	DistanceMeters  sql.NullFloat64
+	DurationSeconds sql.NullInt32
+	WorkoutID       int32
+}
+
+func (q *Queries) ListRecordCandidateSets(ctx context.Context, arg ListRecordCandidateSetsParams) ([]ListRecordCandidateSetsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listRecordCandidateSets, arg.UserID, arg.DefinitionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListRecordCandidateSetsRow
+	for rows.Next() {
+		var i ListRecordCandidateSetsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Weight,
+			&i.Repetitions,
+			&i.DistanceMeters,
+			&i.DurationSeconds,
+			&i.WorkoutID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUser = `-- name: ListUser :many
+select id, username, email, role, email_verified_at, suspended_at, create_at
+from users
+where ($1::text is null
+       or strpos(lower(username), lower($1::text)) > 0
+       or strpos(lower(email), lower($1::text)) > 0)
+  and ($2::text is null or role = $2::text)
+  and ($3::bool is null or (suspended_at is not null) = $3::bool)
+  and ($4::text is null
+       or ($5::bool and (username, id) < ($4::text, $6::int))
+       or (not $5::bool and (username, id) > ($4::text, $6::int)))
+order by
+  case when not $5::bool then username end,
+  case when not $5::bool then id end,
+  case when $5::bool then username end desc,
+  case when $5::bool then id end desc
+limit $7
+`
+
+type ListUserParams struct {
+	Query         sql.NullString
+	Role          sql.NullString
+	Suspended     sql.NullBool
+	AfterUsername sql.NullString
+	SortDesc      bool
+	AfterID       int32
+	PageLimit     int32
+}
+
+type ListUserRow struct {
+	ID              int32
+	Username        string
+	Email           string
+	Role            string
+	EmailVerifiedAt sql.NullTime
+	SuspendedAt     sql.NullTime
+	CreateAt        time.Time
+}
+
+func (q *Queries) ListUser(ctx context.Context, arg ListUserParams) ([]ListUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUser,
+		arg.Query,
+		arg.Role,
+		arg.Suspended,
+		arg.AfterUsername,
+		arg.SortDesc,
+		arg.AfterID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserRow
+	for rows.Next() {
+		var i ListUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Email,
+			&i.Role,
+			&i.EmailVerifiedAt,
+			&i.SuspendedAt,
+			&i.CreateAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWorkoutDefinitionIDs = `-- name: ListWorkoutDefinitionIDs :many
+select distinct definition_id from exercises
+where workout_id = $1 and definition_id is not null
+`
+
+func (q *Queries) ListWorkoutDefinitionIDs(ctx context.Context, workoutID int32) ([]sql.NullInt32, error) {
+	rows, err := q.db.QueryContext(ctx, listWorkoutDefinitionIDs, workoutID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []sql.NullInt32
+	for rows.Next() {
+		var definition_id sql.NullInt32
+		if err := rows.Scan(&definition_id); err != nil {
+			return nil, err
+		}
+		items = append(items, definition_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWorkoutTemplates = `-- name: ListWorkoutTemplates :many
+select id, user_id, name, description, create_at, update_at from workout_templates
+where user_id = $1
+order by name, id
+`
+
+func (q *Queries) ListWorkoutTemplates(ctx context.Context, userID int32) ([]WorkoutTemplate, error) {
+	rows, err := q.db.QueryContext(ctx, listWorkoutTemplates, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkoutTemplate
+	for rows.Next() {
+		var i WorkoutTemplate
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Description,
+			&i.CreateAt,
+			&i.UpdateAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWorkouts = `-- name: ListWorkouts :many
+select id, user_id, name, description, date, create_at, update_at, template_id, started_at, ended_at, timezone
+from workouts w
+where w.user_id = $1
+  and ($2::date is null or w.date >= $2::date)
+  and ($3::date is null or w.date <= $3::date)
+  and ($4::text is null or strpos(lower(w.name), lower($4::text)) > 0)
+  and ($5::int is null or w.template_id = $5::int)
+  and ($6::int is null or exists (
+      select 1 from exercises e
+      where e.workout_id = w.id and e.definition_id = $6::int
+  ))
+  and ($7::timestamptz is null
+       or ($8::bool
+           and (case when $9::text = 'updated' then w.update_at else w.started_at end, w.id) < ($7::timestamptz, $10::int))
+       or (not $8::bool
+           and (case when $9::text = 'updated' then w.update_at else w.started_at end, w.id) > ($7::timestamptz, $10::int)))
+order by
+  case when not $8::bool then (case when $9::text = 'updated' then w.update_at else w.started_at end) end,
+  case when not $8::bool then w.id end,
+  case when $8::bool then (case when $9::text = 'updated' then w.update_at else w.started_at end) end desc,
+  case when $8::bool then w.id end desc
+limit $11
 `
 
-type GetWorkoutByUserIDParams struct {
-	ID     int32
-	UserID int32
+type ListWorkoutsParams struct {
+	UserID       int32
+	FromDate     sql.NullTime
+	ToDate       sql.NullTime
+	Name         sql.NullString
+	TemplateID   sql.NullInt32
+	DefinitionID sql.NullInt32
+	AfterKey     sql.NullTime
+	SortDesc     bool
+	SortBy       string
+	AfterID      int32
+	PageLimit    int32
 }
 
-func (q *Queries) GetWorkoutByUserID(ctx context.Context, arg GetWorkoutByUserIDParams) (Workout, error) {
-	row := q.db.QueryRowContext(ctx, getWorkoutByUserID, arg.ID, arg.UserID)
-	var i Workout
-	err := row.Scan(
-		&i.ID,
-		&i.UserID,
-		&i.Name,
-		&i.Description,
-		&i.Date,
-		&i.CreateAt,
-		&i.UpdateAt,
+func (q *Queries) ListWorkouts(ctx context.Context, arg ListWorkoutsParams) ([]Workout, error) {
+	rows, err := q.db.QueryContext(ctx, listWorkouts,
+		arg.UserID,
+		arg.FromDate,
+		arg.ToDate,
+		arg.Name,
+		arg.TemplateID,
+		arg.DefinitionID,
+		arg.AfterKey,
+		arg.SortDesc,
+		arg.SortBy,
+		arg.AfterID,
+		arg.PageLimit,
 	)
-	return i, err
-}
-
-const getWorkoutsByUserID = `-- name: GetWorkoutsByUserID :many
-select id, user_id, name, description, date, create_at, update_at
-from workouts
-where user_id = $1
-`
-
-func (q *Queries) GetWorkoutsByUserID(ctx context.Context, userID int32) ([]Workout, error) {
-	rows, err := q.db.QueryContext(ctx, getWorkoutsByUserID, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -204,6 +2452,10 @@ func (q *Queries) GetWorkoutsByUserID(ctx context.Context, userID int32) ([]Work
 			&i.Date,
 			&i.CreateAt,
 			&i.UpdateAt,
+			&i.TemplateID,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.Timezone,
 		); err != nil {
 			return nil, err
 		}
@@ -218,33 +2470,127 @@ func (q *Queries) GetWorkoutsByUserID(ctx context.Context, userID int32) ([]Work
 	return items, nil
 }
 
-const listUser = `-- name: ListUser :many
-select id, username, email, profile
-from users
-order by username
+const purgeAuditEvents = `-- name: PurgeAuditEvents :execrows
+delete from audit_events
+where create_at < now() - (select retention from audit_settings)
 `
 
-type ListUserRow struct {
-	ID       int32
-	Username string
-	Email    string
-	Profile  pqtype.NullRawMessage
+func (q *Queries) PurgeAuditEvents(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeAuditEvents)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const purgeLoginThrottles = `-- name: PurgeLoginThrottles :execrows
+delete from login_throttles
+where last_failure < $1 and (locked_until is null or locked_until < $1)
+`
+
+func (q *Queries) PurgeLoginThrottles(ctx context.Context, lastFailure time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeLoginThrottles, lastFailure)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const purgeUnverifiedUsers = `-- name: PurgeUnverifiedUsers :execrows
+delete from users
+where email_verified_at is null and create_at < $1
+`
+
+func (q *Queries) PurgeUnverifiedUsers(ctx context.Context, createAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeUnverifiedUsers, createAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeRefreshToken = `-- name: RevokeRefreshToken :execrows
+update refresh_tokens
+set revoked_at = now()
+where id = $1 and revoked_at is null
+`
+
+func (q *Queries) RevokeRefreshToken(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeRefreshToken, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeRefreshTokenFamily = `-- name: RevokeRefreshTokenFamily :exec
+update refresh_tokens
+set revoked_at = now()
+where family_id = $1 and revoked_at is null
+`
+
+func (q *Queries) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	_, err := q.db.ExecContext(ctx, revokeRefreshTokenFamily, familyID)
+	return err
+}
+
+const revokeUserRefreshTokens = `-- name: RevokeUserRefreshTokens :exec
+update refresh_tokens
+set revoked_at = now()
+where user_id = $1 and revoked_at is null
+`
+
+func (q *Queries) RevokeUserRefreshTokens(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, revokeUserRefreshTokens, userID)
+	return err
+}
+
+const searchExerciseDefinitions = `-- name: SearchExerciseDefinitions :many
+select id, user_id, name, aliases, primary_muscles, secondary_muscles, equipment, movement_pattern, unilateral, kind, create_at from exercise_definitions
+where (user_id is null or user_id = $1::int)
+  and ($2::text = ''
+       or name ilike '%' || $2::text || '%'
+       or exists (select 1 from unnest(aliases) a where a ilike '%' || $2::text || '%'))
+  and ($3::text = ''
+       or $3::text = any(primary_muscles)
+       or $3::text = any(secondary_muscles))
+  and ($4::text = '' or equipment = $4::text)
+order by user_id nulls first, name
+`
+
+type SearchExerciseDefinitionsParams struct {
+	UserID    int32
+	Query     string
+	Muscle    string
+	Equipment string
 }
 
-func (q *Queries) ListUser(ctx context.Context) ([]ListUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, listUser)
+func (q *Queries) SearchExerciseDefinitions(ctx context.Context, arg SearchExerciseDefinitionsParams) ([]ExerciseDefinition, error) {
+	rows, err := q.db.QueryContext(ctx, searchExerciseDefinitions,
+		arg.UserID,
+		arg.Query,
+		arg.Muscle,
+		arg.Equipment,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListUserRow
+	var items []ExerciseDefinition
 	for rows.Next() {
-		var i ListUserRow
+		var i ExerciseDefinition
 		if err := rows.Scan(
 			&i.ID,
-			&i.Username,
-			&i.Email,
-			&i.Profile,
+			&i.UserID,
+			&i.Name,
+			pq.Array(&i.Aliases),
+			pq.Array(&i.PrimaryMuscles),
+			pq.Array(&i.SecondaryMuscles),
+			&i.Equipment,
+			&i.MovementPattern,
+			&i.Unilateral,
+			&i.Kind,
+			&i.CreateAt,
 		); err != nil {
 			return nil, err
 		}
@@ -259,19 +2605,144 @@ func (q *Queries) ListUser(ctx context.Context) ([]ListUserRow, error) {
 	return items, nil
 }
 
-const savePasswordResetToken = `-- name: SavePasswordResetToken :exec
-insert into password_reset_tokens (user_id, token, expiration)
-values ($1, $2, $3)
+const setAuditRetention = `-- name: SetAuditRetention :exec
+update audit_settings
+set retention = $1::bigint * interval '1 second'
+`
+
+func (q *Queries) SetAuditRetention(ctx context.Context, seconds int64) error {
+	_, err := q.db.ExecContext(ctx, setAuditRetention, seconds)
+	return err
+}
+
+const setTOTPSecret = `-- name: SetTOTPSecret :execrows
+update users
+set totp_secret = $2, totp_last_step = null
+where id = $1 and totp_enabled_at is null
+`
+
+type SetTOTPSecretParams struct {
+	ID         int32
+	TotpSecret sql.NullString
+}
+
+func (q *Queries) SetTOTPSecret(ctx context.Context, arg SetTOTPSecretParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setTOTPSecret, arg.ID, arg.TotpSecret)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const suspendUser = `-- name: SuspendUser :execrows
+update users
+set suspended_at = now()
+where id = $1 and suspended_at is null
+`
+
+func (q *Queries) SuspendUser(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, suspendUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const unsuspendUser = `-- name: UnsuspendUser :execrows
+update users
+set suspended_at = null
+where id = $1 and suspended_at is not null
+`
+
+func (q *Queries) UnsuspendUser(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, unsuspendUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateBodyMeasurement = `-- name: UpdateBodyMeasurement :one
+update body_measurements
+set measured_on = $3, weight_kg = $4, body_fat_percent = $5, neck_cm = $6, chest_cm = $7, waist_cm = $8,
+    hips_cm = $9, arm_cm = $10, thigh_cm = $11, calf_cm = $12, notes = $13, update_at = now()
+where id = $1 and user_id = $2
+returning id, user_id, measured_on, weight_kg, body_fat_percent, neck_cm, chest_cm, waist_cm, hips_cm, arm_cm, thigh_cm, calf_cm, notes, create_at, update_at
+`
+
+type UpdateBodyMeasurementParams struct {
+	ID             int32
+	UserID         int32
+	MeasuredOn     time.Time
+	WeightKg       sql.NullFloat64
+	BodyFatPercent sql.NullFloat64
+	NeckCm         sql.NullFloat64
+	ChestCm        sql.NullFloat64
+	WaistCm        sql.NullFloat64
+	HipsCm         sql.NullFloat64
+	ArmCm          sql.NullFloat64
+	ThighCm        sql.NullFloat64
+	CalfCm         sql.NullFloat64
+	Notes          sql.NullString
+}
+
+func (q *Queries) UpdateBodyMeasurement(ctx context.Context, arg UpdateBodyMeasurementParams) (BodyMeasurement, error) {
+	row := q.db.QueryRowContext(ctx, updateBodyMeasurement,
+		arg.ID,
+		arg.UserID,
+		arg.MeasuredOn,
+		arg.WeightKg,
+		arg.BodyFatPercent,
+		arg.NeckCm,
+		arg.ChestCm,
+		arg.WaistCm,
+		arg.HipsCm,
+		arg.ArmCm,
+		arg.ThighCm,
+		arg.CalfCm,
+		arg.Notes,
+	)
+	var i BodyMeasurement
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.MeasuredOn,
+		&i.WeightKg,
+		&i.BodyFatPercent,
+		&i.NeckCm,
+		&i.ChestCm,
+		&i.WaistCm,
+		&i.HipsCm,
+		&i.ArmCm,
+		&i.ThighCm,
+		&i.CalfCm,
+		&i.Notes,
+		&i.CreateAt,
+		&i.UpdateAt,
+	)
+	return i, err
+}
+
+const updateLoginThrottle = `-- name: UpdateLoginThrottle :exec
+update login_throttles
+set failures = $2, last_failure = $3, locked_until = $4
+where key = $1
 `
 
-type SavePasswordResetTokenParams struct {
-	UserID     sql.NullInt32
-	Token      string
-	Expiration time.Time
+type UpdateLoginThrottleParams struct {
+	Key         string
+	Failures    int32
+	LastFailure time.Time
+	LockedUntil sql.NullTime
 }
 
-func (q *Queries) SavePasswordResetToken(ctx context.Context, arg SavePasswordResetTokenParams) error {
-	_, err := q.db.ExecContext(ctx, savePasswordResetToken, arg.UserID, arg.Token, arg.Expiration)
+func (q *Queries) UpdateLoginThrottle(ctx context.Context, arg UpdateLoginThrottleParams) error {
+	_, err := q.db.ExecContext(ctx, updateLoginThrottle,
+		arg.Key,
+		arg.Failures,
+		arg.LastFailure,
+		arg.LockedUntil,
+	)
 	return err
 }
 
@@ -291,9 +2762,101 @@ func (q *Queries) UpdatePassword(ctx context.Context, arg UpdatePasswordParams)
 	return err
 }
 
+const updatePersonalRecord = `-- name: UpdatePersonalRecord :one
+update personal_records
+set value = $2, weight = $3, repetitions = $4, set_id = $5
+where id = $1
+returning id, user_id, definition_id, record_type, value, previous_value, weight, repetitions, distance_meters, duration_seconds, workout_id, set_id, achieved_at
+`
+
+type UpdatePersonalRecordParams struct {
+	ID          int32
+	Value       float64
+	Weight      sql.NullFloat64
+	Repetitions sql.NullInt32
+	SetID       sql.NullInt32
+}
+
+func (q *Queries) UpdatePersonalRecord(ctx context.Context, arg UpdatePersonalRecordParams) (PersonalRecord, error) {
+	row := q.db.QueryRowContext(ctx, updatePersonalRecord,
+		arg.ID,
+		arg.Value,
+		arg.Weight,
+		arg.Repetitions,
+		arg.SetID,
+	)
+	var i PersonalRecord
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.DefinitionID,
+		&i.RecordType,
+		&i.Value,
+		&i.PreviousValue,
+		&i.Weight,
+		&i.Repetitions,
+		&i.DistanceMeters,
+		&i.DurationSeconds,
+		&i.WorkoutID,
+		&i.SetID,
+		&i.AchievedAt,
+	)
+	return i, err
+}
+
+const updateSet = `-- name: UpdateSet :one
+update sets
+set repetitions = $3, weight = $4, duration_seconds = $5, distance_meters = $6, rpe = $7, rest_seconds = $8
+where id = $1 and exercise_id = $2
+returning id, exercise_id, position, repetitions, weight, duration_seconds, distance_meters, rpe, rest_seconds, create_at, target_reps_min, target_reps_max, target_weight_min, target_weight_max
+`
+
+type UpdateSetParams struct {
+	ID              int32
+	ExerciseID      int32
+	Repetitions     sql.NullInt32
+	Weight          sql.NullFloat64
+	DurationSeconds sql.NullInt32
+	DistanceMeters  sql.NullFloat64
+	Rpe             sql.NullFloat64
+	RestSeconds     sql.NullInt32
+}
+
+func (q *Queries) UpdateSet(ctx context.Context, arg UpdateSetParams) (Set, error) {
+	row := q.db.QueryRowContext(ctx, updateSet,
+		arg.ID,
+		arg.ExerciseID,
+		arg.Repetitions,
+		arg.Weight,
+		arg.DurationSeconds,
+		arg.DistanceMeters,
+		arg.Rpe,
+		arg.RestSeconds,
+	)
+	var i Set
+	err := row.Scan(
+		&i.ID,
+		&i.ExerciseID,
+		&i.Position,
+		&i.Repetitions,
+		&i.Weight,
+		&i.DurationSeconds,
+		&i.DistanceMeters,
+		&i.Rpe,
+		&i.RestSeconds,
+		&i.CreateAt,
+		&i.TargetRepsMin,
+		&i.TargetRepsMax,
+		&i.TargetWeightMin,
+		&i.TargetWeightMax,
+	)
+	return i, err
+}
+
 const updateUser = `-- name: UpdateUser :exec
 update users
-set username = $2, email = $3, profile = $4
+set username = $2, email = $3, profile = $4,
+    email_verified_at = case when email = $3 then email_verified_at end
 where id = $1
 `
 
@@ -314,9 +2877,25 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) error {
 	return err
 }
 
-const updateWorkout = `-- name: UpdateWorkout :exec
+const updateUserRole = `-- name: UpdateUserRole :exec
+update users
+set role = $2
+where id = $1
+`
+
+type UpdateUserRoleParams struct {
+	ID   int32
+	Role string
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserRole, arg.ID, arg.Role)
+	return err
+}
+
+const updateWorkout = `-- name: UpdateWorkout :execrows
 update workouts
-set name = $3, description = $4, date = $5, update_at = now()
+set name = $3, description = $4, date = $5, started_at = $6, ended_at = $7, timezone = $8, update_at = now()
 where id = $1 and user_id = $2
 `
 
@@ -326,15 +2905,167 @@ type UpdateWorkoutParams struct {
 	Name        string
 	Description sql.NullString
 	Date        time.Time
+	StartedAt   time.Time
+	EndedAt     sql.NullTime
+	Timezone    string
 }
 
-func (q *Queries) UpdateWorkout(ctx context.Context, arg UpdateWorkoutParams) error {
-	_, err := q.db.ExecContext(ctx, updateWorkout,
+func (q *Queries) UpdateWorkout(ctx context.Context, arg UpdateWorkoutParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateWorkout,
 		arg.ID,
 		arg.UserID,
 		arg.Name,
 		arg.Description,
 		arg.Date,
+		arg.StartedAt,
+		arg.EndedAt,
+		arg.Timezone,
 	)
-	return err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateWorkoutTemplate = `-- name: UpdateWorkoutTemplate :execrows
+update workout_templates
+set name = $3, description = $4, update_at = now()
+where id = $1 and user_id = $2
+`
+
+type UpdateWorkoutTemplateParams struct {
+	ID          int32
+	UserID      int32
+	Name        string
+	Description sql.NullString
+}
+
+func (q *Queries) UpdateWorkoutTemplate(ctx context.Context, arg UpdateWorkoutTemplateParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateWorkoutTemplate,
+		arg.ID,
+		arg.UserID,
+		arg.Name,
+		arg.Description,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const useEmailVerificationToken = `-- name: UseEmailVerificationToken :one
+update email_verification_tokens
+set used_at = now()
+where token_hash = $1 and used_at is null and expires_at > now()
+returning id, user_id, email, token_hash, expires_at, used_at, create_at
+`
+
+func (q *Queries) UseEmailVerificationToken(ctx context.Context, tokenHash string) (EmailVerificationToken, error) {
+	row := q.db.QueryRowContext(ctx, useEmailVerificationToken, tokenHash)
+	var i EmailVerificationToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Email,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreateAt,
+	)
+	return i, err
+}
+
+const usePasswordResetToken = `-- name: UsePasswordResetToken :one
+delete from password_reset_tokens
+where token_hash = $1 and expires_at > now()
+returning id, user_id, token_hash, expires_at, create_at
+`
+
+func (q *Queries) UsePasswordResetToken(ctx context.Context, tokenHash string) (PasswordResetToken, error) {
+	row := q.db.QueryRowContext(ctx, usePasswordResetToken, tokenHash)
+	var i PasswordResetToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.CreateAt,
+	)
+	return i, err
+}
+
+const useRecoveryCode = `-- name: UseRecoveryCode :execrows
+delete from totp_recovery_codes
+where user_id = $1 and code_hash = $2
+`
+
+type UseRecoveryCodeParams struct {
+	UserID   int32
+	CodeHash string
+}
+
+func (q *Queries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useRecoveryCode, arg.UserID, arg.CodeHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const useTOTPStep = `-- name: UseTOTPStep :execrows
+update users
+set totp_last_step = $2
+where id = $1 and totp_enabled_at is not null
+  and (totp_last_step is null or totp_last_step < $2)
+`
+
+type UseTOTPStepParams struct {
+	ID           int32
+	TotpLastStep sql.NullInt64
+}
+
+func (q *Queries) UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useTOTPStep, arg.ID, arg.TotpLastStep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const usernameTaken = `-- name: UsernameTaken :one
+select exists (
+    select 1 from users
+    where lower(username) = lower($1) and id <> $2::int
+)
+`
+
+type UsernameTakenParams struct {
+	Lower    string
+	ExceptID int32
+}
+
+func (q *Queries) UsernameTaken(ctx context.Context, arg UsernameTakenParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, usernameTaken, arg.Lower, arg.ExceptID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const verifyUserEmail = `-- name: VerifyUserEmail :execrows
+update users
+set email_verified_at = now()
+where id = $1 and email = $2 and email_verified_at is null
+`
+
+type VerifyUserEmailParams struct {
+	ID    int32
+	Email string
+}
+
+func (q *Queries) VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, verifyUserEmail, arg.ID, arg.Email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }